@@ -1,34 +1,113 @@
 package config
 
 import (
+	"log/slog"
 	"os"
 	"strconv"
+	"strings"
 	"time"
 )
 
 // Config contains application configuration vars
 type Config struct {
 	ServerPort   int
+	GRPCPort     int
 	ReadTimeout  time.Duration
 	WriteTimeout time.Duration
 	IdleTimeout  time.Duration
+
+	// Request limits, a zero value disables the limit
+	MaxBodyBytes         int64
+	MaxBookings          int
+	MaxHeuristicBookings int
+	MaxOptimizerTime     time.Duration
+
+	// Asynchronous optimization jobs
+	JobWorkers      int
+	JobQueueSize    int
+	JobTimeout      time.Duration
+	MaxJobBookings  int
+	JobRetention    time.Duration
+	MaxFinishedJobs int
+
+	// Aliases of the CSV columns, mapping a header name to the booking field it holds
+	CSVHeaders map[string]string
+
+	// Minimum level of the logged records
+	LogLevel slog.Level
+
+	// Exporter of the trace spans: none, stdout or otlp
+	TracesExporter string
 }
 
 // Load loads configuration from env vars
 func Load() *Config {
 	serverPort, _ := strconv.Atoi(getEnv("SERVER_PORT", "8080"))
+	grpcPort, _ := strconv.Atoi(getEnv("GRPC_PORT", "9090"))
 	readTimeout, _ := strconv.Atoi(getEnv("READ_TIMEOUT", "15"))
 	writeTimeout, _ := strconv.Atoi(getEnv("WRITE_TIMEOUT", "15"))
 	idleTimeout, _ := strconv.Atoi(getEnv("IDLE_TIMEOUT", "60"))
+	maxBodyBytes, _ := strconv.ParseInt(getEnv("MAX_BODY_BYTES", "1048576"), 10, 64)
+	maxBookings, _ := strconv.Atoi(getEnv("MAX_BOOKINGS", "20"))
+	maxHeuristicBookings, _ := strconv.Atoi(getEnv("MAX_HEURISTIC_BOOKINGS", "1000"))
+	maxOptimizerTime, _ := strconv.Atoi(getEnv("MAX_OPTIMIZER_TIME", "10"))
+	jobWorkers, _ := strconv.Atoi(getEnv("JOB_WORKERS", "2"))
+	jobQueueSize, _ := strconv.Atoi(getEnv("JOB_QUEUE_SIZE", "100"))
+	jobTimeout, _ := strconv.Atoi(getEnv("JOB_TIMEOUT", "600"))
+	maxJobBookings, _ := strconv.Atoi(getEnv("MAX_JOB_BOOKINGS", "25"))
+	jobRetention, _ := strconv.Atoi(getEnv("JOB_RETENTION", "3600"))
+	maxFinishedJobs, _ := strconv.Atoi(getEnv("MAX_FINISHED_JOBS", "1000"))
 
 	return &Config{
 		ServerPort:   serverPort,
+		GRPCPort:     grpcPort,
 		ReadTimeout:  time.Duration(readTimeout) * time.Second,
 		WriteTimeout: time.Duration(writeTimeout) * time.Second,
 		IdleTimeout:  time.Duration(idleTimeout) * time.Second,
+
+		MaxBodyBytes:         maxBodyBytes,
+		MaxBookings:          maxBookings,
+		MaxHeuristicBookings: maxHeuristicBookings,
+		MaxOptimizerTime:     time.Duration(maxOptimizerTime) * time.Second,
+
+		JobWorkers:      jobWorkers,
+		JobQueueSize:    jobQueueSize,
+		JobTimeout:      time.Duration(jobTimeout) * time.Second,
+		MaxJobBookings:  maxJobBookings,
+		JobRetention:    time.Duration(jobRetention) * time.Second,
+		MaxFinishedJobs: maxFinishedJobs,
+
+		CSVHeaders: parseMapping(getEnv("CSV_HEADERS", "")),
+
+		LogLevel: parseLevel(getEnv("LOG_LEVEL", "info")),
+
+		TracesExporter: getEnv("TRACES_EXPORTER", "none"),
 	}
 }
 
+// parseLevel reads a log level name (debug, info, warn or error), falling back to info when unknown
+func parseLevel(value string) slog.Level {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(value)); err != nil {
+		return slog.LevelInfo
+	}
+
+	return level
+}
+
+// parseMapping reads a comma separated list of key=value pairs, skipping the malformed ones
+func parseMapping(value string) map[string]string {
+	mapping := make(map[string]string)
+	for _, pair := range strings.Split(value, ",") {
+		key, val, ok := strings.Cut(pair, "=")
+		if key, val = strings.TrimSpace(key), strings.TrimSpace(val); ok && key != "" && val != "" {
+			mapping[key] = val
+		}
+	}
+
+	return mapping
+}
+
 func getEnv(key, defaultValue string) string {
 	value := os.Getenv(key)
 	if value == "" {
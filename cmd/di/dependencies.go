@@ -1,16 +1,45 @@
 package di
 
-import "github.com/duksonn/stay-for-long/internal/application"
+import (
+	"context"
+	"log/slog"
+	"os"
+
+	"github.com/duksonn/stay-for-long/cmd/config"
+	"github.com/duksonn/stay-for-long/internal/application"
+	"github.com/duksonn/stay-for-long/internal/infra/memory"
+	"github.com/duksonn/stay-for-long/internal/infra/metrics"
+	"github.com/duksonn/stay-for-long/internal/infra/tracing"
+)
 
 // Dependencies list the use cases application services of the system
 type Dependencies struct {
+	Config   *config.Config
 	StatsSvc *application.StatsService
+	JobSvc   *application.JobService
+	Metrics  *metrics.Metrics
+	Logger   *slog.Logger
+	Tracing  *tracing.Tracing
 }
 
 // Init return the initialized dependencies of the system
-func Init() *Dependencies {
+// It fails when the traces exporter is unknown or cannot be created
+func Init(cfg *config.Config) (*Dependencies, error) {
+	// Stores
+	jobStore := memory.NewJobStore(cfg.JobRetention, cfg.MaxFinishedJobs)
+
+	// Observability
+	m := metrics.New()
+	logger := slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: cfg.LogLevel}))
+	t, err := tracing.New(context.Background(), cfg.TracesExporter)
+	if err != nil {
+		return nil, err
+	}
+
 	// Services
-	statsSvc := application.NewStatsService()
+	statsSvc := application.NewStatsService(application.WithObserver(m), application.WithTracerProvider(t))
+	jobSvc := application.NewJobService(jobStore, cfg.JobWorkers, cfg.JobQueueSize, cfg.JobTimeout,
+		application.WithObserver(m), application.WithTracerProvider(t))
 
-	return &Dependencies{StatsSvc: statsSvc}
+	return &Dependencies{Config: cfg, StatsSvc: statsSvc, JobSvc: jobSvc, Metrics: m, Logger: logger, Tracing: t}, nil
 }
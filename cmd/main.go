@@ -1,37 +1,99 @@
 package main
 
 import (
+	"context"
 	"errors"
-	"log"
+	"log/slog"
+	"net"
 	"net/http"
+	"os"
+	"os/signal"
 	"strconv"
+	"syscall"
+	"time"
+	_ "time/tzdata" // Embed the IANA database so property timezones resolve in minimal images
 
 	"github.com/duksonn/stay-for-long/cmd/config"
 	"github.com/duksonn/stay-for-long/cmd/di"
+	internalgrpc "github.com/duksonn/stay-for-long/internal/infra/grpc"
 	internalhttp "github.com/duksonn/stay-for-long/internal/infra/http"
 )
 
+// shutdownTimeout bounds the time given to the requests in flight and the span exporter on shutdown
+const shutdownTimeout = 10 * time.Second
+
 func main() {
 	cfg := config.Load()
 
-	deps := di.Init()
-	log.Printf("Dependencies init successfully")
+	deps, err := di.Init(cfg)
+	if err != nil {
+		fatal(slog.Default(), "Could not init dependencies", err)
+	}
+	logger := deps.Logger
+	slog.SetDefault(logger)
+	logger.Info("Dependencies init successfully")
 
 	router, err := internalhttp.Routes(deps)
 	if err != nil {
-		log.Fatalf("Could not start router: %v", err)
+		fatal(logger, "Could not start router", err)
 	}
 
+	grpcServer, err := internalgrpc.NewServer(deps)
+	if err != nil {
+		fatal(logger, "Could not start grpc server", err)
+	}
+	listener, err := net.Listen("tcp", ":"+strconv.Itoa(cfg.GRPCPort))
+	if err != nil {
+		fatal(logger, "Could not listen on grpc port", err, slog.Int("port", cfg.GRPCPort))
+	}
+	go func() {
+		logger.Info("Starting grpc server", slog.Int("port", cfg.GRPCPort))
+		if err := grpcServer.Serve(listener); err != nil {
+			fatal(logger, "Could not start grpc server", err)
+		}
+	}()
+
 	server := &http.Server{
 		Addr:         ":" + strconv.Itoa(cfg.ServerPort),
 		Handler:      router,
 		ReadTimeout:  cfg.ReadTimeout,
 		WriteTimeout: cfg.WriteTimeout,
 		IdleTimeout:  cfg.IdleTimeout,
+		ErrorLog:     slog.NewLogLogger(logger.Handler(), slog.LevelError),
 	}
 
-	log.Printf("Starting server on %v", cfg.ServerPort)
+	// Stop serving on SIGINT or SIGTERM, flushing the spans still buffered before exiting
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	drained := make(chan struct{})
+	go func() {
+		defer close(drained)
+		<-ctx.Done()
+		logger.Info("Shutting down")
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+		if err := server.Shutdown(shutdownCtx); err != nil {
+			logger.Error("Could not shut down server", slog.Any("error", err))
+		}
+		grpcServer.GracefulStop()
+	}()
+
+	logger.Info("Starting server", slog.Int("port", cfg.ServerPort))
 	if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
-		log.Fatalf("Could not start server: %v", err)
+		fatal(logger, "Could not start server", err)
+	}
+	<-drained
+
+	deps.JobSvc.Close()
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := deps.Tracing.Shutdown(shutdownCtx); err != nil {
+		logger.Error("Could not flush spans", slog.Any("error", err))
 	}
 }
+
+// fatal logs err at the error level and exits
+func fatal(logger *slog.Logger, msg string, err error, attrs ...any) {
+	logger.Error(msg, append(attrs, slog.Any("error", err))...)
+	os.Exit(1)
+}
@@ -0,0 +1,25 @@
+package main
+
+import (
+	"context"
+	"log"
+	"os"
+	"os/signal"
+	_ "time/tzdata" // Embed the IANA database so property timezones resolve on any host
+
+	"github.com/duksonn/stay-for-long/internal/application"
+	"github.com/duksonn/stay-for-long/internal/infra/cli"
+)
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+
+	app, err := cli.NewCLI(application.NewStatsService(), os.Stdin, os.Stdout, os.Stderr)
+	if err != nil {
+		log.Fatalf("Could not start cli: %v", err)
+	}
+	code := app.Run(ctx, os.Args[1:])
+
+	stop()
+	os.Exit(code)
+}
@@ -1 +1,3 @@
 mockgen --source=internal/ports/service.go --destination=internal/mocks/mock_service.go --package=mocks
+mockgen --source=internal/ports/job_store.go --destination=internal/mocks/mock_job_store.go --package=mocks
+mockgen --source=internal/ports/observer.go --destination=internal/mocks/mock_observer.go --package=mocks
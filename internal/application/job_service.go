@@ -0,0 +1,334 @@
+package application
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"math"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/duksonn/stay-for-long/internal/domain"
+	"github.com/duksonn/stay-for-long/internal/ports"
+)
+
+// ErrJobServiceClosed is returned when a job is submitted after the job service was closed
+var ErrJobServiceClosed = errors.New("job service is closed")
+
+// Ensure JobService implements the ports.JobService interface
+var _ ports.JobService = (*JobService)(nil)
+
+// JobService implements the ports.JobService interface and runs MaximizeProfit jobs on a bounded worker pool
+// Jobs are kept in a ports.JobStore, so their state can be polled from any instance sharing the store,
+// while watchers are notified by the instance running the job
+type JobService struct {
+	options
+	store   ports.JobStore
+	timeout time.Duration
+	queue   chan jobTask
+	ctx     context.Context
+	stop    context.CancelFunc
+	workers sync.WaitGroup
+
+	mu       sync.Mutex
+	closed   bool
+	cancels  map[string]context.CancelFunc
+	watchers map[string][]chan *domain.Job
+}
+
+// jobTask is the input of a queued job
+type jobTask struct {
+	id       string
+	requests domain.Bookings
+	opts     domain.MaximizeOptions
+	// link points to the span that submitted the job, the job running in a trace of its own
+	link trace.Link
+}
+
+// NewJobService creates a JobService and starts its workers
+// At most queueSize jobs wait for one of the workers, and each job is aborted once it runs longer than
+// timeout. A zero or negative timeout lets jobs run until they finish or are canceled
+func NewJobService(store ports.JobStore, workers, queueSize int, timeout time.Duration, opts ...Option) *JobService {
+	ctx, stop := context.WithCancel(context.Background())
+	s := &JobService{
+		options:  newOptions(opts),
+		store:    store,
+		timeout:  timeout,
+		queue:    make(chan jobTask, max(queueSize, 1)),
+		ctx:      ctx,
+		stop:     stop,
+		cancels:  make(map[string]context.CancelFunc),
+		watchers: make(map[string][]chan *domain.Job),
+	}
+	for range max(workers, 1) {
+		s.workers.Add(1)
+		go s.work()
+	}
+
+	return s
+}
+
+// Submit queues a MaximizeProfit run and returns the queued job
+func (s *JobService) Submit(ctx context.Context, requests domain.Bookings, opts domain.MaximizeOptions) (*domain.Job, error) {
+	id, err := newJobID()
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return nil, ErrJobServiceClosed
+	}
+	// only Submit sends to the queue and it holds the lock, so the queue cannot fill up after this check
+	if len(s.queue) == cap(s.queue) {
+		return nil, domain.ErrJobQueueFull
+	}
+
+	now := time.Now()
+	job := &domain.Job{ID: id, Status: domain.JobQueued, CreatedAt: now, UpdatedAt: now}
+	if err := s.save(ctx, job); err != nil {
+		return nil, err
+	}
+	s.queue <- jobTask{id: id, requests: requests, opts: opts, link: trace.LinkFromContext(ctx)}
+
+	return job, nil
+}
+
+// Get returns the current state of a job
+func (s *JobService) Get(ctx context.Context, id string) (*domain.Job, error) {
+	return s.store.Get(ctx, id)
+}
+
+// Cancel marks a queued or running job as canceled and stops its worker
+func (s *JobService) Cancel(ctx context.Context, id string) (*domain.Job, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	job, err := s.store.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if job.Status.Finished() {
+		return nil, domain.ErrJobFinished
+	}
+
+	job.Status = domain.JobCanceled
+	job.UpdatedAt = time.Now()
+	if err := s.save(ctx, job); err != nil {
+		return nil, err
+	}
+	if cancel, ok := s.cancels[id]; ok {
+		cancel()
+	}
+
+	return job, nil
+}
+
+// Watch streams the state of a job every time it changes, starting with its current state
+// Watchers that fall behind only receive the latest state. The channel is closed once the job
+// finishes or ctx is done
+func (s *JobService) Watch(ctx context.Context, id string) (<-chan *domain.Job, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	job, err := s.store.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	ch := make(chan *domain.Job, 1)
+	ch <- job
+	if job.Status.Finished() {
+		close(ch)
+		return ch, nil
+	}
+
+	s.watchers[id] = append(s.watchers[id], ch)
+	context.AfterFunc(ctx, func() {
+		s.unwatch(id, ch)
+	})
+
+	return ch, nil
+}
+
+// Close stops accepting jobs, cancels the running ones and waits for the workers to exit
+// Jobs still queued are marked as canceled
+func (s *JobService) Close() {
+	s.mu.Lock()
+	if !s.closed {
+		s.closed = true
+		close(s.queue)
+	}
+	s.mu.Unlock()
+
+	s.stop()
+	s.workers.Wait()
+}
+
+// work runs the queued jobs until the queue is closed
+func (s *JobService) work() {
+	defer s.workers.Done()
+	for task := range s.queue {
+		s.run(task)
+	}
+}
+
+// run executes a single job and records its outcome
+func (s *JobService) run(task jobTask) {
+	var ctx context.Context
+	var cancel context.CancelFunc
+	if s.timeout > 0 {
+		ctx, cancel = context.WithTimeout(s.ctx, s.timeout)
+	} else {
+		ctx, cancel = context.WithCancel(s.ctx)
+	}
+	defer cancel()
+
+	if !s.start(task.id, cancel) {
+		return
+	}
+
+	attrs := append(optionAttributes(task.opts), attrJobID.String(task.id), attrBookings.Int(len(task.requests)))
+	ctx, span := s.tracer.Start(ctx, "JobService.Run", trace.WithLinks(task.link), trace.WithAttributes(attrs...))
+	opts := s.observe(OperationJob, task.requests, task.opts)
+	opts.OnProgress = s.progress(task.id)
+	result, err := domain.MaximizeProfit(ctx, task.requests, opts)
+	endSpan(span, err, selectionAttributes(result)...)
+	s.finish(task.id, result, err)
+}
+
+// start moves a queued job to running and registers its cancel function
+// It reports false when the job must not run, because it was canceled or the service is closing
+func (s *JobService) start(id string, cancel context.CancelFunc) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	job, err := s.store.Get(context.Background(), id)
+	if err != nil || job.Status != domain.JobQueued {
+		return false
+	}
+	job.Status = domain.JobRunning
+	if s.ctx.Err() != nil {
+		job.Status = domain.JobCanceled
+	}
+	job.StartedAt = time.Now()
+	job.UpdatedAt = job.StartedAt
+	if err := s.save(context.Background(), job); err != nil || job.Status != domain.JobRunning {
+		return false
+	}
+	s.cancels[id] = cancel
+
+	return true
+}
+
+// progress returns a domain.ProgressFunc that records the progress of a running job
+// The store is only written when the progress grows by at least one percentage point
+// or a better selection is found
+func (s *JobService) progress(id string) domain.ProgressFunc {
+	last, lastProfit := 0.0, 0.0
+	return func(p domain.SearchProgress) {
+		progress := math.Floor(float64(p.Evaluated)/float64(p.Total)*100) / 100
+		if progress <= last && p.BestProfit == lastProfit {
+			return
+		}
+		last, lastProfit = progress, p.BestProfit
+
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		job, err := s.store.Get(context.Background(), id)
+		if err != nil || job.Status != domain.JobRunning {
+			return
+		}
+		job.Progress = progress
+		job.BestProfit = p.BestProfit
+		job.UpdatedAt = time.Now()
+		_ = s.save(context.Background(), job)
+	}
+}
+
+// finish records the outcome of a job, unless it was canceled meanwhile
+func (s *JobService) finish(id string, result *domain.MaximizeResult, runErr error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.cancels, id)
+
+	job, err := s.store.Get(context.Background(), id)
+	if err != nil || job.Status != domain.JobRunning {
+		return
+	}
+	switch {
+	case runErr == nil:
+		job.Status = domain.JobSucceeded
+		job.Progress = 1
+		job.BestProfit = result.TotalProfit
+		job.Result = result
+	case errors.Is(runErr, context.Canceled):
+		job.Status = domain.JobCanceled
+	default:
+		job.Status = domain.JobFailed
+		job.Error = runErr.Error()
+	}
+	job.UpdatedAt = time.Now()
+	_ = s.save(context.Background(), job)
+}
+
+// save stores the job and notifies its watchers, closing their channels once the job is finished
+// It must be called with s.mu held
+func (s *JobService) save(ctx context.Context, job *domain.Job) error {
+	if err := s.store.Save(ctx, job); err != nil {
+		return err
+	}
+
+	for _, ch := range s.watchers[job.ID] {
+		sendLatest(ch, job)
+		if job.Status.Finished() {
+			close(ch)
+		}
+	}
+	if job.Status.Finished() {
+		delete(s.watchers, job.ID)
+	}
+
+	return nil
+}
+
+// unwatch removes a watcher and closes its channel, unless the job already finished and closed it
+func (s *JobService) unwatch(id string, ch chan *domain.Job) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	watchers := s.watchers[id]
+	for i, watcher := range watchers {
+		if watcher == ch {
+			s.watchers[id] = append(watchers[:i], watchers[i+1:]...)
+			close(ch)
+			break
+		}
+	}
+	if len(s.watchers[id]) == 0 {
+		delete(s.watchers, id)
+	}
+}
+
+// sendLatest replaces the job waiting in a watcher channel, so slow watchers only miss intermediate states
+func sendLatest(ch chan *domain.Job, job *domain.Job) {
+	select {
+	case <-ch:
+	default:
+	}
+	latest := *job
+	ch <- &latest
+}
+
+// newJobID generates a random job identifier
+func newJobID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,224 @@
+package application_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"github.com/duksonn/stay-for-long/internal/application"
+	"github.com/duksonn/stay-for-long/internal/domain"
+	"github.com/duksonn/stay-for-long/internal/infra/memory"
+)
+
+// slowBookings returns enough non-overlapping bookings to keep a worker busy until it is canceled
+func slowBookings() domain.Bookings {
+	baseTime := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	bookings := make(domain.Bookings, 0, 30)
+	for i := range 30 {
+		bookings = append(bookings, &domain.Booking{
+			RequestID:   fmt.Sprintf("req%02d", i),
+			CheckIn:     baseTime.AddDate(0, 0, i*2),
+			Nights:      1,
+			SellingRate: 100,
+			Margin:      10,
+		})
+	}
+
+	return bookings
+}
+
+// waitForStatus polls the job until it reaches the expected status
+func waitForStatus(t *testing.T, service *application.JobService, id string, status domain.JobStatus) *domain.Job {
+	t.Helper()
+	var job *domain.Job
+	require.Eventually(t, func() bool {
+		var err error
+		job, err = service.Get(context.Background(), id)
+		require.NoError(t, err)
+		return job.Status == status
+	}, 5*time.Second, time.Millisecond)
+
+	return job
+}
+
+func TestJobService_Submit(t *testing.T) {
+	service := application.NewJobService(memory.NewJobStore(0, 0), 1, 10, time.Minute)
+	defer service.Close()
+
+	baseTime := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	bookings := domain.Bookings{
+		{RequestID: "req1", CheckIn: baseTime, Nights: 3, SellingRate: 1000, Margin: 20},
+		{RequestID: "req2", CheckIn: baseTime.AddDate(0, 0, 2), Nights: 3, SellingRate: 2000, Margin: 25},
+	}
+
+	job, err := service.Submit(context.Background(), bookings, domain.MaximizeOptions{})
+	require.NoError(t, err)
+	assert.NotEmpty(t, job.ID)
+	assert.Equal(t, domain.JobQueued, job.Status)
+
+	job = waitForStatus(t, service, job.ID, domain.JobSucceeded)
+	assert.Equal(t, float64(1), job.Progress)
+	require.NotNil(t, job.Result)
+	assert.Equal(t, []string{"req2"}, job.Result.RequestIDs)
+
+	_, err = service.Cancel(context.Background(), job.ID)
+	assert.ErrorIs(t, err, domain.ErrJobFinished)
+}
+
+func TestJobService_Cancel(t *testing.T) {
+	service := application.NewJobService(memory.NewJobStore(0, 0), 1, 10, time.Minute)
+	defer service.Close()
+
+	running, err := service.Submit(context.Background(), slowBookings(), domain.MaximizeOptions{})
+	require.NoError(t, err)
+	queued, err := service.Submit(context.Background(), slowBookings(), domain.MaximizeOptions{})
+	require.NoError(t, err)
+	waitForStatus(t, service, running.ID, domain.JobRunning)
+
+	for _, id := range []string{queued.ID, running.ID} {
+		job, err := service.Cancel(context.Background(), id)
+		require.NoError(t, err)
+		assert.Equal(t, domain.JobCanceled, job.Status)
+	}
+
+	// the worker gives up the canceled job and skips the queued one
+	job := waitForStatus(t, service, running.ID, domain.JobCanceled)
+	assert.Nil(t, job.Result)
+	waitForStatus(t, service, queued.ID, domain.JobCanceled)
+}
+
+func TestJobService_Timeout(t *testing.T) {
+	service := application.NewJobService(memory.NewJobStore(0, 0), 1, 10, 10*time.Millisecond)
+	defer service.Close()
+
+	job, err := service.Submit(context.Background(), slowBookings(), domain.MaximizeOptions{})
+	require.NoError(t, err)
+
+	job = waitForStatus(t, service, job.ID, domain.JobFailed)
+	assert.Contains(t, job.Error, context.DeadlineExceeded.Error())
+	assert.Less(t, job.Progress, float64(1))
+}
+
+func TestJobService_QueueFull(t *testing.T) {
+	service := application.NewJobService(memory.NewJobStore(0, 0), 1, 1, time.Minute)
+	defer service.Close()
+
+	running, err := service.Submit(context.Background(), slowBookings(), domain.MaximizeOptions{})
+	require.NoError(t, err)
+	waitForStatus(t, service, running.ID, domain.JobRunning)
+
+	_, err = service.Submit(context.Background(), slowBookings(), domain.MaximizeOptions{})
+	require.NoError(t, err)
+	_, err = service.Submit(context.Background(), slowBookings(), domain.MaximizeOptions{})
+	assert.ErrorIs(t, err, domain.ErrJobQueueFull)
+}
+
+func TestJobService_Closed(t *testing.T) {
+	service := application.NewJobService(memory.NewJobStore(0, 0), 1, 1, time.Minute)
+	service.Close()
+
+	_, err := service.Submit(context.Background(), slowBookings(), domain.MaximizeOptions{})
+	assert.ErrorIs(t, err, application.ErrJobServiceClosed)
+
+	_, err = service.Get(context.Background(), "unknown")
+	assert.ErrorIs(t, err, domain.ErrJobNotFound)
+}
+
+func TestJobService_Watch(t *testing.T) {
+	service := application.NewJobService(memory.NewJobStore(0, 0), 1, 10, time.Minute)
+	defer service.Close()
+
+	_, err := service.Watch(context.Background(), "unknown")
+	assert.ErrorIs(t, err, domain.ErrJobNotFound)
+
+	bookings := slowBookings()[:16]
+	job, err := service.Submit(context.Background(), bookings, domain.MaximizeOptions{})
+	require.NoError(t, err)
+	events, err := service.Watch(context.Background(), job.ID)
+	require.NoError(t, err)
+
+	var last *domain.Job
+	progress := 0.0
+	for event := range events {
+		assert.GreaterOrEqual(t, event.Progress, progress)
+		progress = event.Progress
+		last = event
+	}
+	require.NotNil(t, last)
+	assert.Equal(t, domain.JobSucceeded, last.Status)
+	assert.Equal(t, float64(160), last.BestProfit)
+	require.NotNil(t, last.Result)
+	assert.Len(t, last.Result.RequestIDs, 16)
+
+	// watching a finished job yields its final state
+	events, err = service.Watch(context.Background(), job.ID)
+	require.NoError(t, err)
+	assert.Equal(t, domain.JobSucceeded, (<-events).Status)
+	_, open := <-events
+	assert.False(t, open)
+}
+
+func TestJobService_Watch_ContextDone(t *testing.T) {
+	service := application.NewJobService(memory.NewJobStore(0, 0), 1, 10, time.Minute)
+	defer service.Close()
+
+	job, err := service.Submit(context.Background(), slowBookings(), domain.MaximizeOptions{})
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	events, err := service.Watch(ctx, job.ID)
+	require.NoError(t, err)
+	cancel()
+
+	require.Eventually(t, func() bool {
+		for {
+			select {
+			case _, open := <-events:
+				if !open {
+					return true
+				}
+			default:
+				return false
+			}
+		}
+	}, time.Second, time.Millisecond)
+}
+
+func TestJobService_Tracing(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	service := application.NewJobService(memory.NewJobStore(0, 0), 1, 10, time.Minute, application.WithTracerProvider(tp))
+	defer service.Close()
+
+	baseTime := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	bookings := domain.Bookings{
+		{RequestID: "req1", CheckIn: baseTime, Nights: 3, SellingRate: 1000, Margin: 20},
+		{RequestID: "req2", CheckIn: baseTime.AddDate(0, 0, 2), Nights: 3, SellingRate: 2000, Margin: 25},
+	}
+
+	ctx, submit := tp.Tracer("test").Start(context.Background(), "submit")
+	job, err := service.Submit(ctx, bookings, domain.MaximizeOptions{})
+	require.NoError(t, err)
+	submit.End()
+	waitForStatus(t, service, job.ID, domain.JobSucceeded)
+
+	var run tracetest.SpanStub
+	for _, span := range exporter.GetSpans() {
+		if span.Name == "JobService.Run" {
+			run = span
+		}
+	}
+	require.Equal(t, "JobService.Run", run.Name)
+	assert.False(t, run.Parent.IsValid(), "jobs run in a trace of their own")
+	require.Len(t, run.Links, 1)
+	assert.Equal(t, submit.SpanContext(), run.Links[0].SpanContext)
+	assert.Contains(t, run.Attributes, attribute.String("job.id", job.ID))
+	assert.Contains(t, run.Attributes, attribute.Int("bookings.selected", 1))
+}
@@ -0,0 +1,72 @@
+package application
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+
+	"github.com/duksonn/stay-for-long/internal/domain"
+	"github.com/duksonn/stay-for-long/internal/ports"
+)
+
+// Operations reported to the ports.OptimizerObserver
+const (
+	OperationStats       = "stats"
+	OperationMaximize    = "maximize"
+	OperationPareto      = "pareto"
+	OperationSensitivity = "sensitivity"
+	OperationScenarios   = "scenarios"
+	OperationJob         = "job"
+)
+
+// Option configures the optional behaviour of an application service
+type Option func(*options)
+
+// options holds the optional behaviour shared by the application services
+type options struct {
+	observer ports.OptimizerObserver
+	tracer   trace.Tracer
+}
+
+// WithObserver reports the bookings every operation is called with and the optimizer searches it runs
+func WithObserver(observer ports.OptimizerObserver) Option {
+	return func(o *options) {
+		o.observer = observer
+	}
+}
+
+// newOptions applies opts over the default options
+func newOptions(opts []Option) options {
+	o := options{tracer: noop.NewTracerProvider().Tracer(tracerName)}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	return o
+}
+
+// observeBookings reports the bookings an operation is called with
+func (o options) observeBookings(operation string, requests domain.Bookings) {
+	if o.observer != nil {
+		o.observer.ObserveBookings(operation, len(requests))
+	}
+}
+
+// observe reports the bookings an operation is called with and returns opts reporting its searches too,
+// and tracing the phases of the optimizer, keeping the hooks already set
+func (o options) observe(operation string, requests domain.Bookings, opts domain.MaximizeOptions) domain.MaximizeOptions {
+	opts = o.trace(opts)
+	if o.observer == nil {
+		return opts
+	}
+
+	o.observer.ObserveBookings(operation, len(requests))
+	next := opts.OnSearchDone
+	opts.OnSearchDone = func(ctx context.Context, stats domain.SearchStats) {
+		o.observer.ObserveSearch(stats)
+		next(ctx, stats)
+	}
+
+	return opts
+}
@@ -1,6 +1,8 @@
 package application
 
 import (
+	"context"
+
 	"github.com/duksonn/stay-for-long/internal/domain"
 	"github.com/duksonn/stay-for-long/internal/ports"
 )
@@ -10,20 +12,70 @@ var _ ports.StatsService = (*StatsService)(nil)
 
 // StatsService implements the ports.StatsService interface and provides stats management functionality
 // It handles the business logic for calculating booking statistics and maximizing profit
-type StatsService struct{}
+type StatsService struct {
+	options
+}
 
 // NewStatsService creates and returns a new instance of StatsService
-func NewStatsService() *StatsService {
-	return &StatsService{}
+func NewStatsService(opts ...Option) *StatsService {
+	return &StatsService{options: newOptions(opts)}
 }
 
 // CalculateStats computes the average, minimum, and maximum nightly rates for a set of bookings
-func (s StatsService) CalculateStats(requests domain.Bookings) *domain.StatsResult {
-	return requests.CalculateStats()
+func (s StatsService) CalculateStats(ctx context.Context, requests domain.Bookings) (*domain.StatsResult, error) {
+	_, span := s.startSpan(ctx, "StatsService.CalculateStats", requests)
+	if err := ctx.Err(); err != nil {
+		endSpan(span, err)
+		return nil, err
+	}
+	s.observeBookings(OperationStats, requests)
+	endSpan(span, nil)
+
+	return requests.CalculateStats(), nil
 }
 
-// MaximizeProfit finds the optimal combination of bookings that maximizes total profit
+// MaximizeProfit finds the optimal combination of bookings that maximizes the objective in opts
 // while ensuring no booking periods overlap
-func (s StatsService) MaximizeProfit(requests domain.Bookings) *domain.MaximizeResult {
-	return domain.MaximizeProfit(requests)
+func (s StatsService) MaximizeProfit(ctx context.Context, requests domain.Bookings, opts domain.MaximizeOptions) (*domain.MaximizeResult, error) {
+	ctx, span := s.startSpan(ctx, "StatsService.MaximizeProfit", requests, optionAttributes(opts)...)
+	result, err := domain.MaximizeProfit(ctx, requests, s.observe(OperationMaximize, requests, opts))
+	endSpan(span, err, selectionAttributes(result)...)
+
+	return result, err
+}
+
+// ParetoFrontier returns the non-overlapping selections that are not dominated
+// when trading total profit against occupied nights
+func (s StatsService) ParetoFrontier(ctx context.Context, requests domain.Bookings) ([]*domain.MaximizeResult, error) {
+	ctx, span := s.startSpan(ctx, "StatsService.ParetoFrontier", requests)
+	s.observeBookings(OperationPareto, requests)
+	frontier, err := domain.ParetoFrontier(ctx, requests)
+	endSpan(span, err, attrSelections.Int(len(frontier)))
+
+	return frontier, err
+}
+
+// Sensitivity computes the optimal selection and reports, for each booking, how far its selling rate
+// or margin can move before that selection changes
+func (s StatsService) Sensitivity(ctx context.Context, requests domain.Bookings, opts domain.MaximizeOptions) (*domain.SensitivityResult, error) {
+	ctx, span := s.startSpan(ctx, "StatsService.Sensitivity", requests, optionAttributes(opts)...)
+	result, err := domain.Sensitivity(ctx, requests, s.observe(OperationSensitivity, requests, opts))
+	if err != nil {
+		endSpan(span, err)
+		return nil, err
+	}
+	endSpan(span, nil, selectionAttributes(result.Selection)...)
+
+	return result, nil
+}
+
+// CompareScenarios evaluates the base bookings and every what-if scenario, returning the optimal
+// selection and stats of each one together with its differences against the base
+func (s StatsService) CompareScenarios(ctx context.Context, base domain.Bookings, scenarios []domain.Scenario, opts domain.MaximizeOptions) (*domain.ScenarioComparison, error) {
+	attrs := append(optionAttributes(opts), attrScenarios.Int(len(scenarios)))
+	ctx, span := s.startSpan(ctx, "StatsService.CompareScenarios", base, attrs...)
+	comparison, err := domain.CompareScenarios(ctx, base, scenarios, s.observe(OperationScenarios, base, opts))
+	endSpan(span, err)
+
+	return comparison, err
 }
@@ -1,14 +1,23 @@
 package application_test
 
 import (
+	"context"
+	"slices"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/mock/gomock"
 
 	"github.com/duksonn/stay-for-long/internal/application"
 	"github.com/duksonn/stay-for-long/internal/domain"
+	"github.com/duksonn/stay-for-long/internal/mocks"
 )
 
 func TestStatsService_CalculateStats(t *testing.T) {
@@ -73,7 +82,8 @@ func TestStatsService_CalculateStats(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			result := service.CalculateStats(tt.bookings)
+			result, err := service.CalculateStats(context.Background(), tt.bookings)
+			require.NoError(t, err)
 			require.NotNil(t, result)
 
 			assert.Equal(t, tt.expected.AvgNight, result.AvgNight)
@@ -166,7 +176,8 @@ func TestStatsService_MaximizeProfit(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			result := service.MaximizeProfit(tt.bookings)
+			result, err := service.MaximizeProfit(context.Background(), tt.bookings, domain.MaximizeOptions{})
+			require.NoError(t, err)
 			require.NotNil(t, result)
 
 			assert.Equal(t, tt.expected.RequestIDs, result.RequestIDs)
@@ -177,3 +188,221 @@ func TestStatsService_MaximizeProfit(t *testing.T) {
 		})
 	}
 }
+
+func TestStatsService_ParetoFrontier(t *testing.T) {
+	baseTime := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	service := application.NewStatsService()
+
+	bookings := domain.Bookings{
+		{RequestID: "long", CheckIn: baseTime, Nights: 10, SellingRate: 2000, Margin: 5},
+		{RequestID: "short1", CheckIn: baseTime, Nights: 2, SellingRate: 500, Margin: 30},
+		{RequestID: "short2", CheckIn: baseTime.AddDate(0, 0, 3), Nights: 2, SellingRate: 500, Margin: 30},
+	}
+
+	frontier, err := service.ParetoFrontier(context.Background(), bookings)
+	require.NoError(t, err)
+	require.Len(t, frontier, 2)
+
+	assert.Equal(t, []string{"short1", "short2"}, frontier[0].RequestIDs)
+	assert.Equal(t, float64(300), frontier[0].TotalProfit)
+	assert.Equal(t, []string{"long"}, frontier[1].RequestIDs)
+	assert.Equal(t, 10, frontier[1].TotalNights)
+}
+
+func TestStatsService_Sensitivity(t *testing.T) {
+	baseTime := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	service := application.NewStatsService()
+
+	bookings := domain.Bookings{
+		{RequestID: "req1", CheckIn: baseTime, Nights: 3, SellingRate: 1000, Margin: 20},
+		{RequestID: "req2", CheckIn: baseTime.AddDate(0, 0, 2), Nights: 3, SellingRate: 2000, Margin: 25},
+	}
+
+	result, err := service.Sensitivity(context.Background(), bookings, domain.MaximizeOptions{})
+	require.NoError(t, err)
+	require.NotNil(t, result)
+
+	assert.Equal(t, []string{"req2"}, result.Selection.RequestIDs)
+	require.Len(t, result.Bookings, 2)
+	assert.False(t, result.Bookings[0].Accepted)
+	assert.Equal(t, 1499.98, result.Bookings[0].SellingRateDelta) // req1 wins a 500 tie with req2 on request IDs
+	assert.True(t, result.Bookings[1].Accepted)
+	assert.Equal(t, 1199.99, result.Bookings[1].SellingRateDelta) // req2 loses a 200 tie with req1 on request IDs
+}
+
+func TestStatsService_CompareScenarios(t *testing.T) {
+	baseTime := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	service := application.NewStatsService()
+
+	bookings := domain.Bookings{
+		{RequestID: "req1", CheckIn: baseTime, Nights: 3, SellingRate: 1000, Margin: 20},
+		{RequestID: "req2", CheckIn: baseTime.AddDate(0, 0, 2), Nights: 3, SellingRate: 2000, Margin: 25},
+	}
+	scenarios := []domain.Scenario{
+		{Name: "no req2", Patches: []domain.Patch{domain.RemoveBooking{RequestID: "req2"}}},
+	}
+
+	comparison, err := service.CompareScenarios(context.Background(), bookings, scenarios, domain.MaximizeOptions{})
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"req2"}, comparison.Base.Maximize.RequestIDs)
+	require.Len(t, comparison.Scenarios, 1)
+	assert.Equal(t, []string{"req1"}, comparison.Scenarios[0].Maximize.RequestIDs)
+	assert.Equal(t, float64(-300), comparison.Scenarios[0].Diff.TotalProfit)
+}
+
+func TestStatsService_Observer(t *testing.T) {
+	baseTime := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	bookings := domain.Bookings{
+		{RequestID: "req1", CheckIn: baseTime, Nights: 3, SellingRate: 1000, Margin: 20},
+		{RequestID: "req2", CheckIn: baseTime.AddDate(0, 0, 2), Nights: 3, SellingRate: 2000, Margin: 25},
+	}
+
+	ctrl := gomock.NewController(t)
+	observer := mocks.NewMockOptimizerObserver(ctrl)
+	service := application.NewStatsService(application.WithObserver(observer))
+
+	observer.EXPECT().ObserveBookings(application.OperationStats, 2)
+	_, err := service.CalculateStats(context.Background(), bookings)
+	require.NoError(t, err)
+
+	var searches []domain.SearchStats
+	observer.EXPECT().ObserveBookings(application.OperationMaximize, 2)
+	observer.EXPECT().ObserveSearch(gomock.Any()).Do(func(stats domain.SearchStats) {
+		assert.Equal(t, domain.ModeExact, stats.Mode)
+		assert.Equal(t, 3, stats.Evaluated)
+		assert.False(t, stats.Aborted)
+	})
+	_, err = service.MaximizeProfit(context.Background(), bookings, domain.MaximizeOptions{
+		OnSearchDone: func(_ context.Context, stats domain.SearchStats) { searches = append(searches, stats) },
+	})
+	require.NoError(t, err)
+	assert.Len(t, searches, 1, "the hook set by the caller is kept")
+}
+
+func TestStatsService_Tracing(t *testing.T) {
+	baseTime := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	// req2 overlaps req1 and req3, so it is rejected
+	bookings := domain.Bookings{
+		{RequestID: "req1", CheckIn: baseTime, Nights: 2, SellingRate: 100, Margin: 10},
+		{RequestID: "req2", CheckIn: baseTime.AddDate(0, 0, 1), Nights: 2, SellingRate: 100, Margin: 10},
+		{RequestID: "req3", CheckIn: baseTime.AddDate(0, 0, 2), Nights: 2, SellingRate: 100, Margin: 10},
+	}
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	tests := []struct {
+		name      string
+		ctx       context.Context
+		call      func(ctx context.Context, service *application.StatsService) error
+		wantSpans map[string]string
+		wantAttrs []attribute.KeyValue
+		wantError bool
+	}{
+		{
+			name: "stats",
+			ctx:  context.Background(),
+			call: func(ctx context.Context, service *application.StatsService) error {
+				_, err := service.CalculateStats(ctx, bookings)
+				return err
+			},
+			wantSpans: map[string]string{"StatsService.CalculateStats": ""},
+			wantAttrs: []attribute.KeyValue{attribute.Int("bookings.count", 3)},
+		},
+		{
+			name: "maximize with counter-offers",
+			ctx:  context.Background(),
+			call: func(ctx context.Context, service *application.StatsService) error {
+				_, err := service.MaximizeProfit(ctx, bookings, domain.MaximizeOptions{CounterOffers: true})
+				return err
+			},
+			wantSpans: map[string]string{
+				"StatsService.MaximizeProfit": "",
+				"optimizer.search":            "StatsService.MaximizeProfit",
+				"optimizer.counter_offers":    "StatsService.MaximizeProfit",
+			},
+			wantAttrs: []attribute.KeyValue{
+				attribute.String("optimizer.objective", "profit"),
+				attribute.String("optimizer.mode", "exact"),
+				attribute.Int("bookings.count", 3),
+				attribute.Int("bookings.selected", 2),
+				attribute.Int("bookings.rejected", 1),
+			},
+		},
+		{
+			name: "sensitivity",
+			ctx:  context.Background(),
+			call: func(ctx context.Context, service *application.StatsService) error {
+				_, err := service.Sensitivity(ctx, bookings, domain.MaximizeOptions{})
+				return err
+			},
+			wantSpans: map[string]string{
+				"StatsService.Sensitivity": "",
+				"optimizer.search":         "StatsService.Sensitivity",
+				"optimizer.sensitivity":    "StatsService.Sensitivity",
+			},
+			wantAttrs: []attribute.KeyValue{
+				attribute.String("optimizer.objective", "profit"),
+				attribute.String("optimizer.mode", "exact"),
+				attribute.Int("bookings.count", 3),
+				attribute.Int("bookings.selected", 2),
+				attribute.Int("bookings.rejected", 1),
+			},
+		},
+		{
+			name: "canceled",
+			ctx:  canceled,
+			call: func(ctx context.Context, service *application.StatsService) error {
+				_, err := service.MaximizeProfit(ctx, slices.Repeat(bookings, 5), domain.MaximizeOptions{})
+				return err
+			},
+			wantSpans: map[string]string{
+				"StatsService.MaximizeProfit": "",
+				"optimizer.search":            "StatsService.MaximizeProfit",
+			},
+			wantAttrs: []attribute.KeyValue{
+				attribute.String("optimizer.objective", "profit"),
+				attribute.String("optimizer.mode", "exact"),
+				attribute.Int("bookings.count", 15),
+			},
+			wantError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			exporter := tracetest.NewInMemoryExporter()
+			tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+			service := application.NewStatsService(application.WithTracerProvider(tp))
+
+			err := tt.call(tt.ctx, service)
+			assert.Equal(t, tt.wantError, err != nil)
+
+			spans := exporter.GetSpans()
+			names := make(map[trace.SpanID]string, len(spans))
+			for _, span := range spans {
+				names[span.SpanContext.SpanID()] = span.Name
+			}
+			parents := make(map[string]string, len(spans))
+			for _, span := range spans {
+				parents[span.Name] = names[span.Parent.SpanID()]
+			}
+			assert.Equal(t, tt.wantSpans, parents)
+
+			// the operation span ends last
+			root := spans[len(spans)-1]
+			assert.ElementsMatch(t, tt.wantAttrs, root.Attributes)
+			if tt.wantError {
+				assert.Equal(t, codes.Error, root.Status.Code)
+			} else {
+				assert.Equal(t, codes.Unset, root.Status.Code)
+			}
+			for _, span := range spans {
+				if span.Name == "optimizer.search" {
+					require.NotEmpty(t, span.Events)
+					assert.Equal(t, "search", span.Events[0].Name)
+				}
+			}
+		})
+	}
+}
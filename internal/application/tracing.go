@@ -0,0 +1,104 @@
+package application
+
+import (
+	"cmp"
+	"context"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/duksonn/stay-for-long/internal/domain"
+)
+
+// tracerName names the tracer of the application services
+const tracerName = "github.com/duksonn/stay-for-long/internal/application"
+
+// Attributes of the spans recorded by the application services
+const (
+	attrBookings   = attribute.Key("bookings.count")
+	attrSelected   = attribute.Key("bookings.selected")
+	attrRejected   = attribute.Key("bookings.rejected")
+	attrObjective  = attribute.Key("optimizer.objective")
+	attrMode       = attribute.Key("optimizer.mode")
+	attrEvaluated  = attribute.Key("optimizer.evaluated")
+	attrDuration   = attribute.Key("optimizer.duration_ms")
+	attrAborted    = attribute.Key("optimizer.aborted")
+	attrSelections = attribute.Key("pareto.selections")
+	attrScenarios  = attribute.Key("scenarios.count")
+	attrJobID      = attribute.Key("job.id")
+)
+
+// WithTracerProvider records a span for every operation, and for the phases of the optimizer it runs,
+// with a tracer of tp. Nothing is recorded by default
+func WithTracerProvider(tp trace.TracerProvider) Option {
+	return func(o *options) {
+		o.tracer = tp.Tracer(tracerName)
+	}
+}
+
+// startSpan starts the span of an operation over requests
+func (o options) startSpan(ctx context.Context, name string, requests domain.Bookings, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	attrs = append(attrs, attrBookings.Int(len(requests)))
+
+	return o.tracer.Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// trace returns opts recording a span for every phase of the optimizer and an event for every search on the
+// span it runs within, keeping the OnPhase and OnSearchDone hooks already set
+func (o options) trace(opts domain.MaximizeOptions) domain.MaximizeOptions {
+	nextPhase := opts.OnPhase
+	opts.OnPhase = func(ctx context.Context, phase domain.Phase) (context.Context, func(err error)) {
+		nextEnd := func(error) {}
+		if nextPhase != nil {
+			ctx, nextEnd = nextPhase(ctx, phase)
+		}
+		ctx, span := o.tracer.Start(ctx, "optimizer."+string(phase))
+		return ctx, func(err error) {
+			endSpan(span, err)
+			nextEnd(err)
+		}
+	}
+
+	nextSearch := opts.OnSearchDone
+	opts.OnSearchDone = func(ctx context.Context, stats domain.SearchStats) {
+		trace.SpanFromContext(ctx).AddEvent("search", trace.WithAttributes(
+			attrMode.String(string(stats.Mode)),
+			attrEvaluated.Int(stats.Evaluated),
+			attrDuration.Float64(float64(stats.Duration.Microseconds())/1000),
+			attrAborted.Bool(stats.Aborted),
+		))
+		if nextSearch != nil {
+			nextSearch(ctx, stats)
+		}
+	}
+
+	return opts
+}
+
+// optionAttributes describes the objective and the mode of opts, with their defaults when unset
+func optionAttributes(opts domain.MaximizeOptions) []attribute.KeyValue {
+	return []attribute.KeyValue{
+		attrObjective.String(string(cmp.Or(opts.Objective, domain.ObjectiveProfit))),
+		attrMode.String(string(cmp.Or(opts.Mode, domain.ModeExact))),
+	}
+}
+
+// selectionAttributes describes the size of the selection in result, nothing when there is no result
+func selectionAttributes(result *domain.MaximizeResult) []attribute.KeyValue {
+	if result == nil {
+		return nil
+	}
+
+	return []attribute.KeyValue{attrSelected.Int(len(result.Selected)), attrRejected.Int(len(result.Rejected))}
+}
+
+// endSpan sets attrs on span, records err as its error when set and ends it
+func endSpan(span trace.Span, err error, attrs ...attribute.KeyValue) {
+	span.SetAttributes(attrs...)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
@@ -1,17 +1,39 @@
 package domain
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"math"
+	"slices"
 	"time"
 )
 
+// ErrOptimizationAborted is returned when the optimizer stops because its context is done
+// It wraps the context error, so callers can tell a cancellation from a deadline, and the
+// context cause when one was set
+var ErrOptimizationAborted = errors.New("optimization aborted")
+
+// ErrExactSearchTooLarge is returned when the exact search is asked to enumerate the combinations of
+// more than MaxExactBookings bookings
+var ErrExactSearchTooLarge = errors.New("too many bookings for the exact search")
+
+// MaxExactBookings is the largest number of bookings whose combinations the exact search can count
+// Searches far below it are already impractical, so callers are expected to cap their input lower
+const MaxExactBookings = 62
+
 // Bookings represents a collection of Booking pointers
 type Bookings []*Booking
 
 // Booking represents a hotel booking with its essential information
+// CheckIn carries the arrival time in the property's location. CheckOut is optional and,
+// when set, is the exact departure time, which allows late check-outs on the departure day.
+// PropertyID optionally identifies the property the booking belongs to
 type Booking struct {
 	RequestID   string
+	PropertyID  string
 	CheckIn     time.Time
+	CheckOut    time.Time
 	Nights      int
 	SellingRate float64
 	Margin      float64
@@ -25,12 +47,32 @@ type StatsResult struct {
 }
 
 // MaximizeResult contains the optimal booking combination and its statistics
+// Selected holds the bookings of the combination in canonical order and Optimal reports whether it is
+// proven to be optimal. UpperBound is a score no selection can exceed and Gap is how far Score may be
+// from the optimum, so both equal Score and zero for optimal results
 type MaximizeResult struct {
-	RequestIDs  []string
-	TotalProfit float64
-	AvgNight    float64
-	MinNight    float64
-	MaxNight    float64
+	RequestIDs   []string
+	Selected     Bookings
+	Objective    Objective
+	Score        float64
+	TotalProfit  float64
+	TotalRevenue float64
+	TotalNights  int
+	AvgNight     float64
+	MinNight     float64
+	MaxNight     float64
+	Optimal      bool
+	UpperBound   float64
+	Gap          float64
+	Rejected     []*RejectedBooking
+}
+
+// RejectedBooking describes a booking left out of the optimal selection
+// CounterOfferRate is the minimum selling rate, at the same margin, at which the booking would have
+// been selected. It is zero when it was not requested or when no selling rate achieves it
+type RejectedBooking struct {
+	Booking          *Booking
+	CounterOfferRate float64
 }
 
 // ProfitPerNight calculates the profit per night for a booking
@@ -78,12 +120,32 @@ func (bb Bookings) CalculateStats() *StatsResult {
 	}
 }
 
-// OverlapsWith checks if two bookings have overlapping dates
+// End returns the moment the booking releases the property
+// Without an explicit CheckOut it is the check-in time of day after the last night. Days are added
+// on the calendar of the check-in location, so the wall-clock time is preserved across DST changes
+func (b *Booking) End() time.Time {
+	if !b.CheckOut.IsZero() {
+		return b.CheckOut
+	}
+
+	return b.CheckIn.AddDate(0, 0, b.Nights)
+}
+
+// OverlapsWith checks if two bookings have overlapping stays
 func (b *Booking) OverlapsWith(other *Booking) bool {
-	bEnd := b.CheckIn.AddDate(0, 0, b.Nights)
-	oEnd := other.CheckIn.AddDate(0, 0, other.Nights)
+	return b.CheckIn.Before(other.End()) && other.CheckIn.Before(b.End())
+}
+
+// NightsBetween counts the calendar nights between a check-in and a check-out
+// Only the dates in each time's own location are considered, so DST changes and
+// check-in/check-out times of day do not affect the count
+func NightsBetween(checkIn, checkOut time.Time) int {
+	inYear, inMonth, inDay := checkIn.Date()
+	outYear, outMonth, outDay := checkOut.Date()
+	start := time.Date(inYear, inMonth, inDay, 0, 0, 0, 0, time.UTC)
+	end := time.Date(outYear, outMonth, outDay, 0, 0, 0, 0, time.UTC)
 
-	return b.CheckIn.Before(oEnd) && other.CheckIn.Before(bEnd)
+	return int(end.Sub(start).Hours() / 24)
 }
 
 // HasOverlaps checks if any bookings in the collection overlap with each other
@@ -109,6 +171,26 @@ func (bb Bookings) TotalProfit() float64 {
 	return roundToTwoDecimals(sum)
 }
 
+// TotalRevenue calculates the total selling rate for all bookings
+func (bb Bookings) TotalRevenue() float64 {
+	sum := 0.0
+	for _, b := range bb {
+		sum += b.SellingRate
+	}
+
+	return roundToTwoDecimals(sum)
+}
+
+// TotalNights calculates the number of nights occupied by all bookings
+func (bb Bookings) TotalNights() int {
+	nights := 0
+	for _, b := range bb {
+		nights += b.Nights
+	}
+
+	return nights
+}
+
 // RequestIDs returns an array of all booking request IDs
 func (bb Bookings) RequestIDs() []string {
 	ids := make([]string, 0, len(bb))
@@ -119,67 +201,168 @@ func (bb Bookings) RequestIDs() []string {
 	return ids
 }
 
-// allCombinations generates all possible combinations of bookings
-func allCombinations(bookings []*Booking) []Bookings {
+// cancelCheckInterval is the number of combinations evaluated between two cancellation checks
+const cancelCheckInterval = 1 << 12
+
+// forEachCombination calls fn with every possible combination of bookings
+// The combination passed to fn is reused between calls, so fn must clone it to retain it.
+// The context is checked periodically and ErrOptimizationAborted is returned once it is done.
+// When progress is not nil it is called at the same interval and once all combinations are evaluated.
+// ErrExactSearchTooLarge is returned without calling fn when there are more than MaxExactBookings bookings
+func forEachCombination(ctx context.Context, bookings []*Booking, progress func(evaluated, total int), fn func(combo Bookings)) error {
 	n := len(bookings)
-	var result []Bookings
-	total := int(math.Pow(2, float64(n)))
+	if n > MaxExactBookings {
+		return fmt.Errorf("%w: got %d, limit is %d", ErrExactSearchTooLarge, n, MaxExactBookings)
+	}
+	combo := make(Bookings, 0, n)
+	total := 1 << n
 	for mask := 1; mask < total; mask++ {
-		var combo Bookings
+		if mask%cancelCheckInterval == 0 {
+			if err := ctx.Err(); err != nil {
+				return abortError(ctx, err)
+			}
+			if progress != nil {
+				progress(mask, total)
+			}
+		}
+		combo = combo[:0]
 		for j := 0; j < n; j++ {
 			if (mask>>j)&1 == 1 {
 				combo = append(combo, bookings[j])
 			}
 		}
-		result = append(result, combo)
+		fn(combo)
+	}
+	if progress != nil {
+		progress(total, total)
 	}
 
-	return result
+	return nil
 }
 
-// MaximizeProfit finds the optimal combination of non-overlapping bookings that maximizes profit
-func MaximizeProfit(bookings []*Booking) *MaximizeResult {
-	best := findBestCombination(allCombinations(bookings))
-	return buildMaximizeResult(best)
+// abortError builds the error returned when the optimizer stops because its context is done
+func abortError(ctx context.Context, err error) error {
+	if cause := context.Cause(ctx); cause != nil && !errors.Is(cause, err) {
+		return fmt.Errorf("%w: %w: %w", ErrOptimizationAborted, err, cause)
+	}
+
+	return fmt.Errorf("%w: %w", ErrOptimizationAborted, err)
+}
+
+// MaximizeProfit finds the optimal combination of non-overlapping bookings for the objective in opts
+// It maximizes TotalProfit unless another objective is configured. Bookings are evaluated in canonical
+// order and ties are settled by the TieBreak policy, so the result does not depend on the input order
+// When opts.CounterOffers is set, a counter-offer rate is computed for every rejected booking.
+// In heuristic mode the selection is searched by maximizeHeuristic instead
+func MaximizeProfit(ctx context.Context, bookings []*Booking, opts MaximizeOptions) (*MaximizeResult, error) {
+	sorted := canonicalOrder(bookings)
+	searchCtx, endSearch := opts.startPhase(ctx, PhaseSearch)
+	if opts.Mode == ModeHeuristic {
+		result, err := maximizeHeuristic(searchCtx, sorted, opts)
+		endSearch(err)
+		return result, err
+	}
+
+	best, err := findBestCombination(searchCtx, sorted, opts)
+	endSearch(err)
+	if err != nil {
+		return nil, err
+	}
+
+	result := buildMaximizeResult(sorted, best, opts)
+	if opts.CounterOffers {
+		if err := addCounterOffers(ctx, sorted, result, opts); err != nil {
+			return nil, err
+		}
+	}
+
+	return result, nil
 }
 
-// findBestCombination finds the combination of bookings with the highest profit and no overlaps
-func findBestCombination(combos []Bookings) Bookings {
+// findBestCombination finds the combination of bookings with the highest score and no overlaps
+// Combinations with the same score are ranked with the configured tie-break policy
+func findBestCombination(ctx context.Context, bookings []*Booking, opts MaximizeOptions) (Bookings, error) {
 	var best Bookings
-	maxProfit := -1.0
-	for _, combo := range combos {
+	maxScore := -1.0
+	var progress func(evaluated, total int)
+	if opts.OnProgress != nil {
+		progress = func(evaluated, total int) {
+			opts.OnProgress(SearchProgress{
+				Evaluated:  evaluated,
+				Total:      total,
+				BestScore:  max(maxScore, 0),
+				BestProfit: best.TotalProfit(),
+			})
+		}
+	}
+	start := time.Now()
+	evaluated := 0
+	err := forEachCombination(ctx, bookings, progress, func(combo Bookings) {
+		evaluated++
 		if combo.HasOverlaps() {
-			continue
+			return
 		}
-		profit := combo.TotalProfit()
-		if profit > maxProfit {
-			maxProfit = profit
-			best = combo
+		score := opts.score(combo)
+		if score > maxScore || (score == maxScore && opts.breaksTie(combo, best)) {
+			maxScore = score
+			best = slices.Clone(combo)
 		}
+	})
+	opts.searchDone(ctx, ModeExact, evaluated, time.Since(start), err)
+	if err != nil {
+		return nil, err
 	}
 
-	return best
+	return best, nil
 }
 
 // buildMaximizeResult constructs the final result with statistics for the best combination
-func buildMaximizeResult(best Bookings) *MaximizeResult {
+// Every booking that is not part of the best combination is reported as rejected and the
+// combination is reported as optimal, as it comes from an exhaustive search
+func buildMaximizeResult(bookings, best Bookings, opts MaximizeOptions) *MaximizeResult {
+	rejected := make([]*RejectedBooking, 0, len(bookings)-len(best))
+	for _, b := range bookings {
+		if !slices.Contains(best, b) {
+			rejected = append(rejected, &RejectedBooking{Booking: b})
+		}
+	}
+
 	if len(best) == 0 {
 		return &MaximizeResult{
-			RequestIDs:  []string{},
-			TotalProfit: 0,
-			AvgNight:    0,
-			MinNight:    0,
-			MaxNight:    0,
+			RequestIDs:   []string{},
+			Selected:     Bookings{},
+			Objective:    opts.objective(),
+			Score:        0,
+			TotalProfit:  0,
+			TotalRevenue: 0,
+			TotalNights:  0,
+			AvgNight:     0,
+			MinNight:     0,
+			MaxNight:     0,
+			Optimal:      true,
+			UpperBound:   0,
+			Gap:          0,
+			Rejected:     rejected,
 		}
 	}
 
 	stats := best.CalculateStats()
+	score := opts.score(best)
 	return &MaximizeResult{
-		RequestIDs:  best.RequestIDs(),
-		TotalProfit: best.TotalProfit(),
-		AvgNight:    stats.AvgNight,
-		MinNight:    stats.MinNight,
-		MaxNight:    stats.MaxNight,
+		RequestIDs:   best.RequestIDs(),
+		Selected:     best,
+		Objective:    opts.objective(),
+		Score:        score,
+		TotalProfit:  best.TotalProfit(),
+		TotalRevenue: best.TotalRevenue(),
+		TotalNights:  best.TotalNights(),
+		AvgNight:     stats.AvgNight,
+		MinNight:     stats.MinNight,
+		MaxNight:     stats.MaxNight,
+		Optimal:      true,
+		UpperBound:   score,
+		Gap:          0,
+		Rejected:     rejected,
 	}
 }
 
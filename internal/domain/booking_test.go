@@ -1,6 +1,10 @@
 package domain_test
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
 	"testing"
 	"time"
 
@@ -390,10 +394,12 @@ func TestMaximizeProfit(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			result := domain.MaximizeProfit(tt.bookings)
+			result, err := domain.MaximizeProfit(context.Background(), tt.bookings, domain.MaximizeOptions{})
+			require.NoError(t, err)
 			require.NotNil(t, result)
 
 			assert.Equal(t, tt.expected.RequestIDs, result.RequestIDs)
+			assert.Equal(t, tt.expected.RequestIDs, result.Selected.RequestIDs())
 			assert.Equal(t, tt.expected.TotalProfit, result.TotalProfit)
 			assert.Equal(t, tt.expected.AvgNight, result.AvgNight)
 			assert.Equal(t, tt.expected.MinNight, result.MinNight)
@@ -401,3 +407,422 @@ func TestMaximizeProfit(t *testing.T) {
 		})
 	}
 }
+
+func TestMaximizeProfit_Canceled(t *testing.T) {
+	baseTime := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	errTimeLimit := errors.New("time limit")
+
+	// enough bookings for the optimizer to reach a cancellation check
+	bookings := make([]*domain.Booking, 0, 16)
+	for i := range 16 {
+		bookings = append(bookings, &domain.Booking{
+			RequestID:   fmt.Sprintf("req%02d", i),
+			CheckIn:     baseTime.AddDate(0, 0, i),
+			Nights:      2,
+			SellingRate: 100,
+			Margin:      10,
+		})
+	}
+
+	tests := []struct {
+		name    string
+		ctx     func() (context.Context, context.CancelFunc)
+		wantErr error
+	}{
+		{
+			name: "canceled",
+			ctx: func() (context.Context, context.CancelFunc) {
+				ctx, cancel := context.WithCancel(context.Background())
+				cancel()
+				return ctx, cancel
+			},
+			wantErr: context.Canceled,
+		},
+		{
+			name: "deadline exceeded",
+			ctx: func() (context.Context, context.CancelFunc) {
+				return context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+			},
+			wantErr: context.DeadlineExceeded,
+		},
+		{
+			name: "deadline exceeded with cause",
+			ctx: func() (context.Context, context.CancelFunc) {
+				return context.WithDeadlineCause(context.Background(), time.Now().Add(-time.Second), errTimeLimit)
+			},
+			wantErr: errTimeLimit,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := tt.ctx()
+			defer cancel()
+
+			result, err := domain.MaximizeProfit(ctx, bookings, domain.MaximizeOptions{})
+			assert.Nil(t, result)
+			assert.ErrorIs(t, err, domain.ErrOptimizationAborted)
+			assert.ErrorIs(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestMaximizeProfit_TooManyBookings(t *testing.T) {
+	baseTime := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	for _, n := range []int{domain.MaxExactBookings + 1, 64, 100} {
+		bookings := make([]*domain.Booking, 0, n)
+		for i := range n {
+			bookings = append(bookings, &domain.Booking{
+				RequestID:   fmt.Sprintf("req%03d", i),
+				CheckIn:     baseTime.AddDate(0, 0, i),
+				Nights:      1,
+				SellingRate: 100,
+				Margin:      10,
+			})
+		}
+
+		t.Run(fmt.Sprintf("%d bookings", n), func(t *testing.T) {
+			result, err := domain.MaximizeProfit(context.Background(), bookings, domain.MaximizeOptions{})
+			assert.Nil(t, result)
+			assert.ErrorIs(t, err, domain.ErrExactSearchTooLarge)
+
+			frontier, err := domain.ParetoFrontier(context.Background(), bookings)
+			assert.Nil(t, frontier)
+			assert.ErrorIs(t, err, domain.ErrExactSearchTooLarge)
+
+			result, err = domain.MaximizeProfit(context.Background(), bookings, domain.MaximizeOptions{
+				Mode:   domain.ModeHeuristic,
+				Budget: 10 * time.Millisecond,
+			})
+			require.NoError(t, err)
+			assert.Len(t, result.Selected, n)
+		})
+	}
+}
+
+func TestMaximizeProfit_Progress(t *testing.T) {
+	baseTime := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	bookings := make([]*domain.Booking, 0, 13)
+	for i := range 13 {
+		bookings = append(bookings, &domain.Booking{
+			RequestID:   fmt.Sprintf("req%02d", i),
+			CheckIn:     baseTime.AddDate(0, 0, i),
+			Nights:      2,
+			SellingRate: 100,
+			Margin:      10,
+		})
+	}
+
+	var evaluated []int
+	var last domain.SearchProgress
+	opts := domain.MaximizeOptions{
+		CounterOffers: true,
+		OnProgress: func(progress domain.SearchProgress) {
+			assert.Equal(t, 1<<13, progress.Total)
+			assert.Equal(t, progress.BestScore, progress.BestProfit)
+			evaluated = append(evaluated, progress.Evaluated)
+			last = progress
+		},
+	}
+	result, err := domain.MaximizeProfit(context.Background(), bookings, opts)
+	require.NoError(t, err)
+	assert.Equal(t, result.TotalProfit, last.BestProfit)
+
+	// reported while searching and once at the end, but not for the counter-offer searches
+	assert.Equal(t, []int{1 << 12, 1 << 13}, evaluated)
+}
+
+func TestMaximizeProfit_SearchDone(t *testing.T) {
+	baseTime := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	// the second booking overlaps the first and the third, so it is rejected
+	bookings := []*domain.Booking{
+		{RequestID: "a", CheckIn: baseTime, Nights: 2, SellingRate: 100, Margin: 10},
+		{RequestID: "b", CheckIn: baseTime.AddDate(0, 0, 1), Nights: 2, SellingRate: 100, Margin: 10},
+		{RequestID: "c", CheckIn: baseTime.AddDate(0, 0, 2), Nights: 2, SellingRate: 100, Margin: 10},
+	}
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	tests := []struct {
+		name         string
+		ctx          context.Context
+		bookings     []*domain.Booking
+		opts         domain.MaximizeOptions
+		wantSearches int
+		wantStats    domain.SearchStats
+	}{
+		{
+			name:         "exact",
+			ctx:          context.Background(),
+			bookings:     bookings,
+			wantSearches: 1,
+			wantStats:    domain.SearchStats{Mode: domain.ModeExact, Evaluated: 7},
+		},
+		{
+			name:         "exact with counter-offers",
+			ctx:          context.Background(),
+			bookings:     bookings,
+			opts:         domain.MaximizeOptions{CounterOffers: true},
+			wantSearches: 2, // the main search and the pass computing the counter-offers
+			wantStats:    domain.SearchStats{Mode: domain.ModeExact, Evaluated: 7},
+		},
+		{
+			name:         "heuristic",
+			ctx:          context.Background(),
+			bookings:     bookings,
+			opts:         domain.MaximizeOptions{Mode: domain.ModeHeuristic},
+			wantSearches: 1,
+			wantStats:    domain.SearchStats{Mode: domain.ModeHeuristic},
+		},
+		{
+			name:         "aborted",
+			ctx:          canceled,
+			bookings:     slices.Repeat(bookings, 5),
+			wantSearches: 1,
+			wantStats:    domain.SearchStats{Mode: domain.ModeExact, Evaluated: 1<<12 - 1, Aborted: true},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var searches []domain.SearchStats
+			tt.opts.OnSearchDone = func(_ context.Context, stats domain.SearchStats) {
+				assert.GreaterOrEqual(t, stats.Duration, time.Duration(0))
+				stats.Duration = 0
+				searches = append(searches, stats)
+			}
+
+			_, err := domain.MaximizeProfit(tt.ctx, tt.bookings, tt.opts)
+			assert.Equal(t, tt.wantStats.Aborted, err != nil)
+			require.Len(t, searches, tt.wantSearches)
+			assert.Equal(t, tt.wantStats, searches[0])
+		})
+	}
+}
+
+func TestMaximizeProfit_Phases(t *testing.T) {
+	baseTime := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	// the second booking overlaps the first and the third, so it is rejected
+	bookings := []*domain.Booking{
+		{RequestID: "a", CheckIn: baseTime, Nights: 2, SellingRate: 100, Margin: 10},
+		{RequestID: "b", CheckIn: baseTime.AddDate(0, 0, 1), Nights: 2, SellingRate: 100, Margin: 10},
+		{RequestID: "c", CheckIn: baseTime.AddDate(0, 0, 2), Nights: 2, SellingRate: 100, Margin: 10},
+	}
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	type phaseKey struct{}
+	type phaseEnd struct {
+		phase   domain.Phase
+		aborted bool
+	}
+	tests := []struct {
+		name         string
+		ctx          context.Context
+		bookings     []*domain.Booking
+		opts         domain.MaximizeOptions
+		sensitivity  bool
+		wantEnds     []phaseEnd
+		wantSearches map[domain.Phase]int
+	}{
+		{
+			name:         "exact",
+			ctx:          context.Background(),
+			bookings:     bookings,
+			wantEnds:     []phaseEnd{{phase: domain.PhaseSearch}},
+			wantSearches: map[domain.Phase]int{domain.PhaseSearch: 1},
+		},
+		{
+			name:         "heuristic",
+			ctx:          context.Background(),
+			bookings:     bookings,
+			opts:         domain.MaximizeOptions{Mode: domain.ModeHeuristic},
+			wantEnds:     []phaseEnd{{phase: domain.PhaseSearch}},
+			wantSearches: map[domain.Phase]int{domain.PhaseSearch: 1},
+		},
+		{
+			name:         "counter-offers",
+			ctx:          context.Background(),
+			bookings:     bookings,
+			opts:         domain.MaximizeOptions{CounterOffers: true},
+			wantEnds:     []phaseEnd{{phase: domain.PhaseSearch}, {phase: domain.PhaseCounterOffers}},
+			wantSearches: map[domain.Phase]int{domain.PhaseSearch: 1, domain.PhaseCounterOffers: 1},
+		},
+		{
+			name:        "sensitivity",
+			ctx:         context.Background(),
+			bookings:    bookings,
+			sensitivity: true,
+			wantEnds:    []phaseEnd{{phase: domain.PhaseSearch}, {phase: domain.PhaseSensitivity}},
+		},
+		{
+			name:         "aborted",
+			ctx:          canceled,
+			bookings:     slices.Repeat(bookings, 5),
+			opts:         domain.MaximizeOptions{CounterOffers: true},
+			wantEnds:     []phaseEnd{{phase: domain.PhaseSearch, aborted: true}},
+			wantSearches: map[domain.Phase]int{domain.PhaseSearch: 1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var ends []phaseEnd
+			searches := make(map[domain.Phase]int)
+			tt.opts.OnPhase = func(ctx context.Context, phase domain.Phase) (context.Context, func(err error)) {
+				return context.WithValue(ctx, phaseKey{}, phase), func(err error) {
+					ends = append(ends, phaseEnd{phase: phase, aborted: err != nil})
+				}
+			}
+			tt.opts.OnSearchDone = func(ctx context.Context, _ domain.SearchStats) {
+				phase, _ := ctx.Value(phaseKey{}).(domain.Phase)
+				searches[phase]++
+			}
+
+			var err error
+			if tt.sensitivity {
+				_, err = domain.Sensitivity(tt.ctx, tt.bookings, tt.opts)
+			} else {
+				_, err = domain.MaximizeProfit(tt.ctx, tt.bookings, tt.opts)
+			}
+			assert.Equal(t, tt.wantEnds[len(tt.wantEnds)-1].aborted, err != nil)
+			assert.Equal(t, tt.wantEnds, ends)
+			if tt.wantSearches != nil {
+				assert.Equal(t, tt.wantSearches, searches)
+			} else {
+				// every search but the first one runs within the sensitivity phase
+				assert.Equal(t, 1, searches[domain.PhaseSearch])
+				assert.Positive(t, searches[domain.PhaseSensitivity])
+				assert.Len(t, searches, 2)
+			}
+		})
+	}
+}
+
+func TestBooking_End(t *testing.T) {
+	madrid, err := time.LoadLocation("Europe/Madrid")
+	require.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		booking  *domain.Booking
+		expected time.Time
+	}{
+		{
+			name:     "derived from nights",
+			booking:  &domain.Booking{CheckIn: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), Nights: 3},
+			expected: time.Date(2024, 1, 4, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "derived from nights across dst change",
+			booking:  &domain.Booking{CheckIn: time.Date(2024, 3, 30, 11, 0, 0, 0, madrid), Nights: 1},
+			expected: time.Date(2024, 3, 31, 11, 0, 0, 0, madrid),
+		},
+		{
+			name: "explicit check-out",
+			booking: &domain.Booking{
+				CheckIn:  time.Date(2024, 1, 1, 15, 0, 0, 0, time.UTC),
+				CheckOut: time.Date(2024, 1, 3, 18, 0, 0, 0, time.UTC),
+				Nights:   2,
+			},
+			expected: time.Date(2024, 1, 3, 18, 0, 0, 0, time.UTC),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.True(t, tt.expected.Equal(tt.booking.End()))
+		})
+	}
+}
+
+func TestBooking_OverlapsWith_TimesAndTimezones(t *testing.T) {
+	madrid, err := time.LoadLocation("Europe/Madrid")
+	require.NoError(t, err)
+	newYork, err := time.LoadLocation("America/New_York")
+	require.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		booking1 *domain.Booking
+		booking2 *domain.Booking
+		expected bool
+	}{
+		{
+			name: "same-day turnover across dst change",
+			booking1: &domain.Booking{
+				CheckIn: time.Date(2024, 3, 30, 11, 0, 0, 0, madrid),
+				Nights:  1,
+			},
+			booking2: &domain.Booking{
+				CheckIn: time.Date(2024, 3, 31, 11, 0, 0, 0, madrid),
+				Nights:  2,
+			},
+			expected: false,
+		},
+		{
+			name: "late check-out overlaps same-day check-in",
+			booking1: &domain.Booking{
+				CheckIn:  time.Date(2024, 1, 1, 15, 0, 0, 0, madrid),
+				CheckOut: time.Date(2024, 1, 3, 18, 0, 0, 0, madrid),
+				Nights:   2,
+			},
+			booking2: &domain.Booking{
+				CheckIn: time.Date(2024, 1, 3, 15, 0, 0, 0, madrid),
+				Nights:  2,
+			},
+			expected: true,
+		},
+		{
+			name: "check-out before same-day check-in",
+			booking1: &domain.Booking{
+				CheckIn:  time.Date(2024, 1, 1, 15, 0, 0, 0, madrid),
+				CheckOut: time.Date(2024, 1, 3, 11, 0, 0, 0, madrid),
+				Nights:   2,
+			},
+			booking2: &domain.Booking{
+				CheckIn: time.Date(2024, 1, 3, 15, 0, 0, 0, madrid),
+				Nights:  2,
+			},
+			expected: false,
+		},
+		{
+			name: "instants compared across timezones",
+			booking1: &domain.Booking{
+				CheckIn: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+				Nights:  1,
+			},
+			booking2: &domain.Booking{
+				CheckIn: time.Date(2024, 1, 1, 19, 0, 0, 0, newYork), // 2024-01-02 00:00 UTC
+				Nights:  1,
+			},
+			expected: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, tt.booking1.OverlapsWith(tt.booking2))
+			assert.Equal(t, tt.expected, tt.booking2.OverlapsWith(tt.booking1))
+		})
+	}
+}
+
+func TestNightsBetween(t *testing.T) {
+	madrid, err := time.LoadLocation("Europe/Madrid")
+	require.NoError(t, err)
+
+	assert.Equal(t, 3, domain.NightsBetween(
+		time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		time.Date(2024, 1, 4, 0, 0, 0, 0, time.UTC),
+	))
+	assert.Equal(t, 2, domain.NightsBetween(
+		time.Date(2024, 3, 30, 15, 0, 0, 0, madrid),
+		time.Date(2024, 4, 1, 11, 0, 0, 0, madrid),
+	))
+	assert.Equal(t, 0, domain.NightsBetween(
+		time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC),
+		time.Date(2024, 1, 1, 18, 0, 0, 0, time.UTC),
+	))
+}
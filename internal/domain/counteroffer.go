@@ -0,0 +1,42 @@
+package domain
+
+import (
+	"context"
+	"math"
+	"slices"
+)
+
+// addCounterOffers sets the counter-offer rate of every booking rejected by result, within the
+// counter-offers phase
+// The rates come from a single enumeration of the combinations, whatever the number of rejected bookings
+func addCounterOffers(ctx context.Context, bookings Bookings, result *MaximizeResult, opts MaximizeOptions) (err error) {
+	ctx, end := opts.startPhase(ctx, PhaseCounterOffers)
+	defer func() { end(err) }()
+
+	if len(result.Rejected) == 0 {
+		return nil
+	}
+	rivals, err := findRivals(ctx, bookings, opts)
+	if err != nil {
+		return err
+	}
+	for _, rejected := range result.Rejected {
+		with := rivals.with[slices.Index(bookings, rejected.Booking)]
+		rejected.CounterOfferRate = counterOfferRate(rejected.Booking, result.Selected, with, opts)
+	}
+
+	return nil
+}
+
+// counterOfferRate returns the minimum selling rate, at the same margin, at which the rejected booking
+// would enter the optimal selection best, rounded up to the cent, with being its best combination.
+// It returns 0 when raising the selling rate cannot change the selection, e.g. when the booking has
+// no margin and profit is maximized
+func counterOfferRate(rejected *Booking, best Bookings, with rival, opts MaximizeOptions) float64 {
+	rate := sellingRateAttribute.riseToSelect(rejected, best, with, opts)
+	if math.IsInf(rate, 1) {
+		return 0
+	}
+
+	return rate
+}
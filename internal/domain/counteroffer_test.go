@@ -0,0 +1,97 @@
+package domain_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/duksonn/stay-for-long/internal/domain"
+)
+
+func TestMaximizeProfit_CounterOffers(t *testing.T) {
+	baseTime := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	bookings := []*domain.Booking{
+		{RequestID: "bookata_XY123", CheckIn: baseTime, Nights: 5, SellingRate: 200, Margin: 20},
+		{RequestID: "kayete_PP234", CheckIn: baseTime.AddDate(0, 0, 3), Nights: 4, SellingRate: 156, Margin: 5},
+		{RequestID: "atropote_AA930", CheckIn: baseTime.AddDate(0, 0, 3), Nights: 4, SellingRate: 150, Margin: 0},
+		{RequestID: "acme_AAAAA", CheckIn: baseTime.AddDate(0, 0, 9), Nights: 4, SellingRate: 160, Margin: 30},
+	}
+
+	tests := []struct {
+		name     string
+		opts     domain.MaximizeOptions
+		expected map[string]float64
+	}{
+		{
+			name: "not requested",
+			opts: domain.MaximizeOptions{},
+			expected: map[string]float64{
+				"atropote_AA930": 0,
+				"kayete_PP234":   0,
+			},
+		},
+		{
+			name: "profit objective",
+			opts: domain.MaximizeOptions{CounterOffers: true},
+			expected: map[string]float64{
+				"atropote_AA930": 0,     // no margin, no selling rate can make it profitable
+				"kayete_PP234":   800.1, // 5% of 800.1 beats, once rounded, the 40 earned by bookata_XY123
+			},
+		},
+		{
+			name: "revenue objective",
+			opts: domain.MaximizeOptions{Objective: domain.ObjectiveRevenue, CounterOffers: true},
+			expected: map[string]float64{
+				"atropote_AA930": 200,    // ties bookata_XY123 and wins on request IDs
+				"kayete_PP234":   200.01, // ties bookata_XY123 at 200 and loses on request IDs
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := domain.MaximizeProfit(context.Background(), bookings, tt.opts)
+			require.NoError(t, err)
+			require.NotNil(t, result)
+			assert.Equal(t, []string{"bookata_XY123", "acme_AAAAA"}, result.RequestIDs)
+
+			offers := make(map[string]float64)
+			for _, rejected := range result.Rejected {
+				offers[rejected.Booking.RequestID] = rejected.CounterOfferRate
+			}
+			assert.Equal(t, tt.expected, offers)
+		})
+	}
+}
+
+func TestMaximizeProfit_CounterOfferIsSelected(t *testing.T) {
+	baseTime := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	bookings := []*domain.Booking{
+		{RequestID: "bookata_XY123", CheckIn: baseTime, Nights: 5, SellingRate: 200, Margin: 20},
+		{RequestID: "kayete_PP234", CheckIn: baseTime.AddDate(0, 0, 3), Nights: 4, SellingRate: 156, Margin: 5},
+	}
+
+	result, err := domain.MaximizeProfit(context.Background(), bookings, domain.MaximizeOptions{CounterOffers: true})
+	require.NoError(t, err)
+	require.Len(t, result.Rejected, 1)
+	offer := result.Rejected[0].CounterOfferRate
+
+	accepted := []*domain.Booking{
+		bookings[0],
+		{RequestID: "kayete_PP234", CheckIn: baseTime.AddDate(0, 0, 3), Nights: 4, SellingRate: offer, Margin: 5},
+	}
+	acceptedResult, err := domain.MaximizeProfit(context.Background(), accepted, domain.MaximizeOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"kayete_PP234"}, acceptedResult.RequestIDs)
+
+	declined := []*domain.Booking{
+		bookings[0],
+		{RequestID: "kayete_PP234", CheckIn: baseTime.AddDate(0, 0, 3), Nights: 4, SellingRate: offer - 0.01, Margin: 5},
+	}
+	declinedResult, err := domain.MaximizeProfit(context.Background(), declined, domain.MaximizeOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"bookata_XY123"}, declinedResult.RequestIDs)
+}
@@ -0,0 +1,260 @@
+package domain
+
+import (
+	"cmp"
+	"context"
+	"math"
+	"math/rand/v2"
+	"slices"
+	"time"
+)
+
+const (
+	// defaultHeuristicBudget is the time budget of the heuristic mode when none is configured
+	defaultHeuristicBudget = 100 * time.Millisecond
+	// heuristicCheckInterval is the number of moves tried between two checks of the budget and the context
+	heuristicCheckInterval = 1 << 10
+	// heuristicSeed seeds the random moves, so runs with the same budget explore the same way
+	heuristicSeed = 1
+	// heuristicEpsilon absorbs the rounding errors accumulated while updating the selection value
+	heuristicEpsilon = 1e-9
+)
+
+// maximizeHeuristic searches a good selection of non-overlapping bookings within the time budget in opts
+// It starts from a greedy selection and improves it by simulated annealing: a random booking is either
+// dropped or added replacing the bookings it overlaps with, and worse selections are accepted with a
+// probability that fades as the budget runs out. The search stops early once it reaches the upper bound,
+// which proves the selection optimal. Ties are not settled by the TieBreak policy
+func maximizeHeuristic(ctx context.Context, bookings Bookings, opts MaximizeOptions) (*MaximizeResult, error) {
+	h := newHeuristic(bookings, opts)
+	start := time.Now()
+	err := h.search(ctx)
+	opts.searchDone(ctx, ModeHeuristic, h.moves, time.Since(start), err)
+	if err != nil {
+		return nil, err
+	}
+
+	result := buildMaximizeResult(bookings, h.best(), opts)
+	result.UpperBound = max(math.Ceil(h.bound*100-heuristicEpsilon)/100, result.Score)
+	if h.bestValue >= h.bound-heuristicEpsilon {
+		// the bound is reached by the selection, which is then optimal whatever the rounding of its score
+		result.UpperBound = result.Score
+	}
+	result.Gap = roundToTwoDecimals(result.UpperBound - result.Score)
+	result.Optimal = result.Gap == 0
+
+	return result, nil
+}
+
+// heuristic holds the state of a simulated annealing search over bookings in canonical order
+type heuristic struct {
+	bookings  Bookings
+	opts      MaximizeOptions
+	values    []float64
+	conflicts [][]int
+	bound     float64
+
+	selected     []bool
+	value        float64
+	bestSelected []bool
+	bestValue    float64
+	moves        int
+}
+
+// newHeuristic prepares the search, scoring every booking on its own and building the greedy selection
+func newHeuristic(bookings Bookings, opts MaximizeOptions) *heuristic {
+	h := &heuristic{
+		bookings:  bookings,
+		opts:      opts,
+		values:    make([]float64, len(bookings)),
+		conflicts: make([][]int, len(bookings)),
+		selected:  make([]bool, len(bookings)),
+	}
+	for i, b := range bookings {
+		h.values[i] = opts.score(Bookings{b})
+		for j := i + 1; j < len(bookings); j++ {
+			if b.OverlapsWith(bookings[j]) {
+				h.conflicts[i] = append(h.conflicts[i], j)
+				h.conflicts[j] = append(h.conflicts[j], i)
+			}
+		}
+	}
+	h.bound = upperBound(bookings, h.values)
+	h.greedy()
+	h.bestSelected = slices.Clone(h.selected)
+	h.bestValue = h.value
+
+	return h
+}
+
+// greedy selects bookings by decreasing value per hour of stay, skipping the ones that overlap
+// with a booking already selected. Bookings without value are never selected
+func (h *heuristic) greedy() {
+	order := make([]int, len(h.bookings))
+	for i := range order {
+		order[i] = i
+	}
+	slices.SortStableFunc(order, func(a, b int) int {
+		return cmp.Compare(h.density(b), h.density(a))
+	})
+
+	for _, i := range order {
+		overlaps := slices.ContainsFunc(h.conflicts[i], func(j int) bool { return h.selected[j] })
+		if h.values[i] > 0 && !overlaps {
+			h.flip(i)
+		}
+	}
+}
+
+// density returns the value of a booking per hour of stay
+// Bookings that do not take any time come first
+func (h *heuristic) density(i int) float64 {
+	hours := stayDuration(h.bookings[i]).Hours()
+	if hours <= 0 {
+		return math.Inf(1)
+	}
+
+	return h.values[i] / hours
+}
+
+// search runs simulated annealing until the budget runs out or the selection reaches the upper bound
+func (h *heuristic) search(ctx context.Context) error {
+	budget := h.opts.Budget
+	if budget <= 0 {
+		budget = defaultHeuristicBudget
+	}
+	initialTemperature := 0.0
+	for _, v := range h.values {
+		initialTemperature = max(initialTemperature, v/10)
+	}
+
+	rng := rand.New(rand.NewPCG(heuristicSeed, heuristicSeed))
+	start := time.Now()
+	temperature := initialTemperature
+	for move := 0; len(h.bookings) > 0 && h.bestValue < h.bound-heuristicEpsilon; move++ {
+		if move%heuristicCheckInterval == 0 {
+			if err := ctx.Err(); err != nil {
+				return abortError(ctx, err)
+			}
+			elapsed := time.Since(start)
+			if elapsed >= budget {
+				break
+			}
+			h.progress(elapsed, budget)
+			temperature = initialTemperature * (1 - float64(elapsed)/float64(budget))
+		}
+
+		h.moves++
+		i := rng.IntN(len(h.bookings))
+		delta := h.delta(i)
+		if delta < 0 && (temperature <= 0 || rng.Float64() >= math.Exp(delta/temperature)) {
+			continue
+		}
+		h.flip(i)
+		if h.value > h.bestValue+heuristicEpsilon {
+			copy(h.bestSelected, h.selected)
+			h.bestValue = h.value
+		}
+	}
+	h.progress(budget, budget)
+
+	return nil
+}
+
+// delta returns how much the selection value changes when booking i is flipped
+// Adding a booking drops the selected bookings it overlaps with
+func (h *heuristic) delta(i int) float64 {
+	if h.selected[i] {
+		return -h.values[i]
+	}
+
+	delta := h.values[i]
+	for _, j := range h.conflicts[i] {
+		if h.selected[j] {
+			delta -= h.values[j]
+		}
+	}
+
+	return delta
+}
+
+// flip drops booking i from the selection or adds it, dropping the selected bookings it overlaps with
+func (h *heuristic) flip(i int) {
+	h.value += h.delta(i)
+	if h.selected[i] {
+		h.selected[i] = false
+		return
+	}
+
+	h.selected[i] = true
+	for _, j := range h.conflicts[i] {
+		h.selected[j] = false
+	}
+}
+
+// best returns the best selection found so far, in canonical order
+func (h *heuristic) best() Bookings {
+	best := make(Bookings, 0, len(h.bookings))
+	for i, b := range h.bookings {
+		if h.bestSelected[i] {
+			best = append(best, b)
+		}
+	}
+
+	return best
+}
+
+// progress reports the time spent in the budget together with the best selection found so far
+func (h *heuristic) progress(elapsed, budget time.Duration) {
+	if h.opts.OnProgress == nil {
+		return
+	}
+
+	best := h.best()
+	total := max(int(budget.Milliseconds()), 1)
+	h.opts.OnProgress(SearchProgress{
+		Evaluated:  min(int(elapsed.Milliseconds()), total),
+		Total:      total,
+		BestScore:  h.opts.score(best),
+		BestProfit: best.TotalProfit(),
+	})
+}
+
+// upperBound returns the highest value a selection of non-overlapping bookings can add up to
+// Bookings overlap on a single timeline, so the bound is solved exactly by weighted interval scheduling:
+// in order of check-out, each booking either stays out or joins the best selection of the bookings
+// leaving by its check-in. Bookings without value never join a selection
+func upperBound(bookings Bookings, values []float64) float64 {
+	order := make([]int, 0, len(bookings))
+	for i := range bookings {
+		if values[i] > 0 {
+			order = append(order, i)
+		}
+	}
+	// on equal check-outs, a booking that does not take any time comes after the stay it ends with
+	slices.SortFunc(order, func(a, b int) int {
+		if c := bookings[a].End().Compare(bookings[b].End()); c != 0 {
+			return c
+		}
+		return bookings[a].CheckIn.Compare(bookings[b].CheckIn)
+	})
+
+	// best[k] is the highest value of a selection among the first k bookings of order
+	best := make([]float64, len(order)+1)
+	for k, i := range order {
+		compatible, _ := slices.BinarySearchFunc(order[:k], bookings[i].CheckIn, func(j int, checkIn time.Time) int {
+			if bookings[j].End().After(checkIn) {
+				return 1
+			}
+			return -1
+		})
+		best[k+1] = max(best[k], best[compatible]+values[i])
+	}
+
+	return best[len(order)]
+}
+
+// stayDuration returns how long a booking holds the property
+func stayDuration(b *Booking) time.Duration {
+	return b.End().Sub(b.CheckIn)
+}
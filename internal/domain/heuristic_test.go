@@ -0,0 +1,154 @@
+package domain_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/duksonn/stay-for-long/internal/domain"
+)
+
+func TestParseMode(t *testing.T) {
+	tests := []struct {
+		name     string
+		value    string
+		expected domain.Mode
+		wantErr  error
+	}{
+		{name: "empty defaults to exact", value: "", expected: domain.ModeExact},
+		{name: "exact", value: "exact", expected: domain.ModeExact},
+		{name: "heuristic", value: "heuristic", expected: domain.ModeHeuristic},
+		{name: "unknown", value: "fast", wantErr: domain.ErrInvalidMode},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mode, err := domain.ParseMode(tt.value)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, mode)
+		})
+	}
+}
+
+func TestMaximizeProfit_Heuristic(t *testing.T) {
+	baseTime := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	heuristic := domain.MaximizeOptions{Mode: domain.ModeHeuristic, Budget: 20 * time.Millisecond}
+
+	tests := []struct {
+		name       string
+		bookings   []*domain.Booking
+		expected   []string
+		score      float64
+		upperBound float64
+		optimal    bool
+	}{
+		{
+			name:       "empty bookings",
+			bookings:   []*domain.Booking{},
+			expected:   []string{},
+			optimal:    true,
+			upperBound: 0,
+		},
+		{
+			name: "no overlaps reaches the upper bound",
+			bookings: []*domain.Booking{
+				{RequestID: "req1", CheckIn: baseTime, Nights: 3, SellingRate: 1000, Margin: 20},
+				{RequestID: "req2", CheckIn: baseTime.AddDate(0, 0, 4), Nights: 3, SellingRate: 2000, Margin: 25},
+			},
+			expected:   []string{"req1", "req2"},
+			score:      700,
+			upperBound: 700,
+			optimal:    true,
+		},
+		{
+			name: "short stays beat a long one",
+			bookings: []*domain.Booking{
+				{RequestID: "long", CheckIn: baseTime, Nights: 10, SellingRate: 1000, Margin: 10},
+				{RequestID: "short1", CheckIn: baseTime, Nights: 2, SellingRate: 1000, Margin: 15},
+				{RequestID: "short2", CheckIn: baseTime.AddDate(0, 0, 3), Nights: 2, SellingRate: 1000, Margin: 15},
+			},
+			expected:   []string{"short1", "short2"},
+			score:      300,
+			upperBound: 300,
+			optimal:    true,
+		},
+		{
+			name: "local search improves the greedy selection",
+			bookings: []*domain.Booking{
+				// the densest booking blocks the two that together are worth more
+				{RequestID: "dense", CheckIn: baseTime.AddDate(0, 0, 1), Nights: 2, SellingRate: 1000, Margin: 20},
+				{RequestID: "left", CheckIn: baseTime, Nights: 2, SellingRate: 1000, Margin: 15},
+				{RequestID: "right", CheckIn: baseTime.AddDate(0, 0, 2), Nights: 2, SellingRate: 1000, Margin: 15},
+			},
+			expected:   []string{"left", "right"},
+			score:      300,
+			upperBound: 300,
+			optimal:    true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := domain.MaximizeProfit(context.Background(), tt.bookings, heuristic)
+			require.NoError(t, err)
+
+			assert.Equal(t, tt.expected, result.RequestIDs)
+			assert.Equal(t, tt.score, result.Score)
+			assert.Equal(t, tt.upperBound, result.UpperBound)
+			assert.Equal(t, tt.optimal, result.Optimal)
+			assert.Equal(t, tt.upperBound-tt.score, result.Gap)
+
+			exact, err := domain.MaximizeProfit(context.Background(), tt.bookings, domain.MaximizeOptions{})
+			require.NoError(t, err)
+			assert.True(t, exact.Optimal)
+			assert.Equal(t, exact.Score, exact.UpperBound)
+			assert.Zero(t, exact.Gap)
+			assert.LessOrEqual(t, exact.Score, result.UpperBound)
+		})
+	}
+}
+
+func TestMaximizeProfit_HeuristicBudget(t *testing.T) {
+	baseTime := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	// overlapping stays with different rates keep the selection below the upper bound, so the whole budget is used
+	bookings := make([]*domain.Booking, 0, 200)
+	for i := range 200 {
+		bookings = append(bookings, &domain.Booking{
+			RequestID:   fmt.Sprintf("req%03d", i),
+			CheckIn:     baseTime.AddDate(0, 0, i),
+			Nights:      2 + i%3,
+			SellingRate: float64(100 + (i*37)%50),
+			Margin:      10,
+		})
+	}
+
+	var last domain.SearchProgress
+	opts := domain.MaximizeOptions{
+		Mode:       domain.ModeHeuristic,
+		Budget:     20 * time.Millisecond,
+		OnProgress: func(p domain.SearchProgress) { last = p },
+	}
+	start := time.Now()
+	result, err := domain.MaximizeProfit(context.Background(), bookings, opts)
+	require.NoError(t, err)
+
+	assert.Less(t, time.Since(start), time.Second)
+	assert.Greater(t, result.Score, 0.0)
+	assert.GreaterOrEqual(t, result.UpperBound, result.Score)
+	assert.Equal(t, domain.SearchProgress{Evaluated: 20, Total: 20, BestScore: result.Score, BestProfit: result.TotalProfit}, last)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = domain.MaximizeProfit(ctx, bookings, opts)
+	assert.True(t, errors.Is(err, domain.ErrOptimizationAborted) && errors.Is(err, context.Canceled))
+}
@@ -0,0 +1,64 @@
+package domain
+
+import (
+	"errors"
+	"time"
+)
+
+var (
+	// ErrJobNotFound is returned when no job exists with the requested ID
+	ErrJobNotFound = errors.New("job not found")
+	// ErrJobFinished is returned when canceling a job that already reached a final status
+	ErrJobFinished = errors.New("job already finished")
+	// ErrJobQueueFull is returned when a job is submitted while every worker is busy and the queue is full
+	ErrJobQueueFull = errors.New("job queue is full")
+)
+
+// JobStatus is the lifecycle stage of an asynchronous optimization job
+type JobStatus string
+
+const (
+	// JobQueued means the job waits for a free worker
+	JobQueued JobStatus = "queued"
+	// JobRunning means a worker is searching the optimal selection
+	JobRunning JobStatus = "running"
+	// JobSucceeded means the job finished and holds its result
+	JobSucceeded JobStatus = "succeeded"
+	// JobFailed means the job stopped with an error, e.g. it ran out of time
+	JobFailed JobStatus = "failed"
+	// JobCanceled means the job was canceled before it finished
+	JobCanceled JobStatus = "canceled"
+)
+
+// Finished reports whether the status is final
+func (s JobStatus) Finished() bool {
+	return s == JobSucceeded || s == JobFailed || s == JobCanceled
+}
+
+// Job is an asynchronous MaximizeProfit run
+// Progress goes from 0 to 1 as the combinations are evaluated and BestProfit is the total profit of the
+// best selection found so far. Result is set once the job succeeds and Error describes why it failed
+type Job struct {
+	ID         string
+	Status     JobStatus
+	Progress   float64
+	BestProfit float64
+	Result     *MaximizeResult
+	Error      string
+	CreatedAt  time.Time
+	StartedAt  time.Time
+	UpdatedAt  time.Time
+}
+
+// Elapsed returns how long the job has been running at now, or how long it ran once finished
+// It is zero while the job is queued
+func (j *Job) Elapsed(now time.Time) time.Duration {
+	if j.StartedAt.IsZero() {
+		return 0
+	}
+	if j.Status.Finished() {
+		now = j.UpdatedAt
+	}
+
+	return now.Sub(j.StartedAt)
+}
@@ -0,0 +1,43 @@
+package domain_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/duksonn/stay-for-long/internal/domain"
+)
+
+func TestJob_Elapsed(t *testing.T) {
+	start := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	now := start.Add(time.Minute)
+
+	tests := []struct {
+		name     string
+		job      *domain.Job
+		expected time.Duration
+	}{
+		{
+			name:     "queued",
+			job:      &domain.Job{Status: domain.JobQueued},
+			expected: 0,
+		},
+		{
+			name:     "running",
+			job:      &domain.Job{Status: domain.JobRunning, StartedAt: start, UpdatedAt: start.Add(time.Second)},
+			expected: time.Minute,
+		},
+		{
+			name:     "finished",
+			job:      &domain.Job{Status: domain.JobSucceeded, StartedAt: start, UpdatedAt: start.Add(5 * time.Second)},
+			expected: 5 * time.Second,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, tt.job.Elapsed(now))
+		})
+	}
+}
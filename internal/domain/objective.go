@@ -0,0 +1,225 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+var (
+	// ErrInvalidObjective is returned when the optimization objective is unknown
+	ErrInvalidObjective = errors.New("invalid optimization objective")
+	// ErrInvalidWeights is returned when the weighted objective has no usable weights
+	ErrInvalidWeights = errors.New("invalid objective weights")
+	// ErrInvalidMode is returned when the solver mode is unknown or cannot be combined with the other options
+	ErrInvalidMode = errors.New("invalid solver mode")
+	// ErrInvalidBudget is returned when the heuristic time budget is negative
+	ErrInvalidBudget = errors.New("invalid solver budget")
+)
+
+// Objective identifies the metric maximized by the optimizer
+type Objective string
+
+const (
+	// ObjectiveProfit maximizes the total margin earned (default)
+	ObjectiveProfit Objective = "profit"
+	// ObjectiveRevenue maximizes the total selling rate
+	ObjectiveRevenue Objective = "revenue"
+	// ObjectiveOccupancy maximizes the number of occupied nights
+	ObjectiveOccupancy Objective = "occupancy"
+	// ObjectiveWeighted maximizes a weighted blend of profit, revenue and occupancy
+	ObjectiveWeighted Objective = "weighted"
+)
+
+// ParseObjective converts a raw value into an Objective
+// An empty value defaults to ObjectiveProfit
+func ParseObjective(value string) (Objective, error) {
+	switch o := Objective(value); o {
+	case "":
+		return ObjectiveProfit, nil
+	case ObjectiveProfit, ObjectiveRevenue, ObjectiveOccupancy, ObjectiveWeighted:
+		return o, nil
+	default:
+		return "", fmt.Errorf("%w: %q", ErrInvalidObjective, value)
+	}
+}
+
+// Mode selects how the optimal selection is searched
+type Mode string
+
+const (
+	// ModeExact evaluates every combination, so the result is always optimal (default)
+	ModeExact Mode = "exact"
+	// ModeHeuristic improves a greedy selection until its time budget runs out
+	// The result is only optimal when it reaches the upper bound of the problem
+	ModeHeuristic Mode = "heuristic"
+)
+
+// ParseMode converts a raw value into a Mode
+// An empty value defaults to ModeExact
+func ParseMode(value string) (Mode, error) {
+	switch m := Mode(value); m {
+	case "":
+		return ModeExact, nil
+	case ModeExact, ModeHeuristic:
+		return m, nil
+	default:
+		return "", fmt.Errorf("%w: %q", ErrInvalidMode, value)
+	}
+}
+
+// Weights holds the coefficients applied by the weighted objective
+type Weights struct {
+	Profit    float64
+	Revenue   float64
+	Occupancy float64
+}
+
+// SearchProgress describes how far the search for the optimal selection has gone
+// BestScore and BestProfit belong to the best selection found so far. In heuristic mode Evaluated
+// and Total are the milliseconds spent and available in the time budget
+type SearchProgress struct {
+	Evaluated  int
+	Total      int
+	BestScore  float64
+	BestProfit float64
+}
+
+// ProgressFunc receives the progress of the search for the optimal selection
+type ProgressFunc func(progress SearchProgress)
+
+// SearchStats describes a finished search for the optimal selection
+// Evaluated counts the combinations checked in exact mode and the moves tried in heuristic mode.
+// Aborted is set when the search stopped because its context was done
+type SearchStats struct {
+	Mode      Mode
+	Evaluated int
+	Duration  time.Duration
+	Aborted   bool
+}
+
+// SearchDoneFunc receives the stats of a finished search for the optimal selection, with the context it ran with
+type SearchDoneFunc func(ctx context.Context, stats SearchStats)
+
+// Phase names a step of the optimizer reported to OnPhase
+type Phase string
+
+const (
+	// PhaseSearch is the search for the optimal selection
+	PhaseSearch Phase = "search"
+	// PhaseCounterOffers enumerates the combinations once more to find the counter-offer rate of every rejected booking
+	PhaseCounterOffers Phase = "counter_offers"
+	// PhaseSensitivity enumerates the combinations once more to find the deltas of every booking
+	PhaseSensitivity Phase = "sensitivity"
+)
+
+// PhaseFunc is called when a phase of the optimizer starts and returns the context the phase runs with,
+// together with the function called with its outcome when it ends
+type PhaseFunc func(ctx context.Context, phase Phase) (context.Context, func(err error))
+
+// MaximizeOptions configures how the optimizer scores and ranks booking combinations
+// OnProgress, when set, is called periodically while the optimal selection is searched, and OnSearchDone
+// once every search finishes, including the enumerations computing counter-offers or sensitivities.
+// OnPhase, when set, is called as every phase of the optimizer starts, those enumerations running
+// within the counter-offers or sensitivity phase. Budget bounds the search time in heuristic mode and is ignored by the exact mode
+type MaximizeOptions struct {
+	Objective          Objective
+	Weights            Weights
+	TieBreak           TieBreak
+	PreferredProviders []string
+	CounterOffers      bool
+	Mode               Mode
+	Budget             time.Duration
+	OnProgress         ProgressFunc
+	OnSearchDone       SearchDoneFunc
+	OnPhase            PhaseFunc
+}
+
+// Validate checks that the options describe a usable objective and tie-break policy
+func (o MaximizeOptions) Validate() error {
+	objective, err := ParseObjective(string(o.Objective))
+	if err != nil {
+		return err
+	}
+	if err := o.validateTieBreak(); err != nil {
+		return err
+	}
+	if err := o.validateMode(); err != nil {
+		return err
+	}
+	if objective != ObjectiveWeighted {
+		return nil
+	}
+
+	w := o.Weights
+	if w.Profit < 0 || w.Revenue < 0 || w.Occupancy < 0 {
+		return fmt.Errorf("%w: weights cannot be negative", ErrInvalidWeights)
+	}
+	if w.Profit == 0 && w.Revenue == 0 && w.Occupancy == 0 {
+		return fmt.Errorf("%w: at least one weight must be positive", ErrInvalidWeights)
+	}
+
+	return nil
+}
+
+// searchDone reports a finished search to OnSearchDone, when set
+func (o MaximizeOptions) searchDone(ctx context.Context, mode Mode, evaluated int, duration time.Duration, err error) {
+	if o.OnSearchDone == nil {
+		return
+	}
+
+	o.OnSearchDone(ctx, SearchStats{Mode: mode, Evaluated: evaluated, Duration: duration, Aborted: err != nil})
+}
+
+// startPhase reports the start of phase to OnPhase, when set, and returns the context the phase runs with
+// and the function reporting its end
+func (o MaximizeOptions) startPhase(ctx context.Context, phase Phase) (context.Context, func(err error)) {
+	if o.OnPhase == nil {
+		return ctx, func(error) {}
+	}
+
+	return o.OnPhase(ctx, phase)
+}
+
+// validateMode checks the solver mode and its budget
+// Counter-offers are derived from an enumeration of every combination, so they are only offered by the exact mode
+func (o MaximizeOptions) validateMode() error {
+	mode, err := ParseMode(string(o.Mode))
+	if err != nil {
+		return err
+	}
+	if o.Budget < 0 {
+		return fmt.Errorf("%w: budget cannot be negative", ErrInvalidBudget)
+	}
+	if mode == ModeHeuristic && o.CounterOffers {
+		return fmt.Errorf("%w: counter-offers require the exact mode", ErrInvalidMode)
+	}
+
+	return nil
+}
+
+// objective returns the configured objective, falling back to ObjectiveProfit
+func (o MaximizeOptions) objective() Objective {
+	if o.Objective == "" {
+		return ObjectiveProfit
+	}
+
+	return o.Objective
+}
+
+// score evaluates a combination of bookings against the configured objective
+func (o MaximizeOptions) score(bb Bookings) float64 {
+	switch o.objective() {
+	case ObjectiveRevenue:
+		return bb.TotalRevenue()
+	case ObjectiveOccupancy:
+		return float64(bb.TotalNights())
+	case ObjectiveWeighted:
+		return roundToTwoDecimals(o.Weights.Profit*bb.TotalProfit() +
+			o.Weights.Revenue*bb.TotalRevenue() +
+			o.Weights.Occupancy*float64(bb.TotalNights()))
+	default:
+		return bb.TotalProfit()
+	}
+}
@@ -0,0 +1,191 @@
+package domain_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/duksonn/stay-for-long/internal/domain"
+)
+
+func TestParseObjective(t *testing.T) {
+	tests := []struct {
+		name     string
+		value    string
+		expected domain.Objective
+		wantErr  error
+	}{
+		{name: "empty defaults to profit", value: "", expected: domain.ObjectiveProfit},
+		{name: "profit", value: "profit", expected: domain.ObjectiveProfit},
+		{name: "revenue", value: "revenue", expected: domain.ObjectiveRevenue},
+		{name: "occupancy", value: "occupancy", expected: domain.ObjectiveOccupancy},
+		{name: "weighted", value: "weighted", expected: domain.ObjectiveWeighted},
+		{name: "unknown", value: "margin", wantErr: domain.ErrInvalidObjective},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			objective, err := domain.ParseObjective(tt.value)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, objective)
+		})
+	}
+}
+
+func TestMaximizeOptions_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    domain.MaximizeOptions
+		wantErr error
+	}{
+		{name: "zero value", opts: domain.MaximizeOptions{}},
+		{name: "revenue ignores weights", opts: domain.MaximizeOptions{Objective: domain.ObjectiveRevenue}},
+		{
+			name: "weighted with weights",
+			opts: domain.MaximizeOptions{
+				Objective: domain.ObjectiveWeighted,
+				Weights:   domain.Weights{Profit: 1, Occupancy: 10},
+			},
+		},
+		{
+			name:    "weighted without weights",
+			opts:    domain.MaximizeOptions{Objective: domain.ObjectiveWeighted},
+			wantErr: domain.ErrInvalidWeights,
+		},
+		{
+			name: "weighted with negative weight",
+			opts: domain.MaximizeOptions{
+				Objective: domain.ObjectiveWeighted,
+				Weights:   domain.Weights{Profit: 1, Revenue: -1},
+			},
+			wantErr: domain.ErrInvalidWeights,
+		},
+		{
+			name:    "unknown objective",
+			opts:    domain.MaximizeOptions{Objective: "margin"},
+			wantErr: domain.ErrInvalidObjective,
+		},
+		{
+			name: "heuristic with budget",
+			opts: domain.MaximizeOptions{Mode: domain.ModeHeuristic, Budget: time.Second},
+		},
+		{
+			name:    "unknown mode",
+			opts:    domain.MaximizeOptions{Mode: "fast"},
+			wantErr: domain.ErrInvalidMode,
+		},
+		{
+			name:    "negative budget",
+			opts:    domain.MaximizeOptions{Mode: domain.ModeHeuristic, Budget: -time.Second},
+			wantErr: domain.ErrInvalidBudget,
+		},
+		{
+			name:    "heuristic with counter-offers",
+			opts:    domain.MaximizeOptions{Mode: domain.ModeHeuristic, CounterOffers: true},
+			wantErr: domain.ErrInvalidMode,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.opts.Validate()
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestMaximizeProfit_Objectives(t *testing.T) {
+	baseTime := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	// long is the most occupied and highest revenue stay, short1+short2 earn the most margin
+	bookings := []*domain.Booking{
+		{RequestID: "long", CheckIn: baseTime, Nights: 10, SellingRate: 2000, Margin: 5},
+		{RequestID: "short1", CheckIn: baseTime, Nights: 2, SellingRate: 500, Margin: 30},
+		{RequestID: "short2", CheckIn: baseTime.AddDate(0, 0, 3), Nights: 2, SellingRate: 500, Margin: 30},
+	}
+
+	tests := []struct {
+		name          string
+		opts          domain.MaximizeOptions
+		expectedIDs   []string
+		expectedScore float64
+	}{
+		{
+			name:          "profit",
+			opts:          domain.MaximizeOptions{Objective: domain.ObjectiveProfit},
+			expectedIDs:   []string{"short1", "short2"},
+			expectedScore: 300,
+		},
+		{
+			name:          "revenue",
+			opts:          domain.MaximizeOptions{Objective: domain.ObjectiveRevenue},
+			expectedIDs:   []string{"long"},
+			expectedScore: 2000,
+		},
+		{
+			name:          "occupancy",
+			opts:          domain.MaximizeOptions{Objective: domain.ObjectiveOccupancy},
+			expectedIDs:   []string{"long"},
+			expectedScore: 10,
+		},
+		{
+			name: "weighted favours occupancy",
+			opts: domain.MaximizeOptions{
+				Objective: domain.ObjectiveWeighted,
+				Weights:   domain.Weights{Profit: 1, Occupancy: 50},
+			},
+			expectedIDs:   []string{"long"},
+			expectedScore: 600, // 100 + 10 * 50
+		},
+		{
+			name: "weighted favours profit",
+			opts: domain.MaximizeOptions{
+				Objective: domain.ObjectiveWeighted,
+				Weights:   domain.Weights{Profit: 1, Occupancy: 10},
+			},
+			expectedIDs:   []string{"short1", "short2"},
+			expectedScore: 340, // 300 + 4 * 10
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := domain.MaximizeProfit(context.Background(), bookings, tt.opts)
+			require.NoError(t, err)
+			require.NotNil(t, result)
+
+			assert.Equal(t, tt.expectedIDs, result.RequestIDs)
+			assert.Equal(t, tt.opts.Objective, result.Objective)
+			assert.Equal(t, tt.expectedScore, result.Score)
+		})
+	}
+}
+
+func TestMaximizeProfit_Metrics(t *testing.T) {
+	baseTime := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	bookings := []*domain.Booking{
+		{RequestID: "req1", CheckIn: baseTime, Nights: 3, SellingRate: 1000, Margin: 20},
+		{RequestID: "req2", CheckIn: baseTime.AddDate(0, 0, 4), Nights: 3, SellingRate: 2000, Margin: 25},
+	}
+
+	result, err := domain.MaximizeProfit(context.Background(), bookings, domain.MaximizeOptions{})
+	require.NoError(t, err)
+	require.NotNil(t, result)
+
+	assert.Equal(t, domain.ObjectiveProfit, result.Objective)
+	assert.Equal(t, float64(700), result.Score)
+	assert.Equal(t, float64(700), result.TotalProfit)
+	assert.Equal(t, float64(3000), result.TotalRevenue)
+	assert.Equal(t, 6, result.TotalNights)
+}
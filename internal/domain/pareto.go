@@ -0,0 +1,58 @@
+package domain
+
+import (
+	"context"
+	"slices"
+	"sort"
+)
+
+// ParetoFrontier returns the non-overlapping selections that trade profit against occupied nights
+// A selection is kept only when no other selection earns at least as much profit while occupying
+// at least as many nights. Points are ordered by ascending occupied nights, and for a given number
+// of nights only the most profitable selection is reported. Like MaximizeProfit, the frontier
+// does not depend on the input order and equally profitable selections are settled by request IDs
+func ParetoFrontier(ctx context.Context, bookings []*Booking) ([]*MaximizeResult, error) {
+	var opts MaximizeOptions
+	sorted := canonicalOrder(bookings)
+	bestByNights := make(map[int]Bookings)
+	err := forEachCombination(ctx, sorted, nil, func(combo Bookings) {
+		if combo.HasOverlaps() {
+			return
+		}
+		nights := combo.TotalNights()
+		current, ok := bestByNights[nights]
+		if !ok || combo.TotalProfit() > current.TotalProfit() ||
+			(combo.TotalProfit() == current.TotalProfit() && opts.breaksTie(combo, current)) {
+			bestByNights[nights] = slices.Clone(combo)
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	nights := make([]int, 0, len(bestByNights))
+	for n := range bestByNights {
+		nights = append(nights, n)
+	}
+	sort.Sort(sort.Reverse(sort.IntSlice(nights)))
+
+	var frontier []*MaximizeResult
+	maxProfit := -1.0
+	for _, n := range nights {
+		combo := bestByNights[n]
+		if profit := combo.TotalProfit(); profit > maxProfit {
+			maxProfit = profit
+			frontier = append(frontier, buildMaximizeResult(sorted, combo, MaximizeOptions{Objective: ObjectiveProfit}))
+		}
+	}
+
+	// Points were collected from the most to the least occupied selection
+	for i, j := 0, len(frontier)-1; i < j; i, j = i+1, j-1 {
+		frontier[i], frontier[j] = frontier[j], frontier[i]
+	}
+	if frontier == nil {
+		return []*MaximizeResult{}, nil
+	}
+
+	return frontier, nil
+}
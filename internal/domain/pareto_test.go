@@ -0,0 +1,65 @@
+package domain_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/duksonn/stay-for-long/internal/domain"
+)
+
+func TestParetoFrontier(t *testing.T) {
+	baseTime := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name           string
+		bookings       []*domain.Booking
+		expectedIDs    [][]string
+		expectedProfit []float64
+		expectedNights []int
+	}{
+		{
+			name: "profit against occupancy trade-off",
+			bookings: []*domain.Booking{
+				{RequestID: "long", CheckIn: baseTime, Nights: 10, SellingRate: 2000, Margin: 5},
+				{RequestID: "short1", CheckIn: baseTime, Nights: 2, SellingRate: 500, Margin: 30},
+				{RequestID: "short2", CheckIn: baseTime.AddDate(0, 0, 3), Nights: 2, SellingRate: 500, Margin: 30},
+			},
+			expectedIDs:    [][]string{{"short1", "short2"}, {"long"}},
+			expectedProfit: []float64{300, 100},
+			expectedNights: []int{4, 10},
+		},
+		{
+			name: "single dominating selection",
+			bookings: []*domain.Booking{
+				{RequestID: "req1", CheckIn: baseTime, Nights: 3, SellingRate: 1000, Margin: 20},
+				{RequestID: "req2", CheckIn: baseTime.AddDate(0, 0, 4), Nights: 3, SellingRate: 2000, Margin: 25},
+			},
+			expectedIDs:    [][]string{{"req1", "req2"}},
+			expectedProfit: []float64{700},
+			expectedNights: []int{6},
+		},
+		{
+			name:     "empty bookings",
+			bookings: []*domain.Booking{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			frontier, err := domain.ParetoFrontier(context.Background(), tt.bookings)
+			require.NoError(t, err)
+			require.NotNil(t, frontier)
+			require.Len(t, frontier, len(tt.expectedIDs))
+
+			for i, point := range frontier {
+				assert.Equal(t, tt.expectedIDs[i], point.RequestIDs)
+				assert.Equal(t, tt.expectedProfit[i], point.TotalProfit)
+				assert.Equal(t, tt.expectedNights[i], point.TotalNights)
+			}
+		})
+	}
+}
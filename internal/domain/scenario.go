@@ -0,0 +1,205 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+var (
+	// ErrInvalidScenario is returned when a scenario has no name or its name is repeated
+	ErrInvalidScenario = errors.New("invalid scenario")
+	// ErrUnknownRequestID is returned when a patch refers to a request ID that is not in the bookings
+	ErrUnknownRequestID = errors.New("unknown request id")
+)
+
+// Patch is a change applied to a list of bookings to build a what-if scenario
+// Implementations never modify the bookings they receive
+type Patch interface {
+	Apply(bookings Bookings) (Bookings, error)
+}
+
+// RemoveBooking removes the booking with the given request ID
+type RemoveBooking struct {
+	RequestID string
+}
+
+// Apply returns the bookings without the one matching RequestID
+func (p RemoveBooking) Apply(bookings Bookings) (Bookings, error) {
+	patched := slices.DeleteFunc(slices.Clone(bookings), func(b *Booking) bool {
+		return b.RequestID == p.RequestID
+	})
+	if len(patched) == len(bookings) {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownRequestID, p.RequestID)
+	}
+
+	return patched, nil
+}
+
+// AdjustMargin adds Delta percentage points to the margin of the bookings from Provider
+// An empty Provider adjusts every booking
+type AdjustMargin struct {
+	Provider string
+	Delta    float64
+}
+
+// Apply returns the bookings with the margin of the matching providers adjusted
+func (p AdjustMargin) Apply(bookings Bookings) (Bookings, error) {
+	patched := make(Bookings, 0, len(bookings))
+	for _, b := range bookings {
+		if p.Provider == "" || strings.EqualFold(b.Provider(), p.Provider) {
+			adjusted := *b
+			adjusted.Margin += p.Delta
+			b = &adjusted
+		}
+		patched = append(patched, b)
+	}
+
+	return patched, nil
+}
+
+// Blackout closes the property for a period, modelled as a stay so it reuses the booking overlap rules
+// Every booking overlapping the period is removed
+type Blackout struct {
+	Period *Booking
+}
+
+// Apply returns the bookings that do not overlap the blackout period
+func (p Blackout) Apply(bookings Bookings) (Bookings, error) {
+	return slices.DeleteFunc(slices.Clone(bookings), func(b *Booking) bool {
+		return b.OverlapsWith(p.Period)
+	}), nil
+}
+
+// AddBooking adds a new booking request
+type AddBooking struct {
+	Booking *Booking
+}
+
+// Apply returns the bookings with the new one appended
+func (p AddBooking) Apply(bookings Bookings) (Bookings, error) {
+	return append(slices.Clone(bookings), p.Booking), nil
+}
+
+// Scenario is a named list of patches applied, in order, to the base bookings
+type Scenario struct {
+	Name    string
+	Patches []Patch
+}
+
+// ScenarioResult holds the optimal selection and the stats of a list of bookings
+// Diff is nil for the base and holds the changes against the base for every scenario
+type ScenarioResult struct {
+	Name     string
+	Maximize *MaximizeResult
+	Stats    *StatsResult
+	Diff     *ScenarioDiff
+}
+
+// ScenarioDiff contains the differences of a scenario against the base, computed as scenario minus base
+// Added and Removed list the request IDs that enter or leave the optimal selection
+type ScenarioDiff struct {
+	Score        float64
+	TotalProfit  float64
+	TotalRevenue float64
+	TotalNights  int
+	AvgNight     float64
+	MinNight     float64
+	MaxNight     float64
+	Stats        StatsResult
+	Added        []string
+	Removed      []string
+}
+
+// ScenarioComparison contains the base result followed by the result of every scenario
+type ScenarioComparison struct {
+	Base      *ScenarioResult
+	Scenarios []*ScenarioResult
+}
+
+// CompareScenarios evaluates the base bookings and every scenario with the same optimizer options
+// and reports each scenario together with its differences against the base
+func CompareScenarios(ctx context.Context, base []*Booking, scenarios []Scenario, opts MaximizeOptions) (*ScenarioComparison, error) {
+	names := make(map[string]bool, len(scenarios))
+	for _, s := range scenarios {
+		if s.Name == "" || names[s.Name] {
+			return nil, fmt.Errorf("%w: names must be unique and not empty", ErrInvalidScenario)
+		}
+		names[s.Name] = true
+	}
+
+	baseResult, err := evaluateScenario(ctx, "base", base, opts)
+	if err != nil {
+		return nil, err
+	}
+	comparison := &ScenarioComparison{
+		Base:      baseResult,
+		Scenarios: make([]*ScenarioResult, 0, len(scenarios)),
+	}
+	for _, s := range scenarios {
+		bookings := Bookings(base)
+		for _, patch := range s.Patches {
+			if bookings, err = patch.Apply(bookings); err != nil {
+				return nil, fmt.Errorf("scenario %q: %w", s.Name, err)
+			}
+		}
+
+		result, err := evaluateScenario(ctx, s.Name, bookings, opts)
+		if err != nil {
+			return nil, err
+		}
+		result.Diff = diffScenarios(comparison.Base, result)
+		comparison.Scenarios = append(comparison.Scenarios, result)
+	}
+
+	return comparison, nil
+}
+
+// evaluateScenario computes the optimal selection and the stats of a list of bookings
+func evaluateScenario(ctx context.Context, name string, bookings Bookings, opts MaximizeOptions) (*ScenarioResult, error) {
+	maximize, err := MaximizeProfit(ctx, bookings, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ScenarioResult{
+		Name:     name,
+		Maximize: maximize,
+		Stats:    bookings.CalculateStats(),
+	}, nil
+}
+
+// diffScenarios computes the differences of a scenario against the base
+func diffScenarios(base, scenario *ScenarioResult) *ScenarioDiff {
+	b, s := base.Maximize, scenario.Maximize
+	diff := &ScenarioDiff{
+		Score:        roundToTwoDecimals(s.Score - b.Score),
+		TotalProfit:  roundToTwoDecimals(s.TotalProfit - b.TotalProfit),
+		TotalRevenue: roundToTwoDecimals(s.TotalRevenue - b.TotalRevenue),
+		TotalNights:  s.TotalNights - b.TotalNights,
+		AvgNight:     roundToTwoDecimals(s.AvgNight - b.AvgNight),
+		MinNight:     roundToTwoDecimals(s.MinNight - b.MinNight),
+		MaxNight:     roundToTwoDecimals(s.MaxNight - b.MaxNight),
+		Stats: StatsResult{
+			AvgNight: roundToTwoDecimals(scenario.Stats.AvgNight - base.Stats.AvgNight),
+			MinNight: roundToTwoDecimals(scenario.Stats.MinNight - base.Stats.MinNight),
+			MaxNight: roundToTwoDecimals(scenario.Stats.MaxNight - base.Stats.MaxNight),
+		},
+		Added:   []string{},
+		Removed: []string{},
+	}
+	for _, id := range s.RequestIDs {
+		if !slices.Contains(b.RequestIDs, id) {
+			diff.Added = append(diff.Added, id)
+		}
+	}
+	for _, id := range b.RequestIDs {
+		if !slices.Contains(s.RequestIDs, id) {
+			diff.Removed = append(diff.Removed, id)
+		}
+	}
+
+	return diff
+}
@@ -0,0 +1,151 @@
+package domain_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/duksonn/stay-for-long/internal/domain"
+)
+
+func scenarioBookings() []*domain.Booking {
+	baseTime := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	return []*domain.Booking{
+		{RequestID: "bookata_XY123", CheckIn: baseTime, Nights: 5, SellingRate: 200, Margin: 20},
+		{RequestID: "kayete_PP234", CheckIn: baseTime.AddDate(0, 0, 3), Nights: 4, SellingRate: 156, Margin: 5},
+		{RequestID: "acme_AAAAA", CheckIn: baseTime.AddDate(0, 0, 9), Nights: 4, SellingRate: 160, Margin: 30},
+	}
+}
+
+func TestPatches_Apply(t *testing.T) {
+	baseTime := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name        string
+		patch       domain.Patch
+		expectedIDs []string
+		wantErr     error
+	}{
+		{
+			name:        "remove booking",
+			patch:       domain.RemoveBooking{RequestID: "kayete_PP234"},
+			expectedIDs: []string{"bookata_XY123", "acme_AAAAA"},
+		},
+		{
+			name:    "remove unknown booking",
+			patch:   domain.RemoveBooking{RequestID: "unknown"},
+			wantErr: domain.ErrUnknownRequestID,
+		},
+		{
+			name:        "blackout week",
+			patch:       domain.Blackout{Period: &domain.Booking{CheckIn: baseTime, Nights: 7}},
+			expectedIDs: []string{"acme_AAAAA"},
+		},
+		{
+			name: "add booking",
+			patch: domain.AddBooking{Booking: &domain.Booking{
+				RequestID: "new_1", CheckIn: baseTime.AddDate(0, 0, 20), Nights: 2, SellingRate: 100, Margin: 10,
+			}},
+			expectedIDs: []string{"bookata_XY123", "kayete_PP234", "acme_AAAAA", "new_1"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bookings := scenarioBookings()
+			patched, err := tt.patch.Apply(bookings)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedIDs, patched.RequestIDs())
+			assert.Len(t, bookings, 3, "the original bookings must not be modified")
+		})
+	}
+}
+
+func TestAdjustMargin_Apply(t *testing.T) {
+	bookings := scenarioBookings()
+
+	patched, err := domain.AdjustMargin{Provider: "ACME", Delta: 5}.Apply(bookings)
+	require.NoError(t, err)
+
+	assert.Equal(t, float64(35), patched[2].Margin)
+	assert.Equal(t, float64(30), bookings[2].Margin, "the original bookings must not be modified")
+	assert.Same(t, bookings[0], patched[0])
+}
+
+func TestCompareScenarios(t *testing.T) {
+	scenarios := []domain.Scenario{
+		{
+			Name:    "without bookata",
+			Patches: []domain.Patch{domain.RemoveBooking{RequestID: "bookata_XY123"}},
+		},
+		{
+			Name: "kayete margin up",
+			Patches: []domain.Patch{
+				domain.AdjustMargin{Provider: "kayete", Delta: 25},
+			},
+		},
+	}
+
+	comparison, err := domain.CompareScenarios(context.Background(), scenarioBookings(), scenarios, domain.MaximizeOptions{})
+	require.NoError(t, err)
+
+	assert.Equal(t, "base", comparison.Base.Name)
+	assert.Nil(t, comparison.Base.Diff)
+	assert.Equal(t, []string{"bookata_XY123", "acme_AAAAA"}, comparison.Base.Maximize.RequestIDs)
+	require.Len(t, comparison.Scenarios, 2)
+
+	removed := comparison.Scenarios[0]
+	assert.Equal(t, "without bookata", removed.Name)
+	assert.Equal(t, []string{"kayete_PP234", "acme_AAAAA"}, removed.Maximize.RequestIDs)
+	assert.Equal(t, -32.2, removed.Diff.TotalProfit) // 55.8 - 88
+	assert.Equal(t, -1, removed.Diff.TotalNights)
+	assert.Equal(t, []string{"kayete_PP234"}, removed.Diff.Added)
+	assert.Equal(t, []string{"bookata_XY123"}, removed.Diff.Removed)
+
+	raised := comparison.Scenarios[1]
+	assert.Equal(t, []string{"kayete_PP234", "acme_AAAAA"}, raised.Maximize.RequestIDs)
+	assert.Equal(t, 6.8, raised.Diff.TotalProfit) // 156 * 30% = 46.8 now beats the 40 of bookata_XY123
+	assert.Equal(t, []string{"kayete_PP234"}, raised.Diff.Added)
+	assert.Equal(t, []string{"bookata_XY123"}, raised.Diff.Removed)
+	assert.Equal(t, 3.25, raised.Diff.Stats.AvgNight) // (8 + 11.7 + 12) / 3 against (8 + 1.95 + 12) / 3
+}
+
+func TestCompareScenarios_Errors(t *testing.T) {
+	tests := []struct {
+		name      string
+		scenarios []domain.Scenario
+		wantErr   error
+	}{
+		{
+			name:      "empty name",
+			scenarios: []domain.Scenario{{Name: ""}},
+			wantErr:   domain.ErrInvalidScenario,
+		},
+		{
+			name:      "duplicated name",
+			scenarios: []domain.Scenario{{Name: "a"}, {Name: "a"}},
+			wantErr:   domain.ErrInvalidScenario,
+		},
+		{
+			name: "failing patch",
+			scenarios: []domain.Scenario{
+				{Name: "a", Patches: []domain.Patch{domain.RemoveBooking{RequestID: "unknown"}}},
+			},
+			wantErr: domain.ErrUnknownRequestID,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := domain.CompareScenarios(context.Background(), scenarioBookings(), tt.scenarios, domain.MaximizeOptions{})
+			assert.ErrorIs(t, err, tt.wantErr)
+		})
+	}
+}
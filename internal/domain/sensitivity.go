@@ -0,0 +1,263 @@
+package domain
+
+import (
+	"context"
+	"math"
+	"slices"
+	"time"
+)
+
+// SensitivityResult describes how robust the optimal selection is to changes in each booking
+type SensitivityResult struct {
+	Selection *MaximizeResult
+	Bookings  []*BookingSensitivity
+}
+
+// BookingSensitivity reports how far a booking's values can move before the optimal selection changes
+// For an accepted booking the deltas are how far its selling rate or margin could fall while keeping the
+// same selection. For a rejected booking they are how far the value would need to rise for it to be selected.
+// A delta is +Inf when no change of that value alters the selection
+type BookingSensitivity struct {
+	Booking          *Booking
+	Accepted         bool
+	SellingRateDelta float64
+	MarginDelta      float64
+}
+
+// Sensitivity computes the optimal selection for the objective in opts together with the
+// selling rate and margin sensitivity of every booking, in canonical order
+// The deltas only hold for an optimal selection, so it is always searched in exact mode
+func Sensitivity(ctx context.Context, bookings []*Booking, opts MaximizeOptions) (*SensitivityResult, error) {
+	sorted := canonicalOrder(bookings)
+	searchCtx, endSearch := opts.startPhase(ctx, PhaseSearch)
+	best, err := findBestCombination(searchCtx, sorted, opts)
+	endSearch(err)
+	if err != nil {
+		return nil, err
+	}
+	selection := buildMaximizeResult(sorted, best, opts)
+
+	deltas, err := bookingSensitivities(ctx, sorted, best, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	return &SensitivityResult{Selection: selection, Bookings: deltas}, nil
+}
+
+// bookingSensitivities computes the deltas of every booking against the optimal selection best,
+// within the sensitivity phase
+func bookingSensitivities(ctx context.Context, sorted, best Bookings, opts MaximizeOptions) (_ []*BookingSensitivity, err error) {
+	ctx, end := opts.startPhase(ctx, PhaseSensitivity)
+	defer func() { end(err) }()
+
+	rivals, err := findRivals(ctx, sorted, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	deltas := make([]*BookingSensitivity, 0, len(sorted))
+	for i, b := range sorted {
+		s := &BookingSensitivity{Booking: b, Accepted: slices.Contains(best, b)}
+		if s.Accepted {
+			s.SellingRateDelta = sellingRateAttribute.fallBeforeChange(b, best, rivals.without[i], opts)
+			s.MarginDelta = marginAttribute.fallBeforeChange(b, best, rivals.without[i], opts)
+		} else {
+			s.SellingRateDelta = sellingRateAttribute.riseDelta(b, best, rivals.with[i], opts)
+			s.MarginDelta = marginAttribute.riseDelta(b, best, rivals.with[i], opts)
+		}
+		deltas = append(deltas, s)
+	}
+
+	return deltas, nil
+}
+
+// rival is a combination of bookings together with its score
+type rival struct {
+	combo Bookings
+	score float64
+}
+
+// outrankedBy reports whether the combination candidate, scoring score, would be selected over r
+// It applies the same ranking as the exact search
+func (r rival) outrankedBy(candidate Bookings, score float64, opts MaximizeOptions) bool {
+	return score > r.score || (score == r.score && opts.breaksTie(candidate, r.combo))
+}
+
+// rivals holds, for every booking, the best combination containing it and the best one leaving it out
+// Changing a single booking's value moves the score of every combination containing it by the same
+// amount, so these two combinations are the only ones that can take over the selection
+type rivals struct {
+	with    []rival
+	without []rival
+}
+
+// findRivals enumerates every combination once and keeps the rivals of each booking
+// Rivals start unset with a score of -1, as the best combination does in the exact search
+func findRivals(ctx context.Context, bookings Bookings, opts MaximizeOptions) (*rivals, error) {
+	r := &rivals{with: make([]rival, len(bookings)), without: make([]rival, len(bookings))}
+	for i := range bookings {
+		r.with[i].score, r.without[i].score = -1, -1
+	}
+
+	start := time.Now()
+	evaluated := 0
+	err := forEachCombination(ctx, bookings, nil, func(combo Bookings) {
+		evaluated++
+		if combo.HasOverlaps() {
+			return
+		}
+		score := opts.score(combo)
+		// combo keeps the order of bookings, so membership is checked walking both at once
+		next := 0
+		for i, b := range bookings {
+			current := &r.without[i]
+			if next < len(combo) && combo[next] == b {
+				current = &r.with[i]
+				next++
+			}
+			if current.outrankedBy(combo, score, opts) {
+				*current = rival{combo: slices.Clone(combo), score: score}
+			}
+		}
+	})
+	opts.searchDone(ctx, ModeExact, evaluated, time.Since(start), err)
+	if err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+// attribute is a booking value the optimizer is sensitive to, searched with cent precision
+type attribute struct {
+	get func(*Booking) float64
+	set func(*Booking, float64)
+	// slope returns how much the score of a combination grows per unit of the attribute
+	slope func(MaximizeOptions, *Booking) float64
+}
+
+var sellingRateAttribute = attribute{
+	get: func(b *Booking) float64 { return b.SellingRate },
+	set: func(b *Booking, v float64) { b.SellingRate = v },
+	slope: func(o MaximizeOptions, b *Booking) float64 {
+		switch o.objective() {
+		case ObjectiveRevenue:
+			return 1
+		case ObjectiveOccupancy:
+			return 0
+		case ObjectiveWeighted:
+			return o.Weights.Profit*b.Margin/100 + o.Weights.Revenue
+		default:
+			return b.Margin / 100
+		}
+	},
+}
+
+var marginAttribute = attribute{
+	get: func(b *Booking) float64 { return b.Margin },
+	set: func(b *Booking, v float64) { b.Margin = v },
+	slope: func(o MaximizeOptions, b *Booking) float64 {
+		switch o.objective() {
+		case ObjectiveRevenue, ObjectiveOccupancy:
+			return 0
+		case ObjectiveWeighted:
+			return o.Weights.Profit * b.SellingRate / 100
+		default:
+			return b.SellingRate / 100
+		}
+	},
+}
+
+// riseToSelect returns the minimum value, rounded up to the cent, at which the rejected booking enters
+// the optimal selection best, or +Inf when raising the value cannot get it selected
+//
+// The booking enters once its best combination, with, outranks best. Both scores are linear in the value,
+// so the threshold is solved from the slope and then settled to the cent against the rounded scores
+func (a attribute) riseToSelect(rejected *Booking, best Bookings, with rival, opts MaximizeOptions) float64 {
+	slope := a.slope(opts, rejected)
+	if slope <= 0 {
+		return math.Inf(1)
+	}
+
+	selection := rival{combo: best, score: opts.score(best)}
+	estimate := a.get(rejected) + (selection.score-with.score)/slope
+	selected := func(cents int64) bool {
+		candidate := a.withValue(with.combo, rejected, cents)
+		return selection.outrankedBy(candidate, opts.score(candidate), opts)
+	}
+
+	return float64(settleCents(int64(math.Ceil(estimate*100)), selected)) / 100
+}
+
+// fallBeforeChange returns how far the value of an accepted booking can fall, rounded to the cent,
+// before the optimal selection best is replaced by without, the best combination leaving the booking
+// out, or +Inf when it survives a value of zero
+func (a attribute) fallBeforeChange(accepted *Booking, best Bookings, without rival, opts MaximizeOptions) float64 {
+	slope := a.slope(opts, accepted)
+	if slope <= 0 {
+		return math.Inf(1)
+	}
+
+	holds := func(cents int64) bool {
+		candidate := a.withValue(best, accepted, cents)
+		return !rival{combo: candidate, score: opts.score(candidate)}.outrankedBy(without.combo, without.score, opts)
+	}
+
+	current := a.get(accepted)
+	high := int64(math.Floor(current * 100))
+	if holds(0) {
+		return math.Inf(1)
+	}
+	if !holds(high) {
+		return roundToTwoDecimals(current - float64(high)/100)
+	}
+	estimate := current - (opts.score(best)-without.score)/slope
+	lowest := settleCents(min(max(int64(math.Ceil(estimate*100)), 1), high), holds)
+
+	return roundToTwoDecimals(current - float64(lowest-1)/100)
+}
+
+// riseDelta converts the value at which a rejected booking gets selected into the required increase
+func (a attribute) riseDelta(rejected *Booking, best Bookings, with rival, opts MaximizeOptions) float64 {
+	value := a.riseToSelect(rejected, best, with, opts)
+	if math.IsInf(value, 1) {
+		return value
+	}
+
+	return roundToTwoDecimals(value - a.get(rejected))
+}
+
+// withValue returns a copy of combo where target has its value replaced by cents
+func (a attribute) withValue(combo Bookings, target *Booking, cents int64) Bookings {
+	candidate := *target
+	a.set(&candidate, float64(cents)/100)
+
+	patched := slices.Clone(combo)
+	patched[slices.Index(patched, target)] = &candidate
+
+	return patched
+}
+
+// settleCents returns the lowest cent value at which holds is true, holds being false below it
+// and true from it on. The search gallops away from estimate until it brackets the threshold, so
+// an estimate off by the rounding of the scores only costs a few evaluations
+func settleCents(estimate int64, holds func(cents int64) bool) int64 {
+	low, high := estimate-1, estimate
+	for step := int64(1); !holds(high); step *= 2 {
+		low, high = high, high+step
+	}
+	for step := int64(1); holds(low); step *= 2 {
+		low, high = low-step, low
+	}
+	for high-low > 1 {
+		mid := low + (high-low)/2
+		if holds(mid) {
+			high = mid
+		} else {
+			low = mid
+		}
+	}
+
+	return high
+}
@@ -0,0 +1,69 @@
+package domain_test
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/duksonn/stay-for-long/internal/domain"
+)
+
+func TestSensitivity(t *testing.T) {
+	baseTime := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	bookings := []*domain.Booking{
+		{RequestID: "bookata_XY123", CheckIn: baseTime, Nights: 5, SellingRate: 200, Margin: 20},
+		{RequestID: "kayete_PP234", CheckIn: baseTime.AddDate(0, 0, 3), Nights: 4, SellingRate: 156, Margin: 5},
+		{RequestID: "acme_AAAAA", CheckIn: baseTime.AddDate(0, 0, 9), Nights: 4, SellingRate: 160, Margin: 30},
+	}
+
+	tests := []struct {
+		name     string
+		opts     domain.MaximizeOptions
+		expected map[string]domain.BookingSensitivity
+	}{
+		{
+			name: "profit objective",
+			opts: domain.MaximizeOptions{},
+			expected: map[string]domain.BookingSensitivity{
+				// bookata_XY123 earns 40 against the 7.8 of kayete_PP234 and wins a tie on request IDs,
+				// so they only swap once its rounded profit drops below 7.8
+				"bookata_XY123": {Accepted: true, SellingRateDelta: 161.03, MarginDelta: 16.11},
+				// acme_AAAAA overlaps nothing, no decrease can remove it
+				"acme_AAAAA": {Accepted: true, SellingRateDelta: math.Inf(1), MarginDelta: math.Inf(1)},
+				// kayete_PP234 must earn more than the 40 of bookata_XY123 to replace it
+				"kayete_PP234": {Accepted: false, SellingRateDelta: 644.1, MarginDelta: 20.65},
+			},
+		},
+		{
+			name: "occupancy objective ignores rates and margins",
+			opts: domain.MaximizeOptions{Objective: domain.ObjectiveOccupancy},
+			expected: map[string]domain.BookingSensitivity{
+				"bookata_XY123": {Accepted: true, SellingRateDelta: math.Inf(1), MarginDelta: math.Inf(1)},
+				"acme_AAAAA":    {Accepted: true, SellingRateDelta: math.Inf(1), MarginDelta: math.Inf(1)},
+				"kayete_PP234":  {Accepted: false, SellingRateDelta: math.Inf(1), MarginDelta: math.Inf(1)},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := domain.Sensitivity(context.Background(), bookings, tt.opts)
+			require.NoError(t, err)
+			require.NotNil(t, result)
+			require.NotNil(t, result.Selection)
+			assert.Equal(t, []string{"bookata_XY123", "acme_AAAAA"}, result.Selection.RequestIDs)
+			require.Len(t, result.Bookings, len(bookings))
+
+			for _, s := range result.Bookings {
+				expected := tt.expected[s.Booking.RequestID]
+				assert.Equal(t, expected.Accepted, s.Accepted, s.Booking.RequestID)
+				assert.Equal(t, expected.SellingRateDelta, s.SellingRateDelta, s.Booking.RequestID)
+				assert.Equal(t, expected.MarginDelta, s.MarginDelta, s.Booking.RequestID)
+			}
+		})
+	}
+}
@@ -0,0 +1,140 @@
+package domain
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// ErrInvalidTieBreak is returned when the tie-break policy is unknown or misconfigured
+var ErrInvalidTieBreak = errors.New("invalid tie-break policy")
+
+// TieBreak identifies how the optimizer chooses between selections with the same score
+//
+// Whatever the policy, the optimizer is deterministic: the same set of bookings always yields
+// the same selection regardless of the order in which the bookings are received. When the
+// policy itself cannot separate two selections, the one with the lexicographically smaller
+// sorted request IDs wins
+type TieBreak string
+
+const (
+	// TieBreakRequestIDs prefers the selection with the lexicographically smaller sorted request IDs (default)
+	TieBreakRequestIDs TieBreak = "request_ids"
+	// TieBreakFewestBookings prefers the selection made of fewer bookings
+	TieBreakFewestBookings TieBreak = "fewest_bookings"
+	// TieBreakEarliestCheckIn prefers the selection whose check-ins come first
+	TieBreakEarliestCheckIn TieBreak = "earliest_check_in"
+	// TieBreakPreferredProvider prefers the selection with more bookings from the preferred providers,
+	// compared provider by provider in the configured priority order
+	TieBreakPreferredProvider TieBreak = "preferred_provider"
+)
+
+// ParseTieBreak converts a raw value into a TieBreak
+// An empty value defaults to TieBreakRequestIDs
+func ParseTieBreak(value string) (TieBreak, error) {
+	switch tb := TieBreak(value); tb {
+	case "":
+		return TieBreakRequestIDs, nil
+	case TieBreakRequestIDs, TieBreakFewestBookings, TieBreakEarliestCheckIn, TieBreakPreferredProvider:
+		return tb, nil
+	default:
+		return "", fmt.Errorf("%w: %q", ErrInvalidTieBreak, value)
+	}
+}
+
+// Provider returns the provider prefix of the request ID, e.g. "acme" for "acme_AAAAA"
+func (b *Booking) Provider() string {
+	provider, _, _ := strings.Cut(b.RequestID, "_")
+	return provider
+}
+
+// validateTieBreak checks the tie-break policy and its preferred providers
+func (o MaximizeOptions) validateTieBreak() error {
+	tieBreak, err := ParseTieBreak(string(o.TieBreak))
+	if err != nil {
+		return err
+	}
+	if tieBreak == TieBreakPreferredProvider && len(o.PreferredProviders) == 0 {
+		return fmt.Errorf("%w: preferred providers are required", ErrInvalidTieBreak)
+	}
+
+	return nil
+}
+
+// breaksTie reports whether candidate should replace current when both have the same score
+func (o MaximizeOptions) breaksTie(candidate, current Bookings) bool {
+	if c := o.compareByPolicy(candidate, current); c != 0 {
+		return c < 0
+	}
+	if c := compareRequestIDs(candidate, current); c != 0 {
+		return c < 0
+	}
+
+	return slices.CompareFunc(candidate, current, compareBookings) < 0
+}
+
+// compareByPolicy orders two selections according to the configured tie-break policy
+// A negative result means a is preferred over b
+func (o MaximizeOptions) compareByPolicy(a, b Bookings) int {
+	switch o.TieBreak {
+	case TieBreakFewestBookings:
+		return cmp.Compare(len(a), len(b))
+	case TieBreakEarliestCheckIn:
+		return slices.CompareFunc(a, b, func(x, y *Booking) int {
+			return x.CheckIn.Compare(y.CheckIn)
+		})
+	case TieBreakPreferredProvider:
+		for _, provider := range o.PreferredProviders {
+			if c := cmp.Compare(b.countProvider(provider), a.countProvider(provider)); c != 0 {
+				return c
+			}
+		}
+		return 0
+	default:
+		return 0
+	}
+}
+
+// countProvider counts the bookings coming from the given provider, ignoring case
+func (bb Bookings) countProvider(provider string) int {
+	count := 0
+	for _, b := range bb {
+		if strings.EqualFold(b.Provider(), provider) {
+			count++
+		}
+	}
+
+	return count
+}
+
+// compareRequestIDs compares the sorted request IDs of two selections lexicographically
+func compareRequestIDs(a, b Bookings) int {
+	aIDs, bIDs := a.RequestIDs(), b.RequestIDs()
+	slices.Sort(aIDs)
+	slices.Sort(bIDs)
+
+	return slices.Compare(aIDs, bIDs)
+}
+
+// compareBookings defines the canonical order of bookings used to make the optimizer independent
+// of the input order: check-in first, then request ID and the remaining fields
+func compareBookings(a, b *Booking) int {
+	return cmp.Or(
+		a.CheckIn.Compare(b.CheckIn),
+		strings.Compare(a.RequestID, b.RequestID),
+		a.End().Compare(b.End()),
+		cmp.Compare(a.Nights, b.Nights),
+		cmp.Compare(a.SellingRate, b.SellingRate),
+		cmp.Compare(a.Margin, b.Margin),
+	)
+}
+
+// canonicalOrder returns a copy of the bookings sorted in canonical order
+func canonicalOrder(bookings []*Booking) Bookings {
+	sorted := slices.Clone(Bookings(bookings))
+	slices.SortStableFunc(sorted, compareBookings)
+
+	return sorted
+}
@@ -0,0 +1,134 @@
+package domain_test
+
+import (
+	"context"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/duksonn/stay-for-long/internal/domain"
+)
+
+func TestParseTieBreak(t *testing.T) {
+	tests := []struct {
+		name     string
+		value    string
+		expected domain.TieBreak
+		wantErr  error
+	}{
+		{name: "empty defaults to request ids", value: "", expected: domain.TieBreakRequestIDs},
+		{name: "fewest bookings", value: "fewest_bookings", expected: domain.TieBreakFewestBookings},
+		{name: "earliest check-in", value: "earliest_check_in", expected: domain.TieBreakEarliestCheckIn},
+		{name: "preferred provider", value: "preferred_provider", expected: domain.TieBreakPreferredProvider},
+		{name: "unknown", value: "random", wantErr: domain.ErrInvalidTieBreak},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tieBreak, err := domain.ParseTieBreak(tt.value)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, tieBreak)
+		})
+	}
+}
+
+func TestBooking_Provider(t *testing.T) {
+	assert.Equal(t, "acme", (&domain.Booking{RequestID: "acme_AAAAA"}).Provider())
+	assert.Equal(t, "kayete", (&domain.Booking{RequestID: "kayete_PP_234"}).Provider())
+	assert.Equal(t, "standalone", (&domain.Booking{RequestID: "standalone"}).Provider())
+}
+
+func TestMaximizeProfit_TieBreak(t *testing.T) {
+	baseTime := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	// Every selection below earns a total profit of 200
+	bookings := []*domain.Booking{
+		{RequestID: "zeta_1", CheckIn: baseTime, Nights: 4, SellingRate: 1000, Margin: 20},
+		{RequestID: "acme_1", CheckIn: baseTime.AddDate(0, 0, 1), Nights: 2, SellingRate: 500, Margin: 20},
+		{RequestID: "acme_2", CheckIn: baseTime.AddDate(0, 0, 3), Nights: 2, SellingRate: 500, Margin: 20},
+		{RequestID: "bookata_1", CheckIn: baseTime.AddDate(0, 0, 2), Nights: 4, SellingRate: 1000, Margin: 20},
+	}
+
+	tests := []struct {
+		name        string
+		opts        domain.MaximizeOptions
+		expectedIDs []string
+	}{
+		{
+			name:        "default request ids",
+			opts:        domain.MaximizeOptions{},
+			expectedIDs: []string{"acme_1", "acme_2"},
+		},
+		{
+			name:        "fewest bookings",
+			opts:        domain.MaximizeOptions{TieBreak: domain.TieBreakFewestBookings},
+			expectedIDs: []string{"bookata_1"},
+		},
+		{
+			name:        "earliest check-in",
+			opts:        domain.MaximizeOptions{TieBreak: domain.TieBreakEarliestCheckIn},
+			expectedIDs: []string{"zeta_1"},
+		},
+		{
+			name: "preferred provider",
+			opts: domain.MaximizeOptions{
+				TieBreak:           domain.TieBreakPreferredProvider,
+				PreferredProviders: []string{"Bookata", "zeta"},
+			},
+			expectedIDs: []string{"bookata_1"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.NoError(t, tt.opts.Validate())
+
+			rnd := rand.New(rand.NewSource(42))
+			for i := 0; i < 20; i++ {
+				shuffled := append([]*domain.Booking(nil), bookings...)
+				rnd.Shuffle(len(shuffled), func(i, j int) { shuffled[i], shuffled[j] = shuffled[j], shuffled[i] })
+
+				result, err := domain.MaximizeProfit(context.Background(), shuffled, tt.opts)
+				require.NoError(t, err)
+				require.NotNil(t, result)
+				assert.Equal(t, tt.expectedIDs, result.RequestIDs)
+				assert.Equal(t, float64(200), result.TotalProfit)
+			}
+		})
+	}
+}
+
+func TestParetoFrontier_ShuffledInput(t *testing.T) {
+	baseTime := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	bookings := []*domain.Booking{
+		{RequestID: "b", CheckIn: baseTime, Nights: 2, SellingRate: 500, Margin: 20},
+		{RequestID: "a", CheckIn: baseTime, Nights: 2, SellingRate: 500, Margin: 20},
+		{RequestID: "c", CheckIn: baseTime.AddDate(0, 0, 2), Nights: 5, SellingRate: 300, Margin: 10},
+	}
+
+	rnd := rand.New(rand.NewSource(7))
+	for i := 0; i < 20; i++ {
+		shuffled := append([]*domain.Booking(nil), bookings...)
+		rnd.Shuffle(len(shuffled), func(i, j int) { shuffled[i], shuffled[j] = shuffled[j], shuffled[i] })
+
+		frontier, err := domain.ParetoFrontier(context.Background(), shuffled)
+		require.NoError(t, err)
+		require.Len(t, frontier, 1)
+		assert.Equal(t, []string{"a", "c"}, frontier[0].RequestIDs)
+	}
+}
+
+func TestMaximizeOptions_ValidateTieBreak(t *testing.T) {
+	err := domain.MaximizeOptions{TieBreak: domain.TieBreakPreferredProvider}.Validate()
+	assert.ErrorIs(t, err, domain.ErrInvalidTieBreak)
+
+	err = domain.MaximizeOptions{TieBreak: "random"}.Validate()
+	assert.ErrorIs(t, err, domain.ErrInvalidTieBreak)
+}
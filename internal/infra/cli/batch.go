@@ -0,0 +1,206 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/duksonn/stay-for-long/internal/domain"
+)
+
+// Ways of grouping the bookings of a batch, each group being optimized on its own
+const (
+	groupByFile     = "file"
+	groupByProperty = "property"
+)
+
+// noProperty is how the report names the group of the bookings without property ID, whose key is empty
+const noProperty = "(none)"
+
+var (
+	// ErrInvalidGroupBy is returned when the batch grouping is unknown
+	ErrInvalidGroupBy = errors.New("invalid group by")
+	// ErrMissingProperty is returned for the bookings without property ID of a batch grouped by property
+	// Such a booking competes with the stays of every property, so it cannot be optimized with any of them
+	ErrMissingProperty = errors.New("missing property_id")
+	// ErrBatchIncomplete is returned when some files or groups of a batch failed, after writing the report
+	ErrBatchIncomplete = errors.New("batch incomplete")
+)
+
+// batchGroup holds the bookings optimized together in a batch
+// Groups by property are keyed by property ID, the bookings without one sharing the empty key
+type batchGroup struct {
+	name     string
+	bookings domain.Bookings
+}
+
+// label returns the name the group is reported with
+func (g batchGroup) label() string {
+	if g.name == "" {
+		return noProperty
+	}
+
+	return g.name
+}
+
+// runBatch maximizes the booking files of a directory per file or per property and writes a report of the results
+// Files or groups that fail, including the groups over -max-bookings and, by property, the bookings without
+// property ID, are listed in the report and make the command fail once it is written
+func (c *CLI) runBatch(ctx context.Context, args []string) error {
+	var input inputFlags
+	var flags maximizeFlags
+	var groupBy, format, out string
+	var maxBookings int
+	fs := c.newFlagSet("batch")
+	input.register(fs)
+	flags.register(fs)
+	registerMaxBookings(fs, &maxBookings)
+	fs.StringVar(&groupBy, "group-by", groupByFile, "optimize together the bookings of each file or of each property_id: file or property")
+	fs.StringVar(&format, "report", reportMarkdown, "report format: markdown or html")
+	fs.StringVar(&out, "out", "", "file the report is written to (default stdout)")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		fmt.Fprintln(c.stderr, "batch expects a single directory")
+		fs.Usage()
+		return errUsage
+	}
+	if groupBy != groupByFile && groupBy != groupByProperty {
+		return fmt.Errorf("%w: %q", ErrInvalidGroupBy, groupBy)
+	}
+	render, err := reportRenderer(format)
+	if err != nil {
+		return err
+	}
+	opts, err := flags.options()
+	if err != nil {
+		return err
+	}
+
+	dir := fs.Arg(0)
+	files, err := bookingFiles(dir, input.format)
+	if err != nil {
+		return err
+	}
+	rep := newReport(dir, groupBy, opts)
+	groups := c.readGroups(dir, files, input, groupBy, rep)
+	for _, g := range groups {
+		if groupBy == groupByProperty && g.name == "" {
+			rep.addFailure(g.label(), fmt.Errorf("%w: %d bookings compete with every property", ErrMissingProperty, len(g.bookings)))
+			continue
+		}
+		if err := checkBookings(len(g.bookings), maxBookings, opts.Mode); err != nil {
+			rep.addFailure(g.label(), err)
+			continue
+		}
+		result, err := c.maximize(ctx, g.bookings, &flags, opts)
+		if err != nil {
+			rep.addFailure(g.label(), err)
+			continue
+		}
+		rep.addGroup(g.label(), g.bookings, result)
+	}
+
+	if err := c.writeReport(out, func(w io.Writer) error { return render(w, rep) }); err != nil {
+		return err
+	}
+	if len(rep.Failures) > 0 {
+		return fmt.Errorf("%w: %d failed inputs, see the report", ErrBatchIncomplete, len(rep.Failures))
+	}
+
+	return nil
+}
+
+// bookingFiles returns, in lexical order, the files under dir whose extension is a known input format,
+// restricted to format when it is set. Hidden files and directories are skipped
+func bookingFiles(dir, format string) ([]string, error) {
+	if format != "" {
+		if _, ok := mediaTypes[format]; !ok {
+			return nil, fmt.Errorf("%w: %q", ErrInvalidFormat, format)
+		}
+	}
+
+	var files []string
+	err := filepath.WalkDir(dir, func(path string, entry os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if path != dir && strings.HasPrefix(entry.Name(), ".") {
+			if entry.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !entry.Type().IsRegular() {
+			return nil
+		}
+		if ext, ok := extensionFormats[strings.ToLower(filepath.Ext(path))]; ok && (format == "" || ext == format) {
+			files = append(files, path)
+		}
+		return nil
+	})
+
+	return files, err
+}
+
+// readGroups decodes the files and splits their bookings into the groups optimized together
+// Files that cannot be decoded are recorded as failures of rep. Groups by property are sorted by name, the group
+// of the bookings without property coming last
+func (c *CLI) readGroups(dir string, files []string, input inputFlags, groupBy string, rep *report) []batchGroup {
+	var groups []batchGroup
+	byProperty := make(map[string]domain.Bookings)
+	for _, file := range files {
+		name, err := filepath.Rel(dir, file)
+		if err != nil {
+			name = file
+		}
+		bookings, err := c.readFile(file, input)
+		if err != nil {
+			rep.addFailure(name, err)
+			continue
+		}
+		if groupBy == groupByFile {
+			groups = append(groups, batchGroup{name: name, bookings: bookings})
+			continue
+		}
+		for _, b := range bookings {
+			byProperty[b.PropertyID] = append(byProperty[b.PropertyID], b)
+		}
+	}
+
+	for _, property := range slices.Sorted(maps.Keys(byProperty)) {
+		if property != "" {
+			groups = append(groups, batchGroup{name: property, bookings: byProperty[property]})
+		}
+	}
+	if unassigned, ok := byProperty[""]; ok {
+		groups = append(groups, batchGroup{bookings: unassigned})
+	}
+
+	return groups
+}
+
+// writeReport writes the report with write into the out file, or to stdout when out is empty
+func (c *CLI) writeReport(out string, write func(io.Writer) error) error {
+	if out == "" {
+		return write(c.stdout)
+	}
+
+	f, err := os.Create(out)
+	if err != nil {
+		return err
+	}
+	if err := write(f); err != nil {
+		f.Close()
+		return err
+	}
+
+	return f.Close()
+}
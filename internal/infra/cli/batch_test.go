@@ -0,0 +1,157 @@
+package cli_test
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/duksonn/stay-for-long/internal/application"
+	"github.com/duksonn/stay-for-long/internal/infra/cli"
+)
+
+func TestCLI_RunBatch(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"partner_a.csv": "request_id,property_id,check_in,nights,selling_rate,margin\n" +
+			"bookata_XY123,madrid,2020-01-01,5,200,20\n" +
+			"kayete_PP234,madrid,2020-01-04,4,156,5\n" +
+			"acme_AAAAA,bcn,2020-01-10,4,160,30\n",
+		"nested/partner_b.ndjson": `{"request_id":"acme_BBBBB","property_id":"bcn","check_in":"2020-01-16","nights":2,"selling_rate":50,"margin":10}` + "\n",
+		"notes.txt":               "not a booking file",
+		".hidden/partner_c.json":  "[]",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o700))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	}
+
+	tests := []struct {
+		name           string
+		args           []string
+		broken         bool
+		expectedCode   int
+		expectedReport []string
+	}{
+		{
+			name:         "markdown report per file",
+			args:         []string{"batch", dir},
+			expectedCode: 0,
+			expectedReport: []string{
+				"- Grouped by: file\n",
+				"| 2 | 4 | 3 | 75% | 93 | 93 | 410 | 11 | yes | 0 |\n",
+				"| partner_a.csv | 3 | 2 | 66.7% | 88 | 88 | 360 | 9 | yes | 0 |\n",
+				"| nested/partner_b.ndjson | 1 | 1 | 100% | 5 | 5 | 50 | 2 | yes | 0 |\n",
+				"| acme | 2 | 2 | 100% |\n| bookata | 1 | 1 | 100% |\n| kayete | 1 | 0 | 0% |\n",
+			},
+		},
+		{
+			name:         "markdown report per property",
+			args:         []string{"batch", "-group-by", "property", dir},
+			expectedCode: 0,
+			expectedReport: []string{
+				"| Property | Bookings |",
+				"| 2 | 4 | 3 | 75% | 93 | 93 | 410 | 11 | yes | 2 |\n",
+				"| bcn | 2 | 2 | 100% | 53 | 53 | 210 | 6 | yes | 2 |\n| madrid | 2 | 1 | 50% | 40 | 40 | 200 | 5 | yes | 0 |\n",
+				"| acme | 2 | 2 | 100% |\n",
+			},
+		},
+		{
+			name:         "html report restricted to a format",
+			args:         []string{"batch", "-report", "html", "-format", "csv", "-objective", "revenue", dir},
+			expectedCode: 0,
+			expectedReport: []string{
+				"<li>Objective: revenue, exact mode</li>",
+				"<tr><td>partner_a.csv</td>",
+			},
+		},
+		{
+			name:         "broken file is reported",
+			args:         []string{"batch", dir},
+			broken:       true,
+			expectedCode: 1,
+			expectedReport: []string{
+				"## Failures\n",
+				"| zz_broken.json | invalid request json |\n",
+			},
+		},
+		{
+			name:         "group over max bookings is reported",
+			args:         []string{"batch", "-group-by", "property", "-max-bookings", "1", dir},
+			expectedCode: 1,
+			expectedReport: []string{
+				"## Failures\n",
+				"| bcn | too many bookings: got 2, limit is 1, use -mode heuristic or raise -max-bookings |\n",
+				"| madrid | too many bookings: got 2, limit is 1, use -mode heuristic or raise -max-bookings |\n",
+			},
+		},
+		{
+			name:         "heuristic mode is not bounded by max bookings",
+			args:         []string{"batch", "-group-by", "property", "-max-bookings", "1", "-mode", "heuristic", "-budget", "10ms", dir},
+			expectedCode: 0,
+			expectedReport: []string{
+				"| bcn | 2 | 2 | 100% | 53 | 53 | 210 | 6 |",
+			},
+		},
+		{
+			name:         "invalid group by",
+			args:         []string{"batch", "-group-by", "provider", dir},
+			expectedCode: 1,
+		},
+		{
+			name:         "missing directory",
+			args:         []string{"batch"},
+			expectedCode: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			broken := filepath.Join(dir, "zz_broken.json")
+			if tt.broken {
+				require.NoError(t, os.WriteFile(broken, []byte("{"), 0o600))
+				defer os.Remove(broken)
+			}
+
+			var stdout, stderr bytes.Buffer
+			c, err := cli.NewCLI(application.NewStatsService(), strings.NewReader(""), &stdout, &stderr)
+			require.NoError(t, err)
+
+			code := c.Run(context.Background(), tt.args)
+
+			assert.Equal(t, tt.expectedCode, code, stderr.String())
+			for _, expected := range tt.expectedReport {
+				assert.Contains(t, stdout.String(), expected)
+			}
+			assert.NotContains(t, stdout.String(), "partner_c")
+		})
+	}
+}
+
+func TestCLI_RunBatch_OutFile(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "bookings.json"),
+		[]byte(`[{"request_id":"acme_AAAAA","property_id":"unassigned","check_in":"2020-01-10","nights":4,"selling_rate":160,"margin":30},`+
+			`{"request_id":"acme_BBBBB","check_in":"2020-01-10","nights":4,"selling_rate":160,"margin":30}]`), 0o600))
+	out := filepath.Join(t.TempDir(), "report.md")
+
+	var stdout, stderr bytes.Buffer
+	c, err := cli.NewCLI(application.NewStatsService(), strings.NewReader(""), &stdout, &stderr)
+	require.NoError(t, err)
+
+	code := c.Run(context.Background(), []string{"batch", "-group-by", "property", "-out", out, dir})
+
+	// the booking without property fails on its own, apart from the property named unassigned
+	require.Equal(t, 1, code, stderr.String())
+	assert.Empty(t, stdout.String())
+	report, err := os.ReadFile(out)
+	require.NoError(t, err)
+	assert.Contains(t, string(report), "| unassigned | 1 | 1 | 100% | 48 | 48 | 160 | 4 | yes | 0 |\n")
+	assert.Contains(t, string(report), "| (none) | missing property_id: 1 bookings compete with every property |\n")
+}
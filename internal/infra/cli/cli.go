@@ -0,0 +1,487 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/duksonn/stay-for-long/internal/domain"
+	"github.com/duksonn/stay-for-long/internal/infra/dto"
+	"github.com/duksonn/stay-for-long/internal/infra/limits"
+	"github.com/duksonn/stay-for-long/internal/ports"
+)
+
+// Exit codes returned by Run
+const (
+	exitOK    = 0
+	exitError = 1
+	exitUsage = 2
+)
+
+// Defaults of the flags bounding an optimization, matching those of the server
+const (
+	defaultMaxBookings = 20
+	defaultTimeout     = time.Minute
+)
+
+// Output formats of the results
+const (
+	outputTable = "table"
+	outputJSON  = "json"
+)
+
+var (
+	// ErrNilStatsService is returned when the stats service is nil
+	ErrNilStatsService = errors.New("stats service cannot be nil")
+	// ErrUnknownCommand is returned when the subcommand does not exist
+	ErrUnknownCommand = errors.New("unknown command")
+	// ErrInvalidFormat is returned when the input format is unknown or cannot be guessed
+	ErrInvalidFormat = errors.New("invalid input format")
+	// ErrInvalidOutput is returned when the output format is unknown
+	ErrInvalidOutput = errors.New("invalid output format")
+	// ErrInvalidCSVHeader is returned when a CSV header alias is not written as Alias=field
+	ErrInvalidCSVHeader = errors.New("invalid csv header alias")
+	// ErrTooManyBookings is returned when an exact optimization holds more bookings than -max-bookings
+	ErrTooManyBookings = errors.New("too many bookings")
+)
+
+// mediaTypes maps the input formats to the media type the bookings are decoded as
+var mediaTypes = map[string]string{
+	"json":   dto.ContentTypeJSON,
+	"csv":    dto.ContentTypeCSV,
+	"ndjson": dto.ContentTypeNDJSON,
+	"ics":    dto.ContentTypeCalendar,
+}
+
+// extensionFormats maps the file extensions to the input format guessed for them
+var extensionFormats = map[string]string{
+	".json":   "json",
+	".csv":    "csv",
+	".ndjson": "ndjson",
+	".jsonl":  "ndjson",
+	".ics":    "ics",
+}
+
+// CLI runs the stats and maximize commands over booking files, without starting the HTTP server
+type CLI struct {
+	statsService ports.StatsService
+	stdin        io.Reader
+	stdout       io.Writer
+	stderr       io.Writer
+}
+
+// NewCLI creates a new instance of CLI reading from stdin when no file is given
+// Returns ErrNilStatsService if the stats service is nil
+func NewCLI(statsService ports.StatsService, stdin io.Reader, stdout, stderr io.Writer) (*CLI, error) {
+	if statsService == nil {
+		return nil, ErrNilStatsService
+	}
+
+	return &CLI{statsService: statsService, stdin: stdin, stdout: stdout, stderr: stderr}, nil
+}
+
+// inputFlags holds the flags selecting how the booking files are read
+type inputFlags struct {
+	format     string
+	csvHeaders csvHeaderFlag
+}
+
+// register adds the input flags to fs
+func (f *inputFlags) register(fs *flag.FlagSet) {
+	f.csvHeaders = make(csvHeaderFlag)
+	fs.StringVar(&f.format, "format", "", "input format: json, csv, ndjson or ics (default guessed from the file extension, json for stdin)")
+	fs.Var(f.csvHeaders, "csv-header", "CSV column alias as Alias=field, can be repeated")
+}
+
+// maximizeFlags holds the flags configuring the optimization, mirroring the query parameters of /maximize
+type maximizeFlags struct {
+	objective string
+	tieBreak  string
+	providers string
+	mode      string
+	opts      domain.MaximizeOptions
+	timeout   time.Duration
+}
+
+// register adds the optimization flags to fs
+func (f *maximizeFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&f.objective, "objective", "", "objective to maximize: profit, revenue, occupancy or weighted (default profit)")
+	fs.Float64Var(&f.opts.Weights.Profit, "profit-weight", 0, "profit weight of the weighted objective")
+	fs.Float64Var(&f.opts.Weights.Revenue, "revenue-weight", 0, "revenue weight of the weighted objective")
+	fs.Float64Var(&f.opts.Weights.Occupancy, "occupancy-weight", 0, "occupancy weight of the weighted objective")
+	fs.StringVar(&f.tieBreak, "tie-break", "", "tie-break policy: request_ids, fewest_bookings, earliest_check_in or preferred_provider (default request_ids)")
+	fs.StringVar(&f.providers, "preferred-provider", "", "comma separated providers preferred by the preferred_provider tie-break, in priority order")
+	fs.BoolVar(&f.opts.CounterOffers, "counter-offers", false, "compute the counter-offer rate of the rejected bookings")
+	fs.StringVar(&f.mode, "mode", "", "solver mode: exact or heuristic (default exact)")
+	fs.DurationVar(&f.opts.Budget, "budget", 0, "time budget of the heuristic mode, e.g. 500ms")
+	fs.DurationVar(&f.timeout, "timeout", defaultTimeout, "abort each optimization after this long, 0 means no limit")
+}
+
+// registerMaxBookings adds the -max-bookings flag of the commands running an optimization
+func registerMaxBookings(fs *flag.FlagSet, maxBookings *int) {
+	fs.IntVar(maxBookings, "max-bookings", defaultMaxBookings, "maximum bookings of an exact optimization, 0 disables the limit")
+}
+
+// checkBookings fails with ErrTooManyBookings when an exact optimization would hold more than maxBookings bookings
+// The heuristic mode is not bounded by the number of bookings, only by its time budget
+func checkBookings(count, maxBookings int, mode domain.Mode) error {
+	if mode == domain.ModeExact && maxBookings > 0 && count > maxBookings {
+		return fmt.Errorf("%w: got %d, limit is %d, use -mode heuristic or raise -max-bookings", ErrTooManyBookings, count, maxBookings)
+	}
+
+	return nil
+}
+
+// options converts the parsed flags to validated domain.MaximizeOptions
+func (f *maximizeFlags) options() (domain.MaximizeOptions, error) {
+	opts := f.opts
+	var err error
+	if opts.Objective, err = domain.ParseObjective(f.objective); err != nil {
+		return domain.MaximizeOptions{}, err
+	}
+	if opts.TieBreak, err = domain.ParseTieBreak(f.tieBreak); err != nil {
+		return domain.MaximizeOptions{}, err
+	}
+	if opts.Mode, err = domain.ParseMode(f.mode); err != nil {
+		return domain.MaximizeOptions{}, err
+	}
+	for _, provider := range strings.Split(f.providers, ",") {
+		if provider = strings.TrimSpace(provider); provider != "" {
+			opts.PreferredProviders = append(opts.PreferredProviders, provider)
+		}
+	}
+	if err := opts.Validate(); err != nil {
+		return domain.MaximizeOptions{}, err
+	}
+
+	return opts, nil
+}
+
+// maximize runs the optimization over bookings, bounded by the -timeout flag
+func (c *CLI) maximize(ctx context.Context, bookings domain.Bookings, flags *maximizeFlags, opts domain.MaximizeOptions) (*domain.MaximizeResult, error) {
+	if flags.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, flags.timeout)
+		defer cancel()
+	}
+
+	return c.statsService.MaximizeProfit(ctx, bookings, opts)
+}
+
+// csvHeaderFlag collects the repeated Alias=field CSV header aliases
+type csvHeaderFlag map[string]string
+
+// String returns the aliases as a comma separated list
+func (f csvHeaderFlag) String() string {
+	pairs := make([]string, 0, len(f))
+	for alias, field := range f {
+		pairs = append(pairs, alias+"="+field)
+	}
+
+	return strings.Join(pairs, ",")
+}
+
+// Set adds an Alias=field alias
+func (f csvHeaderFlag) Set(value string) error {
+	alias, field, ok := strings.Cut(value, "=")
+	if alias, field = strings.TrimSpace(alias), strings.TrimSpace(field); !ok || alias == "" || field == "" {
+		return fmt.Errorf("%w: %q", ErrInvalidCSVHeader, value)
+	}
+	f[alias] = field
+
+	return nil
+}
+
+// Run executes the command in args, e.g. ["maximize", "-objective", "revenue", "bookings.csv"],
+// and returns the process exit code: 0 on success, 2 on usage errors and 1 on any other error
+func (c *CLI) Run(ctx context.Context, args []string) int {
+	if len(args) == 0 {
+		c.usage()
+		return exitUsage
+	}
+
+	var err error
+	switch args[0] {
+	case "stats":
+		err = c.runStats(ctx, args[1:])
+	case "maximize":
+		err = c.runMaximize(ctx, args[1:])
+	case "batch":
+		err = c.runBatch(ctx, args[1:])
+	case "help", "-h", "-help", "--help":
+		c.usage()
+		return exitOK
+	default:
+		fmt.Fprintf(c.stderr, "stayforlong: %v: %q\n", ErrUnknownCommand, args[0])
+		c.usage()
+		return exitUsage
+	}
+
+	switch {
+	case err == nil, errors.Is(err, flag.ErrHelp):
+		return exitOK
+	case errors.Is(err, errUsage):
+		// The flag set already reported the error together with the command usage
+		return exitUsage
+	default:
+		fmt.Fprintf(c.stderr, "stayforlong: %v\n", err)
+		return exitError
+	}
+}
+
+// errUsage marks the flag parsing errors
+var errUsage = errors.New("invalid usage")
+
+// usage prints the available commands
+func (c *CLI) usage() {
+	fmt.Fprint(c.stderr, `Usage: stayforlong stats|maximize [flags] [file ...]
+       stayforlong batch [flags] <directory>
+
+Commands:
+  stats     Print the average, minimum and maximum profit per night
+  maximize  Print the non-overlapping bookings that maximize the objective
+  batch     Maximize every booking file of a directory, per file or per property, and write a report
+
+Bookings are read from the files, or from stdin when none is given or the file is "-".
+Run "stayforlong <command> -h" for the flags of a command.
+`)
+}
+
+// newFlagSet returns an empty flag set for a command, reporting its errors to stderr
+func (c *CLI) newFlagSet(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(c.stderr)
+
+	return fs
+}
+
+// registerOutput adds the -output flag of the commands printing a result
+func registerOutput(fs *flag.FlagSet, output *string) {
+	fs.StringVar(output, "output", outputTable, "output format: table or json")
+}
+
+// parseFlags parses args into fs, marking the errors already reported by the flag set with errUsage
+func parseFlags(fs *flag.FlagSet, args []string) error {
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return err
+		}
+		return fmt.Errorf("%w: %w", errUsage, err)
+	}
+
+	return nil
+}
+
+// checkOutput fails with ErrInvalidOutput when the output format is unknown
+func checkOutput(output string) error {
+	if output != outputTable && output != outputJSON {
+		return fmt.Errorf("%w: %q", ErrInvalidOutput, output)
+	}
+
+	return nil
+}
+
+// runStats prints the stats of the bookings
+func (c *CLI) runStats(ctx context.Context, args []string) error {
+	var input inputFlags
+	var output string
+	fs := c.newFlagSet("stats")
+	input.register(fs)
+	registerOutput(fs, &output)
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if err := checkOutput(output); err != nil {
+		return err
+	}
+
+	bookings, err := c.readBookings(fs.Args(), input)
+	if err != nil {
+		return err
+	}
+	stats, err := c.statsService.CalculateStats(ctx, bookings)
+	if err != nil {
+		return err
+	}
+
+	if output == outputJSON {
+		return json.NewEncoder(c.stdout).Encode(dto.NewStatsResult(stats))
+	}
+	return writeTable(c.stdout, [][]string{
+		{"AVG NIGHT", "MIN NIGHT", "MAX NIGHT"},
+		{formatFloat(stats.AvgNight), formatFloat(stats.MinNight), formatFloat(stats.MaxNight)},
+	})
+}
+
+// runMaximize prints the selection that maximizes the objective, followed by every booking and its status
+func (c *CLI) runMaximize(ctx context.Context, args []string) error {
+	var input inputFlags
+	var flags maximizeFlags
+	var output string
+	var maxBookings int
+	fs := c.newFlagSet("maximize")
+	input.register(fs)
+	flags.register(fs)
+	registerMaxBookings(fs, &maxBookings)
+	registerOutput(fs, &output)
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if err := checkOutput(output); err != nil {
+		return err
+	}
+	opts, err := flags.options()
+	if err != nil {
+		return err
+	}
+
+	bookings, err := c.readBookings(fs.Args(), input)
+	if err != nil {
+		return err
+	}
+	if err := checkBookings(len(bookings), maxBookings, opts.Mode); err != nil {
+		return err
+	}
+	result, err := c.maximize(ctx, bookings, &flags, opts)
+	if err != nil {
+		return err
+	}
+
+	if output == outputJSON {
+		return json.NewEncoder(c.stdout).Encode(dto.NewMaximizeResult(result))
+	}
+	return writeMaximizeTable(c.stdout, result)
+}
+
+// readBookings decodes the bookings of every file in order, reading stdin for "-" or when there are no files
+func (c *CLI) readBookings(files []string, input inputFlags) (domain.Bookings, error) {
+	if len(files) == 0 {
+		files = []string{"-"}
+	}
+
+	bookings := make(domain.Bookings, 0)
+	for _, file := range files {
+		decoded, err := c.readFile(file, input)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", file, err)
+		}
+		bookings = append(bookings, decoded...)
+	}
+
+	return bookings, nil
+}
+
+// readFile decodes the bookings of a single file, in the format of the -format flag or guessed from its extension
+func (c *CLI) readFile(file string, input inputFlags) ([]*domain.Booking, error) {
+	format := input.format
+	if format == "" {
+		format = "json"
+		if file != "-" {
+			var ok bool
+			if format, ok = extensionFormats[strings.ToLower(filepath.Ext(file))]; !ok {
+				return nil, fmt.Errorf("%w: cannot guess it from the extension, use -format", ErrInvalidFormat)
+			}
+		}
+	}
+	mediaType, ok := mediaTypes[format]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidFormat, format)
+	}
+
+	body := c.stdin
+	if file != "-" {
+		f, err := os.Open(file)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		body = f
+	}
+
+	columns, err := dto.NewCSVColumns(input.csvHeaders)
+	if err != nil {
+		return nil, err
+	}
+
+	return dto.DecodeBookings(body, mediaType, limits.Limits{}, columns)
+}
+
+// writeMaximizeTable prints the summary of the selection and a row per booking, accepted ones first
+func writeMaximizeTable(w io.Writer, result *domain.MaximizeResult) error {
+	summary := [][]string{
+		{"OBJECTIVE", "SCORE", "TOTAL PROFIT", "TOTAL REVENUE", "TOTAL NIGHTS", "OPTIMAL", "GAP"},
+		{
+			string(result.Objective), formatFloat(result.Score), formatFloat(result.TotalProfit),
+			formatFloat(result.TotalRevenue), strconv.Itoa(result.TotalNights),
+			strconv.FormatBool(result.Optimal), formatFloat(result.Gap),
+		},
+	}
+	if err := writeTable(w, summary); err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintln(w); err != nil {
+		return err
+	}
+
+	rows := [][]string{{"REQUEST ID", "STATUS", "CHECK IN", "CHECK OUT", "NIGHTS", "SELLING RATE", "MARGIN", "PROFIT", "COUNTER OFFER"}}
+	for _, b := range result.Selected {
+		rows = append(rows, bookingRow(b, "accepted", ""))
+	}
+	for _, r := range result.Rejected {
+		counterOffer := ""
+		if r.CounterOfferRate > 0 {
+			counterOffer = formatFloat(r.CounterOfferRate)
+		}
+		rows = append(rows, bookingRow(r.Booking, "rejected", counterOffer))
+	}
+
+	return writeTable(w, rows)
+}
+
+// bookingRow returns the table cells describing a booking
+func bookingRow(b *domain.Booking, status, counterOffer string) []string {
+	return []string{
+		b.RequestID,
+		status,
+		b.CheckIn.Format(time.DateOnly),
+		b.End().Format(time.DateOnly),
+		strconv.Itoa(b.Nights),
+		formatFloat(b.SellingRate),
+		formatFloat(b.Margin),
+		formatFloat(domain.Bookings{b}.TotalProfit()),
+		counterOffer,
+	}
+}
+
+// writeTable prints rows as columns aligned with spaces
+func writeTable(w io.Writer, rows [][]string) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for _, row := range rows {
+		if _, err := fmt.Fprintln(tw, strings.Join(row, "\t")); err != nil {
+			return err
+		}
+	}
+
+	return tw.Flush()
+}
+
+// formatFloat formats a number with the shortest exact representation
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
+
+// roundTo rounds f to the given number of decimals
+func roundTo(f float64, decimals int) float64 {
+	p := math.Pow10(decimals)
+	return math.Round(f*p) / p
+}
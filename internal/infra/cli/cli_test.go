@@ -0,0 +1,199 @@
+package cli_test
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"github.com/duksonn/stay-for-long/internal/domain"
+	"github.com/duksonn/stay-for-long/internal/infra/cli"
+	"github.com/duksonn/stay-for-long/internal/mocks"
+)
+
+func TestNewCLI(t *testing.T) {
+	c, err := cli.NewCLI(nil, nil, nil, nil)
+	assert.ErrorIs(t, err, cli.ErrNilStatsService)
+	assert.Nil(t, c)
+}
+
+func TestCLI_Run(t *testing.T) {
+	checkIn := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	bookings := domain.Bookings{
+		{RequestID: "bookata_XY123", CheckIn: checkIn, CheckOut: checkIn.AddDate(0, 0, 5), Nights: 5, SellingRate: 200, Margin: 20},
+		{RequestID: "acme_AAAAA", CheckIn: checkIn.AddDate(0, 0, 9), CheckOut: checkIn.AddDate(0, 0, 13), Nights: 4, SellingRate: 160, Margin: 30},
+	}
+	rejected := &domain.Booking{RequestID: "kayete_PP234", CheckIn: checkIn.AddDate(0, 0, 3), CheckOut: checkIn.AddDate(0, 0, 7), Nights: 4, SellingRate: 150, Margin: 6}
+
+	dir := t.TempDir()
+	csvFile := filepath.Join(dir, "bookings.csv")
+	require.NoError(t, os.WriteFile(csvFile, []byte("Booking ID,check_in,nights,selling_rate,margin\n"+
+		"bookata_XY123,2020-01-01,5,200,20\nacme_AAAAA,2020-01-10,4,160,30\n"), 0o600))
+	txtFile := filepath.Join(dir, "bookings.txt")
+	require.NoError(t, os.WriteFile(txtFile, []byte(`{"request_id":"bookata_XY123","check_in":"2020-01-01","nights":5,"selling_rate":200,"margin":20}`+"\n"), 0o600))
+
+	tests := []struct {
+		name           string
+		args           []string
+		stdin          string
+		mock           func(*mocks.MockStatsService)
+		expectedCode   int
+		expectedStdout string
+		expectedStderr string
+	}{
+		{
+			name:  "stats from stdin as table",
+			args:  []string{"stats"},
+			stdin: `[{"request_id":"bookata_XY123","check_in":"2020-01-01","nights":5,"selling_rate":200,"margin":20}]`,
+			mock: func(m *mocks.MockStatsService) {
+				m.EXPECT().
+					CalculateStats(gomock.Any(), domain.Bookings{bookings[0]}).
+					Return(&domain.StatsResult{AvgNight: 8, MinNight: 8, MaxNight: 8}, nil)
+			},
+			expectedCode:   0,
+			expectedStdout: "AVG NIGHT  MIN NIGHT  MAX NIGHT\n8          8          8\n",
+		},
+		{
+			name: "stats from csv file with header alias as json",
+			args: []string{"stats", "-output", "json", "-csv-header", "Booking ID=request_id", csvFile},
+			mock: func(m *mocks.MockStatsService) {
+				m.EXPECT().
+					CalculateStats(gomock.Any(), bookings).
+					Return(&domain.StatsResult{AvgNight: 10, MinNight: 8, MaxNight: 12}, nil)
+			},
+			expectedCode:   0,
+			expectedStdout: `{"avg_night":10,"min_night":8,"max_night":12}` + "\n",
+		},
+		{
+			name: "maximize ndjson from stdin as table",
+			args: []string{"maximize", "-format", "ndjson", "-objective", "revenue", "-counter-offers", "-"},
+			stdin: `{"request_id":"bookata_XY123","check_in":"2020-01-01","nights":5,"selling_rate":200,"margin":20}` + "\n" +
+				`{"request_id":"acme_AAAAA","check_in":"2020-01-10","nights":4,"selling_rate":160,"margin":30}` + "\n",
+			mock: func(m *mocks.MockStatsService) {
+				m.EXPECT().
+					MaximizeProfit(gomock.Any(), bookings, domain.MaximizeOptions{
+						Objective: domain.ObjectiveRevenue, TieBreak: domain.TieBreakRequestIDs, Mode: domain.ModeExact, CounterOffers: true,
+					}).
+					Return(&domain.MaximizeResult{
+						RequestIDs: []string{"bookata_XY123", "acme_AAAAA"}, Objective: domain.ObjectiveRevenue,
+						Score: 360, TotalProfit: 88, TotalRevenue: 360, TotalNights: 9, Optimal: true, UpperBound: 360,
+						Selected: bookings,
+						Rejected: []*domain.RejectedBooking{{Booking: rejected, CounterOfferRate: 666.59}},
+					}, nil)
+			},
+			expectedCode: 0,
+			expectedStdout: "OBJECTIVE  SCORE  TOTAL PROFIT  TOTAL REVENUE  TOTAL NIGHTS  OPTIMAL  GAP\n" +
+				"revenue    360    88            360            9             true     0\n" +
+				"\n" +
+				"REQUEST ID     STATUS    CHECK IN    CHECK OUT   NIGHTS  SELLING RATE  MARGIN  PROFIT  COUNTER OFFER\n" +
+				"bookata_XY123  accepted  2020-01-01  2020-01-06  5       200           20      40      \n" +
+				"acme_AAAAA     accepted  2020-01-10  2020-01-14  4       160           30      48      \n" +
+				"kayete_PP234   rejected  2020-01-04  2020-01-08  4       150           6       9       666.59\n",
+		},
+		{
+			name:  "maximize as json",
+			args:  []string{"maximize", "-output", "json", "-mode", "heuristic", "-budget", "50ms"},
+			stdin: `[]`,
+			mock: func(m *mocks.MockStatsService) {
+				m.EXPECT().
+					MaximizeProfit(gomock.Any(), domain.Bookings{}, domain.MaximizeOptions{
+						Objective: domain.ObjectiveProfit, TieBreak: domain.TieBreakRequestIDs, Mode: domain.ModeHeuristic, Budget: 50 * time.Millisecond,
+					}).
+					Return(&domain.MaximizeResult{RequestIDs: []string{}, Objective: domain.ObjectiveProfit, Optimal: true}, nil)
+			},
+			expectedCode: 0,
+			expectedStdout: `{"request_ids":[],"objective":"profit","score":0,"total_profit":0,"total_revenue":0,"total_nights":0,` +
+				`"avg_night":0,"min_night":0,"max_night":0,"optimal":true,"upper_bound":0,"gap":0,"rejected":[]}` + "\n",
+		},
+		{
+			name: "maximize over max bookings",
+			args: []string{"maximize", "-format", "ndjson", "-max-bookings", "1"},
+			stdin: `{"request_id":"bookata_XY123","check_in":"2020-01-01","nights":5,"selling_rate":200,"margin":20}` + "\n" +
+				`{"request_id":"acme_AAAAA","check_in":"2020-01-10","nights":4,"selling_rate":160,"margin":30}` + "\n",
+			expectedCode:   1,
+			expectedStderr: "stayforlong: too many bookings: got 2, limit is 1, use -mode heuristic or raise -max-bookings\n",
+		},
+		{
+			name:  "maximize over the exact search bound without max bookings",
+			args:  []string{"maximize", "-max-bookings", "0"},
+			stdin: `[]`,
+			mock: func(m *mocks.MockStatsService) {
+				m.EXPECT().
+					MaximizeProfit(gomock.Any(), domain.Bookings{}, gomock.Any()).
+					Return(nil, domain.ErrExactSearchTooLarge)
+			},
+			expectedCode:   1,
+			expectedStderr: "stayforlong: too many bookings for the exact search\n",
+		},
+		{
+			name:           "maximize with invalid objective",
+			args:           []string{"maximize", "-objective", "fun"},
+			expectedCode:   1,
+			expectedStderr: "stayforlong: invalid optimization objective: \"fun\"\n",
+		},
+		{
+			name:           "file with unknown extension",
+			args:           []string{"stats", txtFile},
+			expectedCode:   1,
+			expectedStderr: "stayforlong: " + txtFile + ": invalid input format: cannot guess it from the extension, use -format\n",
+		},
+		{
+			name:           "invalid csv line",
+			args:           []string{"stats", "-format", "csv"},
+			stdin:          "request_id,check_in,nights\nbookata_XY123,2020-01-01,five\n",
+			expectedCode:   1,
+			expectedStderr: "stayforlong: -: line 2: invalid number format: column \"nights\"\n",
+		},
+		{
+			name:           "invalid output",
+			args:           []string{"stats", "-output", "xml"},
+			expectedCode:   1,
+			expectedStderr: "stayforlong: invalid output format: \"xml\"\n",
+		},
+		{
+			name:         "unknown flag",
+			args:         []string{"stats", "-verbose"},
+			expectedCode: 2,
+		},
+		{
+			name:         "unknown command",
+			args:         []string{"optimize"},
+			expectedCode: 2,
+		},
+		{
+			name:         "no command",
+			expectedCode: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockStatsService := mocks.NewMockStatsService(ctrl)
+			if tt.mock != nil {
+				tt.mock(mockStatsService)
+			}
+
+			var stdout, stderr bytes.Buffer
+			c, err := cli.NewCLI(mockStatsService, strings.NewReader(tt.stdin), &stdout, &stderr)
+			require.NoError(t, err)
+
+			code := c.Run(context.Background(), tt.args)
+
+			assert.Equal(t, tt.expectedCode, code)
+			assert.Equal(t, tt.expectedStdout, stdout.String())
+			if tt.expectedStderr != "" {
+				assert.Equal(t, tt.expectedStderr, stderr.String())
+			}
+		})
+	}
+}
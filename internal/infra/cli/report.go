@@ -0,0 +1,205 @@
+package cli
+
+import (
+	_ "embed" // Report templates
+	"errors"
+	"fmt"
+	htmltemplate "html/template"
+	"io"
+	"maps"
+	"slices"
+	"strings"
+	"text/template"
+
+	"github.com/duksonn/stay-for-long/internal/domain"
+)
+
+// Formats of the batch report
+const (
+	reportMarkdown = "markdown"
+	reportHTML     = "html"
+)
+
+// ErrInvalidReport is returned when the report format is unknown
+var ErrInvalidReport = errors.New("invalid report format")
+
+var (
+	//go:embed templates/report.md.tmpl
+	markdownReportTemplate string
+	//go:embed templates/report.html.tmpl
+	htmlReportTemplate string
+)
+
+// reportFuncs are the helpers available to the report templates
+var reportFuncs = map[string]any{
+	"number":  formatFloat,
+	"percent": func(f float64) string { return formatFloat(roundTo(f*100, 1)) + "%" },
+	"cell":    func(s string) string { return strings.ReplaceAll(s, "|", `\|`) },
+}
+
+var (
+	markdownReport = template.Must(template.New("report").Funcs(reportFuncs).Parse(markdownReportTemplate))
+	htmlReport     = htmltemplate.Must(htmltemplate.New("report").Funcs(reportFuncs).Parse(htmlReportTemplate))
+)
+
+// report holds the consolidated results of a batch
+type report struct {
+	Directory string
+	GroupBy   string
+	Objective domain.Objective
+	Mode      domain.Mode
+	Totals    reportRow
+	Groups    []reportRow
+	Failures  []reportFailure
+
+	providers map[string]*providerRow
+}
+
+// reportRow summarizes the selection of a group, or of the whole batch for the totals
+// IdleNights counts the nights each property stays empty between two accepted bookings. Scores, totals and
+// idle nights of the batch are the sums over its groups, and it is optimal when all of them are
+type reportRow struct {
+	Name         string
+	Bookings     int
+	Accepted     int
+	Score        float64
+	TotalProfit  float64
+	TotalRevenue float64
+	TotalNights  int
+	Optimal      bool
+	IdleNights   int
+}
+
+// providerRow counts the bookings of a provider across the batch
+type providerRow struct {
+	Provider string
+	Bookings int
+	Accepted int
+}
+
+// reportFailure records a file that could not be decoded or a group that could not be optimized
+type reportFailure struct {
+	Input string
+	Error string
+}
+
+// newReport creates an empty report for the batch of dir
+func newReport(dir, groupBy string, opts domain.MaximizeOptions) *report {
+	return &report{
+		Directory: dir,
+		GroupBy:   groupBy,
+		Objective: opts.Objective,
+		Mode:      opts.Mode,
+		Totals:    reportRow{Name: "total", Optimal: true},
+		providers: make(map[string]*providerRow),
+	}
+}
+
+// addGroup adds the selection of a group, counting its accepted bookings per provider
+func (r *report) addGroup(name string, bookings domain.Bookings, result *domain.MaximizeResult) {
+	row := reportRow{
+		Name:         name,
+		Bookings:     len(bookings),
+		Accepted:     len(result.Selected),
+		Score:        result.Score,
+		TotalProfit:  result.TotalProfit,
+		TotalRevenue: result.TotalRevenue,
+		TotalNights:  result.TotalNights,
+		Optimal:      result.Optimal,
+		IdleNights:   idleNights(result.Selected),
+	}
+	r.Groups = append(r.Groups, row)
+
+	r.Totals.Bookings += row.Bookings
+	r.Totals.Accepted += row.Accepted
+	r.Totals.Score = roundTo(r.Totals.Score+row.Score, 2)
+	r.Totals.TotalProfit = roundTo(r.Totals.TotalProfit+row.TotalProfit, 2)
+	r.Totals.TotalRevenue = roundTo(r.Totals.TotalRevenue+row.TotalRevenue, 2)
+	r.Totals.TotalNights += row.TotalNights
+	r.Totals.Optimal = r.Totals.Optimal && row.Optimal
+	r.Totals.IdleNights += row.IdleNights
+
+	for _, b := range bookings {
+		r.provider(b).Bookings++
+	}
+	for _, b := range result.Selected {
+		r.provider(b).Accepted++
+	}
+}
+
+// idleNights counts, property by property, the nights between the check-out of an accepted booking and
+// the check-in of the next one. Bookings without property share a timeline of their own
+func idleNights(accepted domain.Bookings) int {
+	byProperty := make(map[string]domain.Bookings)
+	for _, b := range accepted {
+		byProperty[b.PropertyID] = append(byProperty[b.PropertyID], b)
+	}
+
+	idle := 0
+	for _, stays := range byProperty {
+		slices.SortFunc(stays, func(a, b *domain.Booking) int { return a.CheckIn.Compare(b.CheckIn) })
+		for i := 1; i < len(stays); i++ {
+			idle += max(domain.NightsBetween(stays[i-1].End(), stays[i].CheckIn), 0)
+		}
+	}
+
+	return idle
+}
+
+// provider returns the counters of the provider of b
+func (r *report) provider(b *domain.Booking) *providerRow {
+	name := b.Provider()
+	p, ok := r.providers[name]
+	if !ok {
+		p = &providerRow{Provider: name}
+		r.providers[name] = p
+	}
+
+	return p
+}
+
+// addFailure records an input of the batch that failed
+func (r *report) addFailure(input string, err error) {
+	r.Failures = append(r.Failures, reportFailure{Input: input, Error: err.Error()})
+}
+
+// Providers returns the provider counters sorted by provider
+func (r *report) Providers() []providerRow {
+	rows := make([]providerRow, 0, len(r.providers))
+	for _, name := range slices.Sorted(maps.Keys(r.providers)) {
+		rows = append(rows, *r.providers[name])
+	}
+
+	return rows
+}
+
+// AcceptanceRate returns the share of the bookings that were selected
+func (r reportRow) AcceptanceRate() float64 {
+	return acceptanceRate(r.Accepted, r.Bookings)
+}
+
+// AcceptanceRate returns the share of the bookings of the provider that were selected
+func (p providerRow) AcceptanceRate() float64 {
+	return acceptanceRate(p.Accepted, p.Bookings)
+}
+
+// acceptanceRate returns accepted over total, or 0 when there are no bookings
+func acceptanceRate(accepted, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+
+	return float64(accepted) / float64(total)
+}
+
+// reportRenderer returns the function writing a report in the given format
+func reportRenderer(format string) (func(io.Writer, *report) error, error) {
+	switch format {
+	case reportMarkdown:
+		return func(w io.Writer, r *report) error { return markdownReport.Execute(w, r) }, nil
+	case reportHTML:
+		return func(w io.Writer, r *report) error { return htmlReport.Execute(w, r) }, nil
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrInvalidReport, format)
+	}
+}
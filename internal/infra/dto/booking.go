@@ -0,0 +1,126 @@
+package dto
+
+import (
+	"errors"
+	"time"
+
+	"github.com/duksonn/stay-for-long/internal/domain"
+)
+
+var (
+	// ErrInvalidDateFormat is returned when the date has invalid format
+	ErrInvalidDateFormat = errors.New("invalid date format")
+	// ErrInvalidTimeFormat is returned when a time of day has invalid format
+	ErrInvalidTimeFormat = errors.New("invalid time format")
+	// ErrInvalidTimezone is returned when the timezone is not a known IANA location
+	ErrInvalidTimezone = errors.New("invalid timezone")
+	// ErrInvalidCheckOut is returned when an explicit check-out is not after the check-in or contradicts nights
+	ErrInvalidCheckOut = errors.New("invalid check-out")
+)
+
+// Locations caches the IANA locations of the bookings decoded together, so each timezone is loaded once
+// A nil Locations loads the timezone of every booking
+type Locations map[string]*time.Location
+
+// Load returns the location of the named timezone, UTC when the name is empty
+func (l Locations) Load(name string) (*time.Location, error) {
+	if name == "" {
+		return time.UTC, nil
+	}
+	if loc, ok := l[name]; ok {
+		return loc, nil
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, ErrInvalidTimezone
+	}
+	if l != nil {
+		l[name] = loc
+	}
+
+	return loc, nil
+}
+
+// Booking represents a booking request as received by the API adapters
+// It contains all necessary information to create a domain.Booking object
+type Booking struct {
+	RequestID    string  `json:"request_id"`     // Unique identifier for the booking request
+	PropertyID   string  `json:"property_id"`    // Optional identifier of the property the booking belongs to
+	CheckIn      string  `json:"check_in"`       // Check-in date in YYYY-MM-DD format
+	CheckOut     string  `json:"check_out"`      // Optional check-out date in YYYY-MM-DD format, alternative to nights
+	CheckInTime  string  `json:"check_in_time"`  // Optional check-in time of day in HH:MM format, defaults to 00:00
+	CheckOutTime string  `json:"check_out_time"` // Optional check-out time of day in HH:MM format, defaults to the check-in one
+	Timezone     string  `json:"timezone"`       // Optional IANA timezone of the property, defaults to UTC
+	Nights       int     `json:"nights"`         // Number of nights for the stay
+	SellingRate  float64 `json:"selling_rate"`   // Total selling rate for the entire stay
+	Margin       float64 `json:"margin"`         // Profit margin percentage
+}
+
+// ToDomain converts the booking request to a domain.Booking, loading its timezone through locations
+// Dates and times of day are interpreted in the property timezone. The check-out date is taken from
+// check_out when present, otherwise it is derived from nights, and the check-out time of day defaults
+// to the check-in one. When check_out or a time of day is given, the check-out must come after the
+// check-in; otherwise a booking of zero nights checks out when it checks in
+func (b Booking) ToDomain(locations Locations) (*domain.Booking, error) {
+	loc, err := locations.Load(b.Timezone)
+	if err != nil {
+		return nil, err
+	}
+
+	checkInDate, err := time.Parse(time.DateOnly, b.CheckIn)
+	if err != nil {
+		return nil, ErrInvalidDateFormat
+	}
+	checkInHour, checkInMinute, err := parseTimeOfDay(b.CheckInTime)
+	if err != nil {
+		return nil, err
+	}
+	checkOutHour, checkOutMinute := checkInHour, checkInMinute
+	if b.CheckOutTime != "" {
+		if checkOutHour, checkOutMinute, err = parseTimeOfDay(b.CheckOutTime); err != nil {
+			return nil, err
+		}
+	}
+
+	nights := b.Nights
+	checkOutDate := checkInDate.AddDate(0, 0, nights)
+	if b.CheckOut != "" {
+		if checkOutDate, err = time.Parse(time.DateOnly, b.CheckOut); err != nil {
+			return nil, ErrInvalidDateFormat
+		}
+		nights = domain.NightsBetween(checkInDate, checkOutDate)
+		if nights < 0 || (b.Nights != 0 && b.Nights != nights) {
+			return nil, ErrInvalidCheckOut
+		}
+	}
+
+	checkIn := time.Date(checkInDate.Year(), checkInDate.Month(), checkInDate.Day(), checkInHour, checkInMinute, 0, 0, loc)
+	checkOut := time.Date(checkOutDate.Year(), checkOutDate.Month(), checkOutDate.Day(), checkOutHour, checkOutMinute, 0, 0, loc)
+	explicit := b.CheckOut != "" || b.CheckInTime != "" || b.CheckOutTime != ""
+	if explicit && !checkOut.After(checkIn) {
+		return nil, ErrInvalidCheckOut
+	}
+
+	return &domain.Booking{
+		RequestID:   b.RequestID,
+		PropertyID:  b.PropertyID,
+		CheckIn:     checkIn,
+		CheckOut:    checkOut,
+		Nights:      nights,
+		SellingRate: b.SellingRate,
+		Margin:      b.Margin,
+	}, nil
+}
+
+// parseTimeOfDay parses an optional HH:MM time of day, defaulting to midnight
+func parseTimeOfDay(value string) (int, int, error) {
+	if value == "" {
+		return 0, 0, nil
+	}
+	t, err := time.Parse("15:04", value)
+	if err != nil {
+		return 0, 0, ErrInvalidTimeFormat
+	}
+
+	return t.Hour(), t.Minute(), nil
+}
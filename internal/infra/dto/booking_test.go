@@ -0,0 +1,125 @@
+package dto_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/duksonn/stay-for-long/internal/infra/dto"
+)
+
+func TestBooking_ToDomain(t *testing.T) {
+	madrid, err := time.LoadLocation("Europe/Madrid")
+	require.NoError(t, err)
+
+	tests := []struct {
+		name        string
+		booking     dto.Booking
+		expectedIn  time.Time
+		expectedEnd time.Time
+		nights      int
+		expectedErr error
+	}{
+		{
+			name:        "nights end at the check-in time of day",
+			booking:     dto.Booking{CheckIn: "2024-01-01", CheckInTime: "15:00", Nights: 1},
+			expectedIn:  time.Date(2024, 1, 1, 15, 0, 0, 0, time.UTC),
+			expectedEnd: time.Date(2024, 1, 2, 15, 0, 0, 0, time.UTC),
+			nights:      1,
+		},
+		{
+			name:        "check-out time applies to the day derived from nights",
+			booking:     dto.Booking{CheckIn: "2024-01-01", CheckInTime: "15:00", CheckOutTime: "11:00", Nights: 2},
+			expectedIn:  time.Date(2024, 1, 1, 15, 0, 0, 0, time.UTC),
+			expectedEnd: time.Date(2024, 1, 3, 11, 0, 0, 0, time.UTC),
+			nights:      2,
+		},
+		{
+			name:        "check-out date without time ends at the check-in time of day",
+			booking:     dto.Booking{CheckIn: "2024-03-30", CheckInTime: "15:00", CheckOut: "2024-04-01", Timezone: "Europe/Madrid"},
+			expectedIn:  time.Date(2024, 3, 30, 15, 0, 0, 0, madrid),
+			expectedEnd: time.Date(2024, 4, 1, 15, 0, 0, 0, madrid),
+			nights:      2,
+		},
+		{
+			name:        "late check-out on the departure day",
+			booking:     dto.Booking{CheckIn: "2024-01-01", CheckOut: "2024-01-03", CheckOutTime: "18:00"},
+			expectedIn:  time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+			expectedEnd: time.Date(2024, 1, 3, 18, 0, 0, 0, time.UTC),
+			nights:      2,
+		},
+		{
+			name:        "zero nights",
+			booking:     dto.Booking{CheckIn: "2024-01-01"},
+			expectedIn:  time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+			expectedEnd: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:        "zero nights with a check-in time",
+			booking:     dto.Booking{CheckIn: "2024-01-01", CheckInTime: "15:00"},
+			expectedErr: dto.ErrInvalidCheckOut,
+		},
+		{
+			name:        "same day check-out",
+			booking:     dto.Booking{CheckIn: "2024-01-01", CheckOut: "2024-01-01"},
+			expectedErr: dto.ErrInvalidCheckOut,
+		},
+		{
+			name:        "zero nights with an earlier check-out time",
+			booking:     dto.Booking{CheckIn: "2024-01-01", CheckInTime: "15:00", CheckOutTime: "11:00"},
+			expectedErr: dto.ErrInvalidCheckOut,
+		},
+		{
+			name:        "same day check-out before the check-in time",
+			booking:     dto.Booking{CheckIn: "2024-01-01", CheckInTime: "15:00", CheckOut: "2024-01-01", CheckOutTime: "11:00"},
+			expectedErr: dto.ErrInvalidCheckOut,
+		},
+		{
+			name:        "check-out contradicting nights",
+			booking:     dto.Booking{CheckIn: "2024-01-01", CheckOut: "2024-01-03", Nights: 3},
+			expectedErr: dto.ErrInvalidCheckOut,
+		},
+		{
+			name:        "unknown timezone",
+			booking:     dto.Booking{CheckIn: "2024-01-01", Nights: 1, Timezone: "Mars/Olympus"},
+			expectedErr: dto.ErrInvalidTimezone,
+		},
+		{
+			name:        "invalid check-out time",
+			booking:     dto.Booking{CheckIn: "2024-01-01", CheckOutTime: "25:00", Nights: 1},
+			expectedErr: dto.ErrInvalidTimeFormat,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			booking, err := tt.booking.ToDomain(dto.Locations{})
+			if tt.expectedErr != nil {
+				assert.ErrorIs(t, err, tt.expectedErr)
+				assert.Nil(t, booking)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.True(t, tt.expectedIn.Equal(booking.CheckIn), booking.CheckIn)
+			assert.True(t, tt.expectedEnd.Equal(booking.End()), booking.End())
+			assert.Equal(t, tt.nights, booking.Nights)
+		})
+	}
+}
+
+func TestLocations_Load(t *testing.T) {
+	locations := dto.Locations{}
+
+	first, err := locations.Load("Europe/Madrid")
+	require.NoError(t, err)
+	second, err := locations.Load("Europe/Madrid")
+	require.NoError(t, err)
+	utc, err := dto.Locations(nil).Load("")
+	require.NoError(t, err)
+
+	assert.Same(t, first, second)
+	assert.Same(t, time.UTC, utc)
+}
@@ -0,0 +1,422 @@
+package dto
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/duksonn/stay-for-long/internal/domain"
+	"github.com/duksonn/stay-for-long/internal/infra/limits"
+)
+
+// iCalendar layouts of DATE and DATE-TIME values
+const (
+	icsDateLayout     = "20060102"
+	icsDateTimeLayout = "20060102T150405"
+)
+
+// Custom iCalendar properties carrying the booking fields that have no standard counterpart
+const (
+	icsRequestID   = "X-REQUEST-ID"
+	icsPropertyID  = "X-PROPERTY-ID"
+	icsSellingRate = "X-SELLING-RATE"
+	icsMargin      = "X-MARGIN"
+)
+
+// icsMaxLineBytes is the longest unfolded content line accepted in a calendar body
+const icsMaxLineBytes = 64 * 1024
+
+// icsEvent holds the properties of a VEVENT needed to build a booking
+type icsEvent struct {
+	line        int
+	uid         string
+	requestID   string
+	propertyID  string
+	start       icsValue
+	end         icsValue
+	sellingRate string
+	margin      string
+}
+
+// icsValue is a DTSTART or DTEND property
+type icsValue struct {
+	line   int
+	params map[string]string
+	value  string
+}
+
+// decodeCalendarBookings reads an iCalendar body and builds a booking from every VEVENT
+// The stay goes from DTSTART to DTEND and the request ID, property ID, selling rate and margin are read from the
+// X-REQUEST-ID, X-PROPERTY-ID, X-SELLING-RATE and X-MARGIN properties, falling back to UID for the request ID.
+// Other components and the components nested in an event, such as alarms, are ignored
+func decodeCalendarBookings(body io.Reader, l limits.Limits) ([]*domain.Booking, error) {
+	lines := newICSLineReader(body)
+	bookings := make([]*domain.Booking, 0)
+	locations := Locations{}
+	var event *icsEvent
+	nested := 0
+	for {
+		line, content, err := lines.next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		name, params, value, err := parseICSProperty(content)
+		if err != nil {
+			return nil, &LineError{Line: line, Err: err}
+		}
+		switch {
+		case event == nil:
+			if name == "BEGIN" && strings.EqualFold(value, "VEVENT") {
+				event = &icsEvent{line: line}
+			}
+		case name == "BEGIN":
+			nested++
+		case name == "END" && nested > 0:
+			nested--
+		case nested > 0:
+			// properties of nested components do not describe the booking
+		case name == "END":
+			if !strings.EqualFold(value, "VEVENT") {
+				return nil, &LineError{Line: line, Err: fmt.Errorf("%w: unexpected END:%s", ErrInvalidCalendar, value)}
+			}
+			if bookings, err = appendCalendarBooking(bookings, event, l, locations); err != nil {
+				return nil, err
+			}
+			event = nil
+		default:
+			event.set(line, name, params, value)
+		}
+	}
+	if event != nil {
+		return nil, &LineError{Line: event.line, Err: fmt.Errorf("%w: unterminated event", ErrInvalidCalendar)}
+	}
+
+	return bookings, nil
+}
+
+// set stores a property of the event, ignoring the ones that do not describe the booking
+func (e *icsEvent) set(line int, name string, params map[string]string, value string) {
+	switch name {
+	case "UID":
+		e.uid = unescapeICSText(value)
+	case icsRequestID:
+		e.requestID = unescapeICSText(value)
+	case icsPropertyID:
+		e.propertyID = unescapeICSText(value)
+	case "DTSTART":
+		e.start = icsValue{line: line, params: params, value: value}
+	case "DTEND":
+		e.end = icsValue{line: line, params: params, value: value}
+	case icsSellingRate:
+		e.sellingRate = value
+	case icsMargin:
+		e.margin = value
+	}
+}
+
+// appendCalendarBooking converts an event and appends it to bookings, failing once they exceed MaxBookings
+// Errors point to the line of the offending property, or to the start of the event when it is incomplete
+func appendCalendarBooking(bookings []*domain.Booking, event *icsEvent, l limits.Limits, locations Locations) ([]*domain.Booking, error) {
+	dto, err := event.booking()
+	if err != nil {
+		return nil, err
+	}
+	if bookings, err = appendBooking(bookings, dto, l, locations); err != nil {
+		return nil, lineError(event.line, err)
+	}
+
+	return bookings, nil
+}
+
+// booking converts the event to a Booking DTO
+// Times are expressed in the timezone of DTSTART, and an all-day event without DTEND lasts one night
+func (e *icsEvent) booking() (Booking, error) {
+	dto := Booking{RequestID: e.requestID, PropertyID: e.propertyID}
+	if dto.RequestID == "" {
+		dto.RequestID = e.uid
+	}
+	if e.start.value == "" {
+		return Booking{}, &LineError{Line: e.line, Err: fmt.Errorf("%w: missing DTSTART", ErrInvalidCalendar)}
+	}
+
+	start, startIsDate, err := e.start.parse(time.UTC)
+	if err != nil {
+		return Booking{}, &LineError{Line: e.start.line, Err: err}
+	}
+	dto.CheckIn = start.Format(time.DateOnly)
+	if !startIsDate {
+		dto.CheckInTime = start.Format("15:04")
+		if loc := start.Location(); loc != time.UTC {
+			dto.Timezone = loc.String()
+		}
+	}
+
+	switch {
+	case e.end.value != "":
+		end, endIsDate, err := e.end.parse(start.Location())
+		if err != nil {
+			return Booking{}, &LineError{Line: e.end.line, Err: err}
+		}
+		end = end.In(start.Location())
+		dto.CheckOut = end.Format(time.DateOnly)
+		if !endIsDate || !startIsDate {
+			// a DATE end is the start of that day, not the check-in time of day
+			dto.CheckOutTime = end.Format("15:04")
+		}
+	case startIsDate:
+		dto.Nights = 1
+	}
+
+	if dto.SellingRate, err = parseICSNumber(icsSellingRate, e.sellingRate); err != nil {
+		return Booking{}, &LineError{Line: e.line, Err: err}
+	}
+	if dto.Margin, err = parseICSNumber(icsMargin, e.margin); err != nil {
+		return Booking{}, &LineError{Line: e.line, Err: err}
+	}
+
+	return dto, nil
+}
+
+// parseICSNumber reads the numeric value of a custom property, which is zero when it is missing
+func parseICSNumber(name, value string) (float64, error) {
+	if value == "" {
+		return 0, nil
+	}
+	n, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %s", ErrInvalidNumberFormat, name)
+	}
+
+	return n, nil
+}
+
+// parse reads a DATE or DATE-TIME value and reports whether it is a DATE
+// DATE-TIME values are read in their TZID, in UTC when they end with Z, or in floating otherwise
+func (v icsValue) parse(floating *time.Location) (time.Time, bool, error) {
+	if strings.EqualFold(v.params["VALUE"], "DATE") || len(v.value) == len(icsDateLayout) {
+		t, err := time.Parse(icsDateLayout, v.value)
+		if err != nil {
+			return time.Time{}, false, ErrInvalidDateFormat
+		}
+		return t, true, nil
+	}
+
+	loc := floating
+	value := v.value
+	switch {
+	case strings.HasSuffix(value, "Z"):
+		loc, value = time.UTC, strings.TrimSuffix(value, "Z")
+	case v.params["TZID"] != "":
+		var err error
+		if loc, err = time.LoadLocation(v.params["TZID"]); err != nil {
+			return time.Time{}, false, ErrInvalidTimezone
+		}
+	}
+	t, err := time.ParseInLocation(icsDateTimeLayout, value, loc)
+	if err != nil {
+		return time.Time{}, false, ErrInvalidDateFormat
+	}
+
+	return t, false, nil
+}
+
+// icsLineReader reads the content lines of an iCalendar body, unfolding the lines continued
+// on the next physical line, and tracks the physical line each content line starts on
+type icsLineReader struct {
+	scanner  *bufio.Scanner
+	line     int
+	text     string
+	buffered bool
+}
+
+// newICSLineReader creates an icsLineReader over body
+func newICSLineReader(body io.Reader) *icsLineReader {
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 0, 4096), icsMaxLineBytes)
+
+	return &icsLineReader{scanner: scanner}
+}
+
+// next returns the next non-empty content line and the physical line it starts on, or io.EOF at the end of the body
+func (r *icsLineReader) next() (int, string, error) {
+	for {
+		if !r.buffered && !r.scan() {
+			return 0, "", r.err()
+		}
+		start, content := r.line, r.text
+		r.buffered = false
+		for r.scan() {
+			if r.text == "" || (r.text[0] != ' ' && r.text[0] != '\t') {
+				r.buffered = true
+				break
+			}
+			content += r.text[1:]
+		}
+		if content != "" {
+			return start, content, nil
+		}
+	}
+}
+
+// scan reads the next physical line
+func (r *icsLineReader) scan() bool {
+	if !r.scanner.Scan() {
+		return false
+	}
+	r.line++
+	r.text = strings.TrimRight(r.scanner.Text(), "\r")
+
+	return true
+}
+
+// err converts the error that stopped the scanner, returning io.EOF at the end of the body
+func (r *icsLineReader) err() error {
+	err := r.scanner.Err()
+	switch {
+	case err == nil:
+		return io.EOF
+	case errors.Is(err, bufio.ErrTooLong):
+		return &LineError{Line: r.line + 1, Err: fmt.Errorf("%w: line too long", ErrInvalidCalendar)}
+	default:
+		return readError(err)
+	}
+}
+
+// parseICSProperty splits a content line into its upper-cased name, its parameters and its value
+// Parameter values may be quoted to hold the ';', ':' and ',' separators
+func parseICSProperty(content string) (string, map[string]string, string, error) {
+	params := make(map[string]string)
+	end := strings.IndexAny(content, ";:")
+	if end <= 0 {
+		return "", nil, "", fmt.Errorf("%w: malformed content line", ErrInvalidCalendar)
+	}
+	name := strings.ToUpper(content[:end])
+
+	for content[end] == ';' {
+		rest := content[end+1:]
+		eq := strings.IndexByte(rest, '=')
+		if eq <= 0 {
+			return "", nil, "", fmt.Errorf("%w: malformed parameter of %s", ErrInvalidCalendar, name)
+		}
+		key, rest := strings.ToUpper(rest[:eq]), rest[eq+1:]
+
+		var value string
+		var consumed int
+		if strings.HasPrefix(rest, `"`) {
+			closing := strings.IndexByte(rest[1:], '"')
+			if closing < 0 {
+				return "", nil, "", fmt.Errorf("%w: unterminated quote in %s", ErrInvalidCalendar, name)
+			}
+			value, consumed = rest[1:closing+1], closing+2
+		} else {
+			consumed = strings.IndexAny(rest, ";:")
+			if consumed < 0 {
+				consumed = len(rest)
+			}
+			value = rest[:consumed]
+		}
+		params[key] = value
+
+		end += 1 + eq + 1 + consumed
+		if end >= len(content) {
+			return "", nil, "", fmt.Errorf("%w: missing value of %s", ErrInvalidCalendar, name)
+		}
+	}
+
+	return name, params, content[end+1:], nil
+}
+
+// unescapeICSText reverts the escaping of TEXT values
+func unescapeICSText(value string) string {
+	return strings.NewReplacer(`\\`, `\`, `\;`, ";", `\,`, ",", `\n`, "\n", `\N`, "\n").Replace(value)
+}
+
+// escapeICSText escapes a TEXT value
+func escapeICSText(value string) string {
+	return strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\n", `\n`).Replace(value)
+}
+
+// WriteCalendar writes bookings as an iCalendar feed with one all-day or timed event per booking
+// Events keep the request ID as UID, so subscribers update them in place when the selection changes
+func WriteCalendar(w io.Writer, bookings domain.Bookings, now time.Time) error {
+	lines := []string{
+		"BEGIN:VCALENDAR",
+		"VERSION:2.0",
+		"PRODID:-//stay-for-long//maximize//EN",
+		"CALSCALE:GREGORIAN",
+		"METHOD:PUBLISH",
+	}
+	stamp := now.UTC().Format(icsDateTimeLayout) + "Z"
+	for _, b := range bookings {
+		start, end := formatICSTimes(b.CheckIn, b.End())
+		lines = append(lines,
+			"BEGIN:VEVENT",
+			"UID:"+escapeICSText(b.RequestID),
+			"DTSTAMP:"+stamp,
+			"DTSTART"+start,
+			"DTEND"+end,
+			"SUMMARY:"+escapeICSText(b.RequestID),
+			icsRequestID+":"+escapeICSText(b.RequestID),
+		)
+		if b.PropertyID != "" {
+			lines = append(lines, icsPropertyID+":"+escapeICSText(b.PropertyID))
+		}
+		lines = append(lines,
+			icsSellingRate+":"+formatFloat(b.SellingRate),
+			icsMargin+":"+formatFloat(b.Margin),
+			"END:VEVENT",
+		)
+	}
+	lines = append(lines, "END:VCALENDAR")
+
+	writer := bufio.NewWriter(w)
+	for _, line := range lines {
+		if _, err := writer.WriteString(foldICSLine(line) + "\r\n"); err != nil {
+			return err
+		}
+	}
+
+	return writer.Flush()
+}
+
+// formatICSTimes formats the parameters and values of the DTSTART and DTEND properties of an event
+// Both take the same value type: stays starting and ending at midnight become all-day events with DATE
+// values, any other stay is written with UTC DATE-TIME values, which need no VTIMEZONE component
+func formatICSTimes(start, end time.Time) (string, string) {
+	if isMidnight(start) && isMidnight(end) {
+		return ";VALUE=DATE:" + start.Format(icsDateLayout), ";VALUE=DATE:" + end.Format(icsDateLayout)
+	}
+
+	return ":" + start.UTC().Format(icsDateTimeLayout) + "Z", ":" + end.UTC().Format(icsDateTimeLayout) + "Z"
+}
+
+// isMidnight reports whether t is the start of a day in its own location
+func isMidnight(t time.Time) bool {
+	return t.Hour() == 0 && t.Minute() == 0 && t.Second() == 0
+}
+
+// foldICSLine splits a content line longer than 75 octets into continuation lines, without splitting characters
+func foldICSLine(line string) string {
+	const limit = 75
+	var folded strings.Builder
+	width := 0
+	for _, r := range line {
+		size := len(string(r))
+		if width+size > limit {
+			folded.WriteString("\r\n ")
+			width = 1
+		}
+		folded.WriteRune(r)
+		width += size
+	}
+
+	return folded.String()
+}
@@ -0,0 +1,280 @@
+package dto
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/duksonn/stay-for-long/internal/domain"
+	"github.com/duksonn/stay-for-long/internal/infra/limits"
+)
+
+// Media types the bookings can be read in
+// Any other media type is decoded as a JSON array
+const (
+	ContentTypeJSON     = "application/json"
+	ContentTypeCSV      = "text/csv"
+	ContentTypeNDJSON   = "application/x-ndjson"
+	ContentTypeCalendar = "text/calendar"
+)
+
+var (
+	// ErrInvalidJSON is returned when the json is invalid
+	ErrInvalidJSON = errors.New("invalid request json")
+	// ErrInvalidNumberFormat is returned when a numeric CSV value is not a number
+	ErrInvalidNumberFormat = errors.New("invalid number format")
+	// ErrInvalidCSV is returned when a CSV body is malformed
+	ErrInvalidCSV = errors.New("invalid request csv")
+	// ErrInvalidCSVHeaders is returned when the CSV header mapping or the header of a CSV body is unusable
+	ErrInvalidCSVHeaders = errors.New("invalid csv headers")
+	// ErrInvalidCalendar is returned when an iCalendar body is malformed
+	ErrInvalidCalendar = errors.New("invalid request calendar")
+	// ErrReadBookings is returned, wrapping the cause, when the bookings cannot be read from their source
+	ErrReadBookings = errors.New("cannot read bookings")
+)
+
+// bookingFields lists the Booking fields a CSV column can be mapped to
+var bookingFields = []string{
+	"request_id", "property_id", "check_in", "check_out", "check_in_time", "check_out_time",
+	"timezone", "nights", "selling_rate", "margin",
+}
+
+// LineError reports the line of a CSV, NDJSON or iCalendar body where a booking could not be decoded
+type LineError struct {
+	Line int
+	Err  error
+}
+
+// Error returns the decoding error prefixed with its line number
+func (e *LineError) Error() string {
+	return fmt.Sprintf("line %d: %v", e.Line, e.Err)
+}
+
+// Unwrap returns the decoding error
+func (e *LineError) Unwrap() error {
+	return e.Err
+}
+
+// CSVColumns maps the normalized CSV header names to the Booking fields they hold
+type CSVColumns map[string]string
+
+// NewCSVColumns builds the CSV header mapping from a set of aliases
+// Every field can always be referred to by its own name. Aliases are matched ignoring case and surrounding
+// spaces, and ErrInvalidCSVHeaders is returned when one of them targets an unknown field
+func NewCSVColumns(aliases map[string]string) (CSVColumns, error) {
+	columns := make(CSVColumns, len(bookingFields)+len(aliases))
+	for _, field := range bookingFields {
+		columns[field] = field
+	}
+	for alias, field := range aliases {
+		if !slices.Contains(bookingFields, field) {
+			return nil, fmt.Errorf("%w: unknown field %q for column %q", ErrInvalidCSVHeaders, field, alias)
+		}
+		columns[normalizeHeader(alias)] = field
+	}
+
+	return columns, nil
+}
+
+// normalizeHeader returns the key a CSV header is looked up with
+func normalizeHeader(header string) string {
+	return strings.ToLower(strings.TrimSpace(header))
+}
+
+// DecodeBookings reads a list of bookings in the given media type and converts it to domain.Booking objects,
+// enforcing the booking count limit. CSV, NDJSON and iCalendar bodies are decoded as a stream, so the limit
+// stops the decoding as soon as it is exceeded and errors are reported with their line number.
+// Errors reading a streamed body are returned wrapped in ErrReadBookings
+func DecodeBookings(body io.Reader, mediaType string, l limits.Limits, columns CSVColumns) ([]*domain.Booking, error) {
+	switch mediaType {
+	case ContentTypeCSV:
+		return decodeCSVBookings(body, l, columns)
+	case ContentTypeNDJSON:
+		return decodeNDJSONBookings(body, l)
+	case ContentTypeCalendar:
+		return decodeCalendarBookings(body, l)
+	default:
+		var dtos []Booking
+		if err := json.NewDecoder(body).Decode(&dtos); err != nil {
+			return nil, ErrInvalidJSON
+		}
+		if err := l.CheckBookings(len(dtos)); err != nil {
+			return nil, err
+		}
+		return BookingsToDomain(dtos)
+	}
+}
+
+// BookingsToDomain converts a slice of Booking DTOs to domain.Booking objects
+func BookingsToDomain(dtos []Booking) ([]*domain.Booking, error) {
+	bookings := make([]*domain.Booking, 0, len(dtos))
+	locations := Locations{}
+	for _, dto := range dtos {
+		booking, err := dto.ToDomain(locations)
+		if err != nil {
+			return nil, err
+		}
+		bookings = append(bookings, booking)
+	}
+
+	return bookings, nil
+}
+
+// readError wraps an error returned by the source of the bookings
+func readError(err error) error {
+	return fmt.Errorf("%w: %w", ErrReadBookings, err)
+}
+
+// decodeCSVBookings reads a CSV body whose first record is the header
+// Columns are matched to the booking fields through columns and unknown columns are ignored
+func decodeCSVBookings(body io.Reader, l limits.Limits, columns CSVColumns) ([]*domain.Booking, error) {
+	reader := csv.NewReader(body)
+	reader.TrimLeadingSpace = true
+	reader.ReuseRecord = true
+
+	header, err := reader.Read()
+	if errors.Is(err, io.EOF) {
+		return []*domain.Booking{}, nil
+	}
+	if err != nil {
+		return nil, csvReadError(err)
+	}
+	header = slices.Clone(header)
+	fields := make([]string, len(header))
+	for i, name := range header {
+		fields[i] = columns[normalizeHeader(name)]
+	}
+	for _, required := range []string{"request_id", "check_in"} {
+		if !slices.Contains(fields, required) {
+			return nil, &LineError{Line: 1, Err: fmt.Errorf("%w: missing %s column", ErrInvalidCSVHeaders, required)}
+		}
+	}
+
+	bookings := make([]*domain.Booking, 0)
+	locations := Locations{}
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return bookings, nil
+		}
+		if err != nil {
+			return nil, csvReadError(err)
+		}
+		line, _ := reader.FieldPos(0)
+
+		var dto Booking
+		for i, value := range record {
+			if err := setBookingField(&dto, fields[i], value); err != nil {
+				return nil, &LineError{Line: line, Err: fmt.Errorf("%w: column %q", err, header[i])}
+			}
+		}
+		if bookings, err = appendBooking(bookings, dto, l, locations); err != nil {
+			return nil, lineError(line, err)
+		}
+	}
+}
+
+// csvReadError converts an error returned by the CSV reader, keeping the line of malformed records
+func csvReadError(err error) error {
+	var parseErr *csv.ParseError
+	if errors.As(err, &parseErr) {
+		return &LineError{Line: parseErr.Line, Err: fmt.Errorf("%w: %w", ErrInvalidCSV, parseErr.Err)}
+	}
+
+	return readError(err)
+}
+
+// setBookingField stores a CSV value in the Booking field it is mapped to
+// Empty values leave the field unset and values of unmapped columns are ignored
+func setBookingField(dto *Booking, field, value string) error {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return nil
+	}
+
+	var err error
+	switch field {
+	case "request_id":
+		dto.RequestID = value
+	case "property_id":
+		dto.PropertyID = value
+	case "check_in":
+		dto.CheckIn = value
+	case "check_out":
+		dto.CheckOut = value
+	case "check_in_time":
+		dto.CheckInTime = value
+	case "check_out_time":
+		dto.CheckOutTime = value
+	case "timezone":
+		dto.Timezone = value
+	case "nights":
+		dto.Nights, err = strconv.Atoi(value)
+	case "selling_rate":
+		dto.SellingRate, err = strconv.ParseFloat(value, 64)
+	case "margin":
+		dto.Margin, err = strconv.ParseFloat(value, 64)
+	}
+	if err != nil {
+		return ErrInvalidNumberFormat
+	}
+
+	return nil
+}
+
+// decodeNDJSONBookings reads a body holding one JSON Booking per line
+// Blank lines are skipped
+func decodeNDJSONBookings(body io.Reader, l limits.Limits) ([]*domain.Booking, error) {
+	reader := bufio.NewReader(body)
+	bookings := make([]*domain.Booking, 0)
+	locations := Locations{}
+	for line := 1; ; line++ {
+		raw, readErr := reader.ReadBytes('\n')
+		if readErr != nil && !errors.Is(readErr, io.EOF) {
+			return nil, readError(readErr)
+		}
+		if raw = bytes.TrimSpace(raw); len(raw) > 0 {
+			var dto Booking
+			if err := json.Unmarshal(raw, &dto); err != nil {
+				return nil, &LineError{Line: line, Err: ErrInvalidJSON}
+			}
+			var err error
+			if bookings, err = appendBooking(bookings, dto, l, locations); err != nil {
+				return nil, lineError(line, err)
+			}
+		}
+		if errors.Is(readErr, io.EOF) {
+			return bookings, nil
+		}
+	}
+}
+
+// appendBooking converts a decoded Booking and appends it to bookings, failing once they exceed MaxBookings
+func appendBooking(bookings []*domain.Booking, dto Booking, l limits.Limits, locations Locations) ([]*domain.Booking, error) {
+	if err := l.CheckBookings(len(bookings) + 1); err != nil {
+		return nil, err
+	}
+	booking, err := dto.ToDomain(locations)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(bookings, booking), nil
+}
+
+// lineError attaches the line number to a booking that could not be converted
+// Limit errors concern the whole body, so they are returned as they are
+func lineError(line int, err error) error {
+	if errors.Is(err, limits.ErrTooManyBookings) {
+		return err
+	}
+
+	return &LineError{Line: line, Err: err}
+}
@@ -0,0 +1,117 @@
+package dto_test
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/duksonn/stay-for-long/internal/domain"
+	"github.com/duksonn/stay-for-long/internal/infra/dto"
+	"github.com/duksonn/stay-for-long/internal/infra/limits"
+)
+
+func TestDecodeBookings(t *testing.T) {
+	tests := []struct {
+		name        string
+		body        io.Reader
+		mediaType   string
+		limits      limits.Limits
+		aliases     map[string]string
+		expectedIDs []string
+		expectedErr error
+		expectedMsg string
+	}{
+		{
+			name:        "json array",
+			body:        strings.NewReader(`[{"request_id":"bookata_XY123","check_in":"2020-01-01","nights":5}]`),
+			mediaType:   dto.ContentTypeJSON,
+			expectedIDs: []string{"bookata_XY123"},
+		},
+		{
+			name:        "unknown media type read as json",
+			body:        strings.NewReader(`{"request_id":"bookata_XY123"}`),
+			mediaType:   "text/plain",
+			expectedErr: dto.ErrInvalidJSON,
+		},
+		{
+			name:        "csv with aliases",
+			body:        strings.NewReader("Booking ID,Arrival,nights\nbookata_XY123,2020-01-01,5\nacme_AAAAA,2020-01-10,4\n"),
+			mediaType:   dto.ContentTypeCSV,
+			aliases:     map[string]string{"Booking ID": "request_id", "Arrival": "check_in"},
+			expectedIDs: []string{"bookata_XY123", "acme_AAAAA"},
+		},
+		{
+			name:        "csv line error",
+			body:        strings.NewReader("request_id,check_in,nights\nbookata_XY123,2020-01-01,five\n"),
+			mediaType:   dto.ContentTypeCSV,
+			expectedErr: dto.ErrInvalidNumberFormat,
+			expectedMsg: `line 2: invalid number format: column "nights"`,
+		},
+		{
+			name:        "ndjson over the booking limit",
+			body:        strings.NewReader(strings.Repeat(`{"request_id":"bookata_XY123","check_in":"2020-01-01","nights":5}`+"\n", 3)),
+			mediaType:   dto.ContentTypeNDJSON,
+			limits:      limits.Limits{MaxBookings: 2},
+			expectedErr: limits.ErrTooManyBookings,
+		},
+		{
+			name: "calendar",
+			body: strings.NewReader("BEGIN:VCALENDAR\r\nBEGIN:VEVENT\r\nUID:bookata_XY123\r\n" +
+				"DTSTART;VALUE=DATE:20200101\r\nDTEND;VALUE=DATE:20200106\r\nEND:VEVENT\r\nEND:VCALENDAR\r\n"),
+			mediaType:   dto.ContentTypeCalendar,
+			expectedIDs: []string{"bookata_XY123"},
+		},
+		{
+			name:        "read failure",
+			body:        io.MultiReader(strings.NewReader("request_id,check_in\n"), iotest.ErrReader(errors.New("disk failure"))),
+			mediaType:   dto.ContentTypeCSV,
+			expectedErr: dto.ErrReadBookings,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			columns, err := dto.NewCSVColumns(tt.aliases)
+			require.NoError(t, err)
+
+			bookings, err := dto.DecodeBookings(tt.body, tt.mediaType, tt.limits, columns)
+
+			if tt.expectedErr != nil {
+				assert.ErrorIs(t, err, tt.expectedErr)
+				if tt.expectedMsg != "" {
+					assert.EqualError(t, err, tt.expectedMsg)
+				}
+				return
+			}
+			require.NoError(t, err)
+			ids := make([]string, 0, len(bookings))
+			for _, b := range bookings {
+				ids = append(ids, b.RequestID)
+			}
+			assert.Equal(t, tt.expectedIDs, ids)
+		})
+	}
+}
+
+func TestNewCSVColumns_UnknownField(t *testing.T) {
+	_, err := dto.NewCSVColumns(map[string]string{"Arrival": "arrival"})
+
+	assert.ErrorIs(t, err, dto.ErrInvalidCSVHeaders)
+}
+
+func TestWriteCSV(t *testing.T) {
+	var buf bytes.Buffer
+
+	err := dto.WriteCSV(&buf, dto.NewStatsResult(&domain.StatsResult{AvgNight: -1.5, MinNight: 2.25, MaxNight: 12}).CSVRecords())
+	require.NoError(t, err)
+	err = dto.WriteCSV(&buf, [][]string{{"=cmd", "@sum", "plain, text"}})
+	require.NoError(t, err)
+
+	assert.Equal(t, "group,avg_night,min_night,max_night\nall,-1.5,2.25,12\n'=cmd,'@sum,\"plain, text\"\n", buf.String())
+}
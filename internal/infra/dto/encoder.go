@@ -0,0 +1,38 @@
+package dto
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// WriteCSV writes records as a UTF-8 CSV document
+// Text cells that spreadsheets would evaluate as formulas are escaped with a leading quote
+func WriteCSV(w io.Writer, records [][]string) error {
+	writer := csv.NewWriter(w)
+	for _, record := range records {
+		for i, cell := range record {
+			record[i] = escapeCSVCell(cell)
+		}
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+
+	return writer.Error()
+}
+
+// escapeCSVCell prefixes the cells starting like a spreadsheet formula, so they are imported as text
+// Numbers are written as they are, so negative values stay numeric
+func escapeCSVCell(cell string) string {
+	if cell == "" || !strings.ContainsRune("=+-@\t\r", rune(cell[0])) {
+		return cell
+	}
+	if _, err := strconv.ParseFloat(cell, 64); err == nil {
+		return cell
+	}
+
+	return "'" + cell
+}
@@ -0,0 +1,151 @@
+package dto
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/duksonn/stay-for-long/internal/domain"
+)
+
+// StatsResult represents the statistics of a set of bookings as returned by the API adapters
+type StatsResult struct {
+	AvgNight float64      `json:"avg_night"`        // Average nightly rate
+	MinNight float64      `json:"min_night"`        // Minimum nightly rate
+	MaxNight float64      `json:"max_night"`        // Maximum nightly rate
+	Groups   []StatsGroup `json:"groups,omitempty"` // Stats of each group, only present when grouping
+}
+
+// StatsGroup represents the statistics of the bookings sharing a group key
+type StatsGroup struct {
+	Key      string  `json:"key"`       // Value the bookings share, empty for the bookings without property
+	Count    int     `json:"count"`     // Number of bookings in the group
+	AvgNight float64 `json:"avg_night"` // Average nightly rate
+	MinNight float64 `json:"min_night"` // Minimum nightly rate
+	MaxNight float64 `json:"max_night"` // Maximum nightly rate
+}
+
+// MaximizeResult represents the optimal booking combination and its associated statistics
+// as returned by the API adapters
+type MaximizeResult struct {
+	RequestIDs   []string          `json:"request_ids"`   // List of request IDs that maximize the objective
+	Objective    string            `json:"objective"`     // Objective used to rank combinations
+	Score        float64           `json:"score"`         // Objective value reached by the selected bookings
+	TotalProfit  float64           `json:"total_profit"`  // Total profit for the selected bookings
+	TotalRevenue float64           `json:"total_revenue"` // Total selling rate for the selected bookings
+	TotalNights  int               `json:"total_nights"`  // Total occupied nights for the selected bookings
+	AvgNight     float64           `json:"avg_night"`     // Average nightly rate for selected bookings
+	MinNight     float64           `json:"min_night"`     // Minimum nightly rate for selected bookings
+	MaxNight     float64           `json:"max_night"`     // Maximum nightly rate for selected bookings
+	Optimal      bool              `json:"optimal"`       // Whether the selection is proven optimal
+	UpperBound   float64           `json:"upper_bound"`   // Score no selection can exceed
+	Gap          float64           `json:"gap"`           // Distance between the upper bound and the score
+	Rejected     []RejectedBooking `json:"rejected"`      // Bookings left out of the selection
+
+	result *domain.MaximizeResult // Result being rendered, used to detail each booking in the CSV and ICS renderings
+}
+
+// RejectedBooking represents a booking left out of the optimal selection
+// CounterOfferRate is only present when counter-offers were requested and one exists
+type RejectedBooking struct {
+	RequestID        string  `json:"request_id"`                   // Request ID of the rejected booking
+	CounterOfferRate float64 `json:"counter_offer_rate,omitempty"` // Minimum selling rate that would get it selected
+}
+
+// NewStatsResult maps a domain.StatsResult to its DTO
+func NewStatsResult(stats *domain.StatsResult) StatsResult {
+	return StatsResult{
+		AvgNight: stats.AvgNight,
+		MinNight: stats.MinNight,
+		MaxNight: stats.MaxNight,
+	}
+}
+
+// NewMaximizeResult maps a domain.MaximizeResult to its DTO
+func NewMaximizeResult(result *domain.MaximizeResult) MaximizeResult {
+	rejected := make([]RejectedBooking, 0, len(result.Rejected))
+	for _, r := range result.Rejected {
+		rejected = append(rejected, RejectedBooking{
+			RequestID:        r.Booking.RequestID,
+			CounterOfferRate: r.CounterOfferRate,
+		})
+	}
+
+	return MaximizeResult{
+		RequestIDs:   result.RequestIDs,
+		Objective:    string(result.Objective),
+		Score:        result.Score,
+		TotalProfit:  result.TotalProfit,
+		TotalRevenue: result.TotalRevenue,
+		TotalNights:  result.TotalNights,
+		AvgNight:     result.AvgNight,
+		MinNight:     result.MinNight,
+		MaxNight:     result.MaxNight,
+		Optimal:      result.Optimal,
+		UpperBound:   result.UpperBound,
+		Gap:          result.Gap,
+		Rejected:     rejected,
+		result:       result,
+	}
+}
+
+// CSVRecords renders one row per group, or a single row for the whole request under the "all" group
+// when the stats are not grouped. The first record is the header
+func (r StatsResult) CSVRecords() [][]string {
+	records := [][]string{{"group", "avg_night", "min_night", "max_night"}}
+	if r.Groups == nil {
+		return append(records, []string{"all", formatFloat(r.AvgNight), formatFloat(r.MinNight), formatFloat(r.MaxNight)})
+	}
+	for _, g := range r.Groups {
+		records = append(records, []string{g.Key, formatFloat(g.AvgNight), formatFloat(g.MinNight), formatFloat(g.MaxNight)})
+	}
+
+	return records
+}
+
+// CSVRecords renders one row per accepted booking followed by one row per rejected booking
+// The first record is the header
+func (r MaximizeResult) CSVRecords() [][]string {
+	records := make([][]string, 0, 1+len(r.result.Selected)+len(r.result.Rejected))
+	records = append(records, []string{
+		"request_id", "status", "check_in", "check_out", "nights", "selling_rate", "margin",
+		"profit", "profit_per_night", "counter_offer_rate",
+	})
+	for _, b := range r.result.Selected {
+		records = append(records, bookingCSVRecord(b, "accepted", ""))
+	}
+	for _, rejected := range r.result.Rejected {
+		counterOffer := ""
+		if rejected.CounterOfferRate > 0 {
+			counterOffer = formatFloat(rejected.CounterOfferRate)
+		}
+		records = append(records, bookingCSVRecord(rejected.Booking, "rejected", counterOffer))
+	}
+
+	return records
+}
+
+// CalendarBookings returns the accepted bookings, rendered as the events of an iCalendar feed
+func (r MaximizeResult) CalendarBookings() domain.Bookings {
+	return r.result.Selected
+}
+
+// bookingCSVRecord renders a booking of a maximize result as a CSV row
+func bookingCSVRecord(b *domain.Booking, status, counterOffer string) []string {
+	return []string{
+		b.RequestID,
+		status,
+		b.CheckIn.Format(time.DateOnly),
+		b.End().Format(time.DateOnly),
+		strconv.Itoa(b.Nights),
+		formatFloat(b.SellingRate),
+		formatFloat(b.Margin),
+		formatFloat(domain.Bookings{b}.TotalProfit()),
+		formatFloat(b.ProfitPerNight()),
+		counterOffer,
+	}
+}
+
+// formatFloat formats a number for a CSV cell or an iCalendar value with the shortest exact representation
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
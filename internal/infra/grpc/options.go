@@ -0,0 +1,28 @@
+package grpc
+
+import "github.com/duksonn/stay-for-long/internal/infra/limits"
+
+// Option configures optional behaviour of the server
+type Option func(*options)
+
+// options holds the optional configuration of the server
+type options struct {
+	limits limits.Limits
+}
+
+// WithLimits sets the request limits enforced by the server
+func WithLimits(l limits.Limits) Option {
+	return func(o *options) {
+		o.limits = l
+	}
+}
+
+// newOptions applies opts over the default options
+func newOptions(opts []Option) options {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	return o
+}
@@ -0,0 +1,981 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.6
+// 	protoc        (unknown)
+// source: stats.proto
+
+package pb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// Objective is the metric maximized by the optimizer, OBJECTIVE_UNSPECIFIED meaning profit
+type Objective int32
+
+const (
+	Objective_OBJECTIVE_UNSPECIFIED Objective = 0
+	Objective_OBJECTIVE_PROFIT      Objective = 1
+	Objective_OBJECTIVE_REVENUE     Objective = 2
+	Objective_OBJECTIVE_OCCUPANCY   Objective = 3
+	Objective_OBJECTIVE_WEIGHTED    Objective = 4
+)
+
+// Enum value maps for Objective.
+var (
+	Objective_name = map[int32]string{
+		0: "OBJECTIVE_UNSPECIFIED",
+		1: "OBJECTIVE_PROFIT",
+		2: "OBJECTIVE_REVENUE",
+		3: "OBJECTIVE_OCCUPANCY",
+		4: "OBJECTIVE_WEIGHTED",
+	}
+	Objective_value = map[string]int32{
+		"OBJECTIVE_UNSPECIFIED": 0,
+		"OBJECTIVE_PROFIT":      1,
+		"OBJECTIVE_REVENUE":     2,
+		"OBJECTIVE_OCCUPANCY":   3,
+		"OBJECTIVE_WEIGHTED":    4,
+	}
+)
+
+func (x Objective) Enum() *Objective {
+	p := new(Objective)
+	*p = x
+	return p
+}
+
+func (x Objective) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (Objective) Descriptor() protoreflect.EnumDescriptor {
+	return file_stats_proto_enumTypes[0].Descriptor()
+}
+
+func (Objective) Type() protoreflect.EnumType {
+	return &file_stats_proto_enumTypes[0]
+}
+
+func (x Objective) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use Objective.Descriptor instead.
+func (Objective) EnumDescriptor() ([]byte, []int) {
+	return file_stats_proto_rawDescGZIP(), []int{0}
+}
+
+// TieBreak chooses between selections with the same score, TIE_BREAK_UNSPECIFIED meaning request IDs
+type TieBreak int32
+
+const (
+	TieBreak_TIE_BREAK_UNSPECIFIED        TieBreak = 0
+	TieBreak_TIE_BREAK_REQUEST_IDS        TieBreak = 1
+	TieBreak_TIE_BREAK_FEWEST_BOOKINGS    TieBreak = 2
+	TieBreak_TIE_BREAK_EARLIEST_CHECK_IN  TieBreak = 3
+	TieBreak_TIE_BREAK_PREFERRED_PROVIDER TieBreak = 4
+)
+
+// Enum value maps for TieBreak.
+var (
+	TieBreak_name = map[int32]string{
+		0: "TIE_BREAK_UNSPECIFIED",
+		1: "TIE_BREAK_REQUEST_IDS",
+		2: "TIE_BREAK_FEWEST_BOOKINGS",
+		3: "TIE_BREAK_EARLIEST_CHECK_IN",
+		4: "TIE_BREAK_PREFERRED_PROVIDER",
+	}
+	TieBreak_value = map[string]int32{
+		"TIE_BREAK_UNSPECIFIED":        0,
+		"TIE_BREAK_REQUEST_IDS":        1,
+		"TIE_BREAK_FEWEST_BOOKINGS":    2,
+		"TIE_BREAK_EARLIEST_CHECK_IN":  3,
+		"TIE_BREAK_PREFERRED_PROVIDER": 4,
+	}
+)
+
+func (x TieBreak) Enum() *TieBreak {
+	p := new(TieBreak)
+	*p = x
+	return p
+}
+
+func (x TieBreak) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (TieBreak) Descriptor() protoreflect.EnumDescriptor {
+	return file_stats_proto_enumTypes[1].Descriptor()
+}
+
+func (TieBreak) Type() protoreflect.EnumType {
+	return &file_stats_proto_enumTypes[1]
+}
+
+func (x TieBreak) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use TieBreak.Descriptor instead.
+func (TieBreak) EnumDescriptor() ([]byte, []int) {
+	return file_stats_proto_rawDescGZIP(), []int{1}
+}
+
+// Mode selects how the selection is searched, MODE_UNSPECIFIED meaning exact
+type Mode int32
+
+const (
+	Mode_MODE_UNSPECIFIED Mode = 0
+	Mode_MODE_EXACT       Mode = 1
+	Mode_MODE_HEURISTIC   Mode = 2
+)
+
+// Enum value maps for Mode.
+var (
+	Mode_name = map[int32]string{
+		0: "MODE_UNSPECIFIED",
+		1: "MODE_EXACT",
+		2: "MODE_HEURISTIC",
+	}
+	Mode_value = map[string]int32{
+		"MODE_UNSPECIFIED": 0,
+		"MODE_EXACT":       1,
+		"MODE_HEURISTIC":   2,
+	}
+)
+
+func (x Mode) Enum() *Mode {
+	p := new(Mode)
+	*p = x
+	return p
+}
+
+func (x Mode) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (Mode) Descriptor() protoreflect.EnumDescriptor {
+	return file_stats_proto_enumTypes[2].Descriptor()
+}
+
+func (Mode) Type() protoreflect.EnumType {
+	return &file_stats_proto_enumTypes[2]
+}
+
+func (x Mode) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use Mode.Descriptor instead.
+func (Mode) EnumDescriptor() ([]byte, []int) {
+	return file_stats_proto_rawDescGZIP(), []int{2}
+}
+
+// Booking is a booking request, with the same fields and rules as the bookings of the HTTP API
+type Booking struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Unique identifier, prefixed with the provider
+	RequestId string `protobuf:"bytes,1,opt,name=request_id,json=requestId,proto3" json:"request_id,omitempty"`
+	// Optional identifier of the property
+	PropertyId string `protobuf:"bytes,2,opt,name=property_id,json=propertyId,proto3" json:"property_id,omitempty"`
+	// Check-in date in YYYY-MM-DD format
+	CheckIn string `protobuf:"bytes,3,opt,name=check_in,json=checkIn,proto3" json:"check_in,omitempty"`
+	// Optional check-out date in YYYY-MM-DD format, alternative to nights
+	CheckOut string `protobuf:"bytes,4,opt,name=check_out,json=checkOut,proto3" json:"check_out,omitempty"`
+	// Optional check-in time of day in HH:MM format, defaults to 00:00
+	CheckInTime string `protobuf:"bytes,5,opt,name=check_in_time,json=checkInTime,proto3" json:"check_in_time,omitempty"`
+	// Optional check-out time of day in HH:MM format, defaults to the check-in time of day
+	CheckOutTime string `protobuf:"bytes,6,opt,name=check_out_time,json=checkOutTime,proto3" json:"check_out_time,omitempty"`
+	// Optional IANA timezone of the property, defaults to UTC
+	Timezone string `protobuf:"bytes,7,opt,name=timezone,proto3" json:"timezone,omitempty"`
+	// Number of nights of the stay
+	Nights int32 `protobuf:"varint,8,opt,name=nights,proto3" json:"nights,omitempty"`
+	// Total selling rate for the entire stay
+	SellingRate float64 `protobuf:"fixed64,9,opt,name=selling_rate,json=sellingRate,proto3" json:"selling_rate,omitempty"`
+	// Profit margin percentage
+	Margin        float64 `protobuf:"fixed64,10,opt,name=margin,proto3" json:"margin,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Booking) Reset() {
+	*x = Booking{}
+	mi := &file_stats_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Booking) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Booking) ProtoMessage() {}
+
+func (x *Booking) ProtoReflect() protoreflect.Message {
+	mi := &file_stats_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Booking.ProtoReflect.Descriptor instead.
+func (*Booking) Descriptor() ([]byte, []int) {
+	return file_stats_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Booking) GetRequestId() string {
+	if x != nil {
+		return x.RequestId
+	}
+	return ""
+}
+
+func (x *Booking) GetPropertyId() string {
+	if x != nil {
+		return x.PropertyId
+	}
+	return ""
+}
+
+func (x *Booking) GetCheckIn() string {
+	if x != nil {
+		return x.CheckIn
+	}
+	return ""
+}
+
+func (x *Booking) GetCheckOut() string {
+	if x != nil {
+		return x.CheckOut
+	}
+	return ""
+}
+
+func (x *Booking) GetCheckInTime() string {
+	if x != nil {
+		return x.CheckInTime
+	}
+	return ""
+}
+
+func (x *Booking) GetCheckOutTime() string {
+	if x != nil {
+		return x.CheckOutTime
+	}
+	return ""
+}
+
+func (x *Booking) GetTimezone() string {
+	if x != nil {
+		return x.Timezone
+	}
+	return ""
+}
+
+func (x *Booking) GetNights() int32 {
+	if x != nil {
+		return x.Nights
+	}
+	return 0
+}
+
+func (x *Booking) GetSellingRate() float64 {
+	if x != nil {
+		return x.SellingRate
+	}
+	return 0
+}
+
+func (x *Booking) GetMargin() float64 {
+	if x != nil {
+		return x.Margin
+	}
+	return 0
+}
+
+type CalculateStatsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Bookings      []*Booking             `protobuf:"bytes,1,rep,name=bookings,proto3" json:"bookings,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CalculateStatsRequest) Reset() {
+	*x = CalculateStatsRequest{}
+	mi := &file_stats_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CalculateStatsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CalculateStatsRequest) ProtoMessage() {}
+
+func (x *CalculateStatsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_stats_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CalculateStatsRequest.ProtoReflect.Descriptor instead.
+func (*CalculateStatsRequest) Descriptor() ([]byte, []int) {
+	return file_stats_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *CalculateStatsRequest) GetBookings() []*Booking {
+	if x != nil {
+		return x.Bookings
+	}
+	return nil
+}
+
+// StatsResult holds the profit per night statistics of a list of bookings
+type StatsResult struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	AvgNight      float64                `protobuf:"fixed64,1,opt,name=avg_night,json=avgNight,proto3" json:"avg_night,omitempty"`
+	MinNight      float64                `protobuf:"fixed64,2,opt,name=min_night,json=minNight,proto3" json:"min_night,omitempty"`
+	MaxNight      float64                `protobuf:"fixed64,3,opt,name=max_night,json=maxNight,proto3" json:"max_night,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StatsResult) Reset() {
+	*x = StatsResult{}
+	mi := &file_stats_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StatsResult) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StatsResult) ProtoMessage() {}
+
+func (x *StatsResult) ProtoReflect() protoreflect.Message {
+	mi := &file_stats_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StatsResult.ProtoReflect.Descriptor instead.
+func (*StatsResult) Descriptor() ([]byte, []int) {
+	return file_stats_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *StatsResult) GetAvgNight() float64 {
+	if x != nil {
+		return x.AvgNight
+	}
+	return 0
+}
+
+func (x *StatsResult) GetMinNight() float64 {
+	if x != nil {
+		return x.MinNight
+	}
+	return 0
+}
+
+func (x *StatsResult) GetMaxNight() float64 {
+	if x != nil {
+		return x.MaxNight
+	}
+	return 0
+}
+
+// Weights holds the coefficients of the weighted objective
+type Weights struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Profit        float64                `protobuf:"fixed64,1,opt,name=profit,proto3" json:"profit,omitempty"`
+	Revenue       float64                `protobuf:"fixed64,2,opt,name=revenue,proto3" json:"revenue,omitempty"`
+	Occupancy     float64                `protobuf:"fixed64,3,opt,name=occupancy,proto3" json:"occupancy,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Weights) Reset() {
+	*x = Weights{}
+	mi := &file_stats_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Weights) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Weights) ProtoMessage() {}
+
+func (x *Weights) ProtoReflect() protoreflect.Message {
+	mi := &file_stats_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Weights.ProtoReflect.Descriptor instead.
+func (*Weights) Descriptor() ([]byte, []int) {
+	return file_stats_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *Weights) GetProfit() float64 {
+	if x != nil {
+		return x.Profit
+	}
+	return 0
+}
+
+func (x *Weights) GetRevenue() float64 {
+	if x != nil {
+		return x.Revenue
+	}
+	return 0
+}
+
+func (x *Weights) GetOccupancy() float64 {
+	if x != nil {
+		return x.Occupancy
+	}
+	return 0
+}
+
+// MaximizeOptions mirrors the query parameters of the /maximize endpoint
+type MaximizeOptions struct {
+	state     protoimpl.MessageState `protogen:"open.v1"`
+	Objective Objective              `protobuf:"varint,1,opt,name=objective,proto3,enum=stayforlong.v1.Objective" json:"objective,omitempty"`
+	Weights   *Weights               `protobuf:"bytes,2,opt,name=weights,proto3" json:"weights,omitempty"`
+	TieBreak  TieBreak               `protobuf:"varint,3,opt,name=tie_break,json=tieBreak,proto3,enum=stayforlong.v1.TieBreak" json:"tie_break,omitempty"`
+	// Providers preferred by TIE_BREAK_PREFERRED_PROVIDER, in priority order
+	PreferredProviders []string `protobuf:"bytes,4,rep,name=preferred_providers,json=preferredProviders,proto3" json:"preferred_providers,omitempty"`
+	// Compute the counter-offer rate of the rejected bookings
+	CounterOffers bool `protobuf:"varint,5,opt,name=counter_offers,json=counterOffers,proto3" json:"counter_offers,omitempty"`
+	Mode          Mode `protobuf:"varint,6,opt,name=mode,proto3,enum=stayforlong.v1.Mode" json:"mode,omitempty"`
+	// Time budget of the heuristic mode, in milliseconds
+	BudgetMs      int64 `protobuf:"varint,7,opt,name=budget_ms,json=budgetMs,proto3" json:"budget_ms,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *MaximizeOptions) Reset() {
+	*x = MaximizeOptions{}
+	mi := &file_stats_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MaximizeOptions) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MaximizeOptions) ProtoMessage() {}
+
+func (x *MaximizeOptions) ProtoReflect() protoreflect.Message {
+	mi := &file_stats_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MaximizeOptions.ProtoReflect.Descriptor instead.
+func (*MaximizeOptions) Descriptor() ([]byte, []int) {
+	return file_stats_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *MaximizeOptions) GetObjective() Objective {
+	if x != nil {
+		return x.Objective
+	}
+	return Objective_OBJECTIVE_UNSPECIFIED
+}
+
+func (x *MaximizeOptions) GetWeights() *Weights {
+	if x != nil {
+		return x.Weights
+	}
+	return nil
+}
+
+func (x *MaximizeOptions) GetTieBreak() TieBreak {
+	if x != nil {
+		return x.TieBreak
+	}
+	return TieBreak_TIE_BREAK_UNSPECIFIED
+}
+
+func (x *MaximizeOptions) GetPreferredProviders() []string {
+	if x != nil {
+		return x.PreferredProviders
+	}
+	return nil
+}
+
+func (x *MaximizeOptions) GetCounterOffers() bool {
+	if x != nil {
+		return x.CounterOffers
+	}
+	return false
+}
+
+func (x *MaximizeOptions) GetMode() Mode {
+	if x != nil {
+		return x.Mode
+	}
+	return Mode_MODE_UNSPECIFIED
+}
+
+func (x *MaximizeOptions) GetBudgetMs() int64 {
+	if x != nil {
+		return x.BudgetMs
+	}
+	return 0
+}
+
+type MaximizeProfitRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Options       *MaximizeOptions       `protobuf:"bytes,1,opt,name=options,proto3" json:"options,omitempty"`
+	Bookings      []*Booking             `protobuf:"bytes,2,rep,name=bookings,proto3" json:"bookings,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *MaximizeProfitRequest) Reset() {
+	*x = MaximizeProfitRequest{}
+	mi := &file_stats_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MaximizeProfitRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MaximizeProfitRequest) ProtoMessage() {}
+
+func (x *MaximizeProfitRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_stats_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MaximizeProfitRequest.ProtoReflect.Descriptor instead.
+func (*MaximizeProfitRequest) Descriptor() ([]byte, []int) {
+	return file_stats_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *MaximizeProfitRequest) GetOptions() *MaximizeOptions {
+	if x != nil {
+		return x.Options
+	}
+	return nil
+}
+
+func (x *MaximizeProfitRequest) GetBookings() []*Booking {
+	if x != nil {
+		return x.Bookings
+	}
+	return nil
+}
+
+// RejectedBooking is a booking left out of the selection
+type RejectedBooking struct {
+	state     protoimpl.MessageState `protogen:"open.v1"`
+	RequestId string                 `protobuf:"bytes,1,opt,name=request_id,json=requestId,proto3" json:"request_id,omitempty"`
+	// Minimum selling rate that would get it selected, only set when counter-offers were requested and one exists
+	CounterOfferRate float64 `protobuf:"fixed64,2,opt,name=counter_offer_rate,json=counterOfferRate,proto3" json:"counter_offer_rate,omitempty"`
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
+}
+
+func (x *RejectedBooking) Reset() {
+	*x = RejectedBooking{}
+	mi := &file_stats_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RejectedBooking) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RejectedBooking) ProtoMessage() {}
+
+func (x *RejectedBooking) ProtoReflect() protoreflect.Message {
+	mi := &file_stats_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RejectedBooking.ProtoReflect.Descriptor instead.
+func (*RejectedBooking) Descriptor() ([]byte, []int) {
+	return file_stats_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *RejectedBooking) GetRequestId() string {
+	if x != nil {
+		return x.RequestId
+	}
+	return ""
+}
+
+func (x *RejectedBooking) GetCounterOfferRate() float64 {
+	if x != nil {
+		return x.CounterOfferRate
+	}
+	return 0
+}
+
+// MaximizeResult holds the selection that maximizes the objective and its statistics
+type MaximizeResult struct {
+	state        protoimpl.MessageState `protogen:"open.v1"`
+	RequestIds   []string               `protobuf:"bytes,1,rep,name=request_ids,json=requestIds,proto3" json:"request_ids,omitempty"`
+	Objective    Objective              `protobuf:"varint,2,opt,name=objective,proto3,enum=stayforlong.v1.Objective" json:"objective,omitempty"`
+	Score        float64                `protobuf:"fixed64,3,opt,name=score,proto3" json:"score,omitempty"`
+	TotalProfit  float64                `protobuf:"fixed64,4,opt,name=total_profit,json=totalProfit,proto3" json:"total_profit,omitempty"`
+	TotalRevenue float64                `protobuf:"fixed64,5,opt,name=total_revenue,json=totalRevenue,proto3" json:"total_revenue,omitempty"`
+	TotalNights  int32                  `protobuf:"varint,6,opt,name=total_nights,json=totalNights,proto3" json:"total_nights,omitempty"`
+	AvgNight     float64                `protobuf:"fixed64,7,opt,name=avg_night,json=avgNight,proto3" json:"avg_night,omitempty"`
+	MinNight     float64                `protobuf:"fixed64,8,opt,name=min_night,json=minNight,proto3" json:"min_night,omitempty"`
+	MaxNight     float64                `protobuf:"fixed64,9,opt,name=max_night,json=maxNight,proto3" json:"max_night,omitempty"`
+	// Whether the selection is proven optimal
+	Optimal bool `protobuf:"varint,10,opt,name=optimal,proto3" json:"optimal,omitempty"`
+	// Score no selection can exceed
+	UpperBound float64 `protobuf:"fixed64,11,opt,name=upper_bound,json=upperBound,proto3" json:"upper_bound,omitempty"`
+	// Distance between the upper bound and the score
+	Gap           float64            `protobuf:"fixed64,12,opt,name=gap,proto3" json:"gap,omitempty"`
+	Rejected      []*RejectedBooking `protobuf:"bytes,13,rep,name=rejected,proto3" json:"rejected,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *MaximizeResult) Reset() {
+	*x = MaximizeResult{}
+	mi := &file_stats_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MaximizeResult) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MaximizeResult) ProtoMessage() {}
+
+func (x *MaximizeResult) ProtoReflect() protoreflect.Message {
+	mi := &file_stats_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MaximizeResult.ProtoReflect.Descriptor instead.
+func (*MaximizeResult) Descriptor() ([]byte, []int) {
+	return file_stats_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *MaximizeResult) GetRequestIds() []string {
+	if x != nil {
+		return x.RequestIds
+	}
+	return nil
+}
+
+func (x *MaximizeResult) GetObjective() Objective {
+	if x != nil {
+		return x.Objective
+	}
+	return Objective_OBJECTIVE_UNSPECIFIED
+}
+
+func (x *MaximizeResult) GetScore() float64 {
+	if x != nil {
+		return x.Score
+	}
+	return 0
+}
+
+func (x *MaximizeResult) GetTotalProfit() float64 {
+	if x != nil {
+		return x.TotalProfit
+	}
+	return 0
+}
+
+func (x *MaximizeResult) GetTotalRevenue() float64 {
+	if x != nil {
+		return x.TotalRevenue
+	}
+	return 0
+}
+
+func (x *MaximizeResult) GetTotalNights() int32 {
+	if x != nil {
+		return x.TotalNights
+	}
+	return 0
+}
+
+func (x *MaximizeResult) GetAvgNight() float64 {
+	if x != nil {
+		return x.AvgNight
+	}
+	return 0
+}
+
+func (x *MaximizeResult) GetMinNight() float64 {
+	if x != nil {
+		return x.MinNight
+	}
+	return 0
+}
+
+func (x *MaximizeResult) GetMaxNight() float64 {
+	if x != nil {
+		return x.MaxNight
+	}
+	return 0
+}
+
+func (x *MaximizeResult) GetOptimal() bool {
+	if x != nil {
+		return x.Optimal
+	}
+	return false
+}
+
+func (x *MaximizeResult) GetUpperBound() float64 {
+	if x != nil {
+		return x.UpperBound
+	}
+	return 0
+}
+
+func (x *MaximizeResult) GetGap() float64 {
+	if x != nil {
+		return x.Gap
+	}
+	return 0
+}
+
+func (x *MaximizeResult) GetRejected() []*RejectedBooking {
+	if x != nil {
+		return x.Rejected
+	}
+	return nil
+}
+
+var File_stats_proto protoreflect.FileDescriptor
+
+const file_stats_proto_rawDesc = "" +
+	"\n" +
+	"\vstats.proto\x12\x0estayforlong.v1\"\xba\x02\n" +
+	"\aBooking\x12\x1d\n" +
+	"\n" +
+	"request_id\x18\x01 \x01(\tR\trequestId\x12\x1f\n" +
+	"\vproperty_id\x18\x02 \x01(\tR\n" +
+	"propertyId\x12\x19\n" +
+	"\bcheck_in\x18\x03 \x01(\tR\acheckIn\x12\x1b\n" +
+	"\tcheck_out\x18\x04 \x01(\tR\bcheckOut\x12\"\n" +
+	"\rcheck_in_time\x18\x05 \x01(\tR\vcheckInTime\x12$\n" +
+	"\x0echeck_out_time\x18\x06 \x01(\tR\fcheckOutTime\x12\x1a\n" +
+	"\btimezone\x18\a \x01(\tR\btimezone\x12\x16\n" +
+	"\x06nights\x18\b \x01(\x05R\x06nights\x12!\n" +
+	"\fselling_rate\x18\t \x01(\x01R\vsellingRate\x12\x16\n" +
+	"\x06margin\x18\n" +
+	" \x01(\x01R\x06margin\"L\n" +
+	"\x15CalculateStatsRequest\x123\n" +
+	"\bbookings\x18\x01 \x03(\v2\x17.stayforlong.v1.BookingR\bbookings\"d\n" +
+	"\vStatsResult\x12\x1b\n" +
+	"\tavg_night\x18\x01 \x01(\x01R\bavgNight\x12\x1b\n" +
+	"\tmin_night\x18\x02 \x01(\x01R\bminNight\x12\x1b\n" +
+	"\tmax_night\x18\x03 \x01(\x01R\bmaxNight\"Y\n" +
+	"\aWeights\x12\x16\n" +
+	"\x06profit\x18\x01 \x01(\x01R\x06profit\x12\x18\n" +
+	"\arevenue\x18\x02 \x01(\x01R\arevenue\x12\x1c\n" +
+	"\toccupancy\x18\x03 \x01(\x01R\toccupancy\"\xd3\x02\n" +
+	"\x0fMaximizeOptions\x127\n" +
+	"\tobjective\x18\x01 \x01(\x0e2\x19.stayforlong.v1.ObjectiveR\tobjective\x121\n" +
+	"\aweights\x18\x02 \x01(\v2\x17.stayforlong.v1.WeightsR\aweights\x125\n" +
+	"\ttie_break\x18\x03 \x01(\x0e2\x18.stayforlong.v1.TieBreakR\btieBreak\x12/\n" +
+	"\x13preferred_providers\x18\x04 \x03(\tR\x12preferredProviders\x12%\n" +
+	"\x0ecounter_offers\x18\x05 \x01(\bR\rcounterOffers\x12(\n" +
+	"\x04mode\x18\x06 \x01(\x0e2\x14.stayforlong.v1.ModeR\x04mode\x12\x1b\n" +
+	"\tbudget_ms\x18\a \x01(\x03R\bbudgetMs\"\x87\x01\n" +
+	"\x15MaximizeProfitRequest\x129\n" +
+	"\aoptions\x18\x01 \x01(\v2\x1f.stayforlong.v1.MaximizeOptionsR\aoptions\x123\n" +
+	"\bbookings\x18\x02 \x03(\v2\x17.stayforlong.v1.BookingR\bbookings\"^\n" +
+	"\x0fRejectedBooking\x12\x1d\n" +
+	"\n" +
+	"request_id\x18\x01 \x01(\tR\trequestId\x12,\n" +
+	"\x12counter_offer_rate\x18\x02 \x01(\x01R\x10counterOfferRate\"\xcc\x03\n" +
+	"\x0eMaximizeResult\x12\x1f\n" +
+	"\vrequest_ids\x18\x01 \x03(\tR\n" +
+	"requestIds\x127\n" +
+	"\tobjective\x18\x02 \x01(\x0e2\x19.stayforlong.v1.ObjectiveR\tobjective\x12\x14\n" +
+	"\x05score\x18\x03 \x01(\x01R\x05score\x12!\n" +
+	"\ftotal_profit\x18\x04 \x01(\x01R\vtotalProfit\x12#\n" +
+	"\rtotal_revenue\x18\x05 \x01(\x01R\ftotalRevenue\x12!\n" +
+	"\ftotal_nights\x18\x06 \x01(\x05R\vtotalNights\x12\x1b\n" +
+	"\tavg_night\x18\a \x01(\x01R\bavgNight\x12\x1b\n" +
+	"\tmin_night\x18\b \x01(\x01R\bminNight\x12\x1b\n" +
+	"\tmax_night\x18\t \x01(\x01R\bmaxNight\x12\x18\n" +
+	"\aoptimal\x18\n" +
+	" \x01(\bR\aoptimal\x12\x1f\n" +
+	"\vupper_bound\x18\v \x01(\x01R\n" +
+	"upperBound\x12\x10\n" +
+	"\x03gap\x18\f \x01(\x01R\x03gap\x12;\n" +
+	"\brejected\x18\r \x03(\v2\x1f.stayforlong.v1.RejectedBookingR\brejected*\x84\x01\n" +
+	"\tObjective\x12\x19\n" +
+	"\x15OBJECTIVE_UNSPECIFIED\x10\x00\x12\x14\n" +
+	"\x10OBJECTIVE_PROFIT\x10\x01\x12\x15\n" +
+	"\x11OBJECTIVE_REVENUE\x10\x02\x12\x17\n" +
+	"\x13OBJECTIVE_OCCUPANCY\x10\x03\x12\x16\n" +
+	"\x12OBJECTIVE_WEIGHTED\x10\x04*\xa2\x01\n" +
+	"\bTieBreak\x12\x19\n" +
+	"\x15TIE_BREAK_UNSPECIFIED\x10\x00\x12\x19\n" +
+	"\x15TIE_BREAK_REQUEST_IDS\x10\x01\x12\x1d\n" +
+	"\x19TIE_BREAK_FEWEST_BOOKINGS\x10\x02\x12\x1f\n" +
+	"\x1bTIE_BREAK_EARLIEST_CHECK_IN\x10\x03\x12 \n" +
+	"\x1cTIE_BREAK_PREFERRED_PROVIDER\x10\x04*@\n" +
+	"\x04Mode\x12\x14\n" +
+	"\x10MODE_UNSPECIFIED\x10\x00\x12\x0e\n" +
+	"\n" +
+	"MODE_EXACT\x10\x01\x12\x12\n" +
+	"\x0eMODE_HEURISTIC\x10\x022\x9e\x02\n" +
+	"\fStatsService\x12T\n" +
+	"\x0eCalculateStats\x12%.stayforlong.v1.CalculateStatsRequest\x1a\x1b.stayforlong.v1.StatsResult\x12W\n" +
+	"\x0eMaximizeProfit\x12%.stayforlong.v1.MaximizeProfitRequest\x1a\x1e.stayforlong.v1.MaximizeResult\x12_\n" +
+	"\x14MaximizeProfitStream\x12%.stayforlong.v1.MaximizeProfitRequest\x1a\x1e.stayforlong.v1.MaximizeResult(\x01B9Z7github.com/duksonn/stay-for-long/internal/infra/grpc/pbb\x06proto3"
+
+var (
+	file_stats_proto_rawDescOnce sync.Once
+	file_stats_proto_rawDescData []byte
+)
+
+func file_stats_proto_rawDescGZIP() []byte {
+	file_stats_proto_rawDescOnce.Do(func() {
+		file_stats_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_stats_proto_rawDesc), len(file_stats_proto_rawDesc)))
+	})
+	return file_stats_proto_rawDescData
+}
+
+var file_stats_proto_enumTypes = make([]protoimpl.EnumInfo, 3)
+var file_stats_proto_msgTypes = make([]protoimpl.MessageInfo, 8)
+var file_stats_proto_goTypes = []any{
+	(Objective)(0),                // 0: stayforlong.v1.Objective
+	(TieBreak)(0),                 // 1: stayforlong.v1.TieBreak
+	(Mode)(0),                     // 2: stayforlong.v1.Mode
+	(*Booking)(nil),               // 3: stayforlong.v1.Booking
+	(*CalculateStatsRequest)(nil), // 4: stayforlong.v1.CalculateStatsRequest
+	(*StatsResult)(nil),           // 5: stayforlong.v1.StatsResult
+	(*Weights)(nil),               // 6: stayforlong.v1.Weights
+	(*MaximizeOptions)(nil),       // 7: stayforlong.v1.MaximizeOptions
+	(*MaximizeProfitRequest)(nil), // 8: stayforlong.v1.MaximizeProfitRequest
+	(*RejectedBooking)(nil),       // 9: stayforlong.v1.RejectedBooking
+	(*MaximizeResult)(nil),        // 10: stayforlong.v1.MaximizeResult
+}
+var file_stats_proto_depIdxs = []int32{
+	3,  // 0: stayforlong.v1.CalculateStatsRequest.bookings:type_name -> stayforlong.v1.Booking
+	0,  // 1: stayforlong.v1.MaximizeOptions.objective:type_name -> stayforlong.v1.Objective
+	6,  // 2: stayforlong.v1.MaximizeOptions.weights:type_name -> stayforlong.v1.Weights
+	1,  // 3: stayforlong.v1.MaximizeOptions.tie_break:type_name -> stayforlong.v1.TieBreak
+	2,  // 4: stayforlong.v1.MaximizeOptions.mode:type_name -> stayforlong.v1.Mode
+	7,  // 5: stayforlong.v1.MaximizeProfitRequest.options:type_name -> stayforlong.v1.MaximizeOptions
+	3,  // 6: stayforlong.v1.MaximizeProfitRequest.bookings:type_name -> stayforlong.v1.Booking
+	0,  // 7: stayforlong.v1.MaximizeResult.objective:type_name -> stayforlong.v1.Objective
+	9,  // 8: stayforlong.v1.MaximizeResult.rejected:type_name -> stayforlong.v1.RejectedBooking
+	4,  // 9: stayforlong.v1.StatsService.CalculateStats:input_type -> stayforlong.v1.CalculateStatsRequest
+	8,  // 10: stayforlong.v1.StatsService.MaximizeProfit:input_type -> stayforlong.v1.MaximizeProfitRequest
+	8,  // 11: stayforlong.v1.StatsService.MaximizeProfitStream:input_type -> stayforlong.v1.MaximizeProfitRequest
+	5,  // 12: stayforlong.v1.StatsService.CalculateStats:output_type -> stayforlong.v1.StatsResult
+	10, // 13: stayforlong.v1.StatsService.MaximizeProfit:output_type -> stayforlong.v1.MaximizeResult
+	10, // 14: stayforlong.v1.StatsService.MaximizeProfitStream:output_type -> stayforlong.v1.MaximizeResult
+	12, // [12:15] is the sub-list for method output_type
+	9,  // [9:12] is the sub-list for method input_type
+	9,  // [9:9] is the sub-list for extension type_name
+	9,  // [9:9] is the sub-list for extension extendee
+	0,  // [0:9] is the sub-list for field type_name
+}
+
+func init() { file_stats_proto_init() }
+func file_stats_proto_init() {
+	if File_stats_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_stats_proto_rawDesc), len(file_stats_proto_rawDesc)),
+			NumEnums:      3,
+			NumMessages:   8,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_stats_proto_goTypes,
+		DependencyIndexes: file_stats_proto_depIdxs,
+		EnumInfos:         file_stats_proto_enumTypes,
+		MessageInfos:      file_stats_proto_msgTypes,
+	}.Build()
+	File_stats_proto = out.File
+	file_stats_proto_goTypes = nil
+	file_stats_proto_depIdxs = nil
+}
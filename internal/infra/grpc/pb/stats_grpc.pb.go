@@ -0,0 +1,203 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             (unknown)
+// source: stats.proto
+
+package pb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	StatsService_CalculateStats_FullMethodName       = "/stayforlong.v1.StatsService/CalculateStats"
+	StatsService_MaximizeProfit_FullMethodName       = "/stayforlong.v1.StatsService/MaximizeProfit"
+	StatsService_MaximizeProfitStream_FullMethodName = "/stayforlong.v1.StatsService/MaximizeProfitStream"
+)
+
+// StatsServiceClient is the client API for StatsService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// StatsService computes booking statistics and the selection of non-overlapping bookings that maximizes profit
+type StatsServiceClient interface {
+	// CalculateStats computes the average, minimum and maximum profit per night of the bookings
+	CalculateStats(ctx context.Context, in *CalculateStatsRequest, opts ...grpc.CallOption) (*StatsResult, error)
+	// MaximizeProfit finds the non-overlapping bookings that maximize the objective
+	MaximizeProfit(ctx context.Context, in *MaximizeProfitRequest, opts ...grpc.CallOption) (*MaximizeResult, error)
+	// MaximizeProfitStream is MaximizeProfit for large uploads: the bookings are sent in chunks and the
+	// options are taken from the first message
+	MaximizeProfitStream(ctx context.Context, opts ...grpc.CallOption) (grpc.ClientStreamingClient[MaximizeProfitRequest, MaximizeResult], error)
+}
+
+type statsServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewStatsServiceClient(cc grpc.ClientConnInterface) StatsServiceClient {
+	return &statsServiceClient{cc}
+}
+
+func (c *statsServiceClient) CalculateStats(ctx context.Context, in *CalculateStatsRequest, opts ...grpc.CallOption) (*StatsResult, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(StatsResult)
+	err := c.cc.Invoke(ctx, StatsService_CalculateStats_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *statsServiceClient) MaximizeProfit(ctx context.Context, in *MaximizeProfitRequest, opts ...grpc.CallOption) (*MaximizeResult, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(MaximizeResult)
+	err := c.cc.Invoke(ctx, StatsService_MaximizeProfit_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *statsServiceClient) MaximizeProfitStream(ctx context.Context, opts ...grpc.CallOption) (grpc.ClientStreamingClient[MaximizeProfitRequest, MaximizeResult], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &StatsService_ServiceDesc.Streams[0], StatsService_MaximizeProfitStream_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[MaximizeProfitRequest, MaximizeResult]{ClientStream: stream}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type StatsService_MaximizeProfitStreamClient = grpc.ClientStreamingClient[MaximizeProfitRequest, MaximizeResult]
+
+// StatsServiceServer is the server API for StatsService service.
+// All implementations must embed UnimplementedStatsServiceServer
+// for forward compatibility.
+//
+// StatsService computes booking statistics and the selection of non-overlapping bookings that maximizes profit
+type StatsServiceServer interface {
+	// CalculateStats computes the average, minimum and maximum profit per night of the bookings
+	CalculateStats(context.Context, *CalculateStatsRequest) (*StatsResult, error)
+	// MaximizeProfit finds the non-overlapping bookings that maximize the objective
+	MaximizeProfit(context.Context, *MaximizeProfitRequest) (*MaximizeResult, error)
+	// MaximizeProfitStream is MaximizeProfit for large uploads: the bookings are sent in chunks and the
+	// options are taken from the first message
+	MaximizeProfitStream(grpc.ClientStreamingServer[MaximizeProfitRequest, MaximizeResult]) error
+	mustEmbedUnimplementedStatsServiceServer()
+}
+
+// UnimplementedStatsServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedStatsServiceServer struct{}
+
+func (UnimplementedStatsServiceServer) CalculateStats(context.Context, *CalculateStatsRequest) (*StatsResult, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CalculateStats not implemented")
+}
+func (UnimplementedStatsServiceServer) MaximizeProfit(context.Context, *MaximizeProfitRequest) (*MaximizeResult, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method MaximizeProfit not implemented")
+}
+func (UnimplementedStatsServiceServer) MaximizeProfitStream(grpc.ClientStreamingServer[MaximizeProfitRequest, MaximizeResult]) error {
+	return status.Errorf(codes.Unimplemented, "method MaximizeProfitStream not implemented")
+}
+func (UnimplementedStatsServiceServer) mustEmbedUnimplementedStatsServiceServer() {}
+func (UnimplementedStatsServiceServer) testEmbeddedByValue()                      {}
+
+// UnsafeStatsServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to StatsServiceServer will
+// result in compilation errors.
+type UnsafeStatsServiceServer interface {
+	mustEmbedUnimplementedStatsServiceServer()
+}
+
+func RegisterStatsServiceServer(s grpc.ServiceRegistrar, srv StatsServiceServer) {
+	// If the following call pancis, it indicates UnimplementedStatsServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&StatsService_ServiceDesc, srv)
+}
+
+func _StatsService_CalculateStats_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CalculateStatsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StatsServiceServer).CalculateStats(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: StatsService_CalculateStats_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StatsServiceServer).CalculateStats(ctx, req.(*CalculateStatsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _StatsService_MaximizeProfit_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(MaximizeProfitRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StatsServiceServer).MaximizeProfit(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: StatsService_MaximizeProfit_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StatsServiceServer).MaximizeProfit(ctx, req.(*MaximizeProfitRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _StatsService_MaximizeProfitStream_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(StatsServiceServer).MaximizeProfitStream(&grpc.GenericServerStream[MaximizeProfitRequest, MaximizeResult]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type StatsService_MaximizeProfitStreamServer = grpc.ClientStreamingServer[MaximizeProfitRequest, MaximizeResult]
+
+// StatsService_ServiceDesc is the grpc.ServiceDesc for StatsService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var StatsService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "stayforlong.v1.StatsService",
+	HandlerType: (*StatsServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "CalculateStats",
+			Handler:    _StatsService_CalculateStats_Handler,
+		},
+		{
+			MethodName: "MaximizeProfit",
+			Handler:    _StatsService_MaximizeProfit_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "MaximizeProfitStream",
+			Handler:       _StatsService_MaximizeProfitStream_Handler,
+			ClientStreams: true,
+		},
+	},
+	Metadata: "stats.proto",
+}
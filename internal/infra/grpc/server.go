@@ -0,0 +1,36 @@
+package grpc
+
+import (
+	"math"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/reflection"
+
+	"github.com/duksonn/stay-for-long/cmd/di"
+	"github.com/duksonn/stay-for-long/internal/infra/grpc/pb"
+	"github.com/duksonn/stay-for-long/internal/infra/limits"
+)
+
+// NewServer returns a gRPC server exposing the stats service of deps under the configured limits
+// MaxBodyBytes caps the size of every received message, the chunks of a stream included, and opts
+// are applied after it. Server reflection is registered so tools like grpcurl can discover the API
+func NewServer(deps *di.Dependencies, opts ...grpc.ServerOption) (*grpc.Server, error) {
+	statsServer, err := NewStatsServer(deps.StatsSvc, WithLimits(limits.Limits{
+		MaxBodyBytes:         deps.Config.MaxBodyBytes,
+		MaxBookings:          deps.Config.MaxBookings,
+		MaxHeuristicBookings: deps.Config.MaxHeuristicBookings,
+		MaxOptimizerTime:     deps.Config.MaxOptimizerTime,
+	}))
+	if err != nil {
+		return nil, err
+	}
+
+	if maxBytes := deps.Config.MaxBodyBytes; maxBytes > 0 {
+		opts = append([]grpc.ServerOption{grpc.MaxRecvMsgSize(int(min(maxBytes, math.MaxInt32)))}, opts...)
+	}
+	server := grpc.NewServer(opts...)
+	pb.RegisterStatsServiceServer(server, statsServer)
+	reflection.Register(server)
+
+	return server, nil
+}
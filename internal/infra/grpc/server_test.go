@@ -0,0 +1,66 @@
+package grpc_test
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	"github.com/duksonn/stay-for-long/cmd/config"
+	"github.com/duksonn/stay-for-long/cmd/di"
+	internalgrpc "github.com/duksonn/stay-for-long/internal/infra/grpc"
+	"github.com/duksonn/stay-for-long/internal/infra/grpc/pb"
+)
+
+func TestNewServer(t *testing.T) {
+	deps, err := di.Init(&config.Config{WriteTimeout: time.Second, JobWorkers: 1, JobQueueSize: 1})
+	require.NoError(t, err)
+	defer deps.JobSvc.Close()
+
+	server, err := internalgrpc.NewServer(deps)
+	require.NoError(t, err)
+
+	services := server.GetServiceInfo()
+	require.Contains(t, services, pb.StatsService_ServiceDesc.ServiceName)
+	assert.Contains(t, services, "grpc.reflection.v1.ServerReflection")
+}
+
+func TestNewServer_MaxBodyBytes(t *testing.T) {
+	deps, err := di.Init(&config.Config{WriteTimeout: time.Second, JobWorkers: 1, JobQueueSize: 1, MaxBodyBytes: 256})
+	require.NoError(t, err)
+	defer deps.JobSvc.Close()
+
+	server, err := internalgrpc.NewServer(deps)
+	require.NoError(t, err)
+	listener := bufconn.Listen(1 << 20)
+	go server.Serve(listener)
+	defer server.Stop()
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return listener.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+	defer conn.Close()
+	client := pb.NewStatsServiceClient(conn)
+
+	_, err = client.CalculateStats(context.Background(), &pb.CalculateStatsRequest{Bookings: []*pb.Booking{
+		{RequestId: "bookata_XY123", CheckIn: "2020-01-01", Nights: 5, SellingRate: 200, Margin: 20},
+	}})
+	require.NoError(t, err)
+
+	bookings := make([]*pb.Booking, 0, 10)
+	for range 10 {
+		bookings = append(bookings, &pb.Booking{RequestId: "bookata_XY123", CheckIn: "2020-01-01", Nights: 5, SellingRate: 200, Margin: 20})
+	}
+	_, err = client.CalculateStats(context.Background(), &pb.CalculateStatsRequest{Bookings: bookings})
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+}
@@ -0,0 +1,153 @@
+package grpc
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/duksonn/stay-for-long/internal/domain"
+	"github.com/duksonn/stay-for-long/internal/infra/dto"
+	"github.com/duksonn/stay-for-long/internal/infra/grpc/pb"
+)
+
+// objectives maps the protobuf objectives to the names parsed by domain.ParseObjective
+var objectives = map[pb.Objective]string{
+	pb.Objective_OBJECTIVE_UNSPECIFIED: "",
+	pb.Objective_OBJECTIVE_PROFIT:      string(domain.ObjectiveProfit),
+	pb.Objective_OBJECTIVE_REVENUE:     string(domain.ObjectiveRevenue),
+	pb.Objective_OBJECTIVE_OCCUPANCY:   string(domain.ObjectiveOccupancy),
+	pb.Objective_OBJECTIVE_WEIGHTED:    string(domain.ObjectiveWeighted),
+}
+
+// tieBreaks maps the protobuf tie-break policies to the names parsed by domain.ParseTieBreak
+var tieBreaks = map[pb.TieBreak]string{
+	pb.TieBreak_TIE_BREAK_UNSPECIFIED:        "",
+	pb.TieBreak_TIE_BREAK_REQUEST_IDS:        string(domain.TieBreakRequestIDs),
+	pb.TieBreak_TIE_BREAK_FEWEST_BOOKINGS:    string(domain.TieBreakFewestBookings),
+	pb.TieBreak_TIE_BREAK_EARLIEST_CHECK_IN:  string(domain.TieBreakEarliestCheckIn),
+	pb.TieBreak_TIE_BREAK_PREFERRED_PROVIDER: string(domain.TieBreakPreferredProvider),
+}
+
+// modes maps the protobuf solver modes to the names parsed by domain.ParseMode
+var modes = map[pb.Mode]string{
+	pb.Mode_MODE_UNSPECIFIED: "",
+	pb.Mode_MODE_EXACT:       string(domain.ModeExact),
+	pb.Mode_MODE_HEURISTIC:   string(domain.ModeHeuristic),
+}
+
+// parseBookings converts protobuf bookings to domain.Booking objects, with the same rules as the HTTP API
+// Errors name the position of the booking, counted from offset so chunks of a stream keep a global position
+func parseBookings(bookings []*pb.Booking, offset int) (domain.Bookings, error) {
+	requests := make(domain.Bookings, 0, len(bookings))
+	locations := dto.Locations{}
+	for i, b := range bookings {
+		booking, err := newBookingDTO(b).ToDomain(locations)
+		if err != nil {
+			return nil, fmt.Errorf("booking %d: %w", offset+i, err)
+		}
+		requests = append(requests, booking)
+	}
+
+	return requests, nil
+}
+
+// newBookingDTO maps a protobuf booking to the booking DTO shared with the HTTP API
+func newBookingDTO(b *pb.Booking) dto.Booking {
+	return dto.Booking{
+		RequestID:    b.GetRequestId(),
+		PropertyID:   b.GetPropertyId(),
+		CheckIn:      b.GetCheckIn(),
+		CheckOut:     b.GetCheckOut(),
+		CheckInTime:  b.GetCheckInTime(),
+		CheckOutTime: b.GetCheckOutTime(),
+		Timezone:     b.GetTimezone(),
+		Nights:       int(b.GetNights()),
+		SellingRate:  b.GetSellingRate(),
+		Margin:       b.GetMargin(),
+	}
+}
+
+// parseMaximizeOptions converts protobuf options to domain.MaximizeOptions and validates them
+// Unspecified enums take the same defaults as omitted query parameters of the HTTP API
+func parseMaximizeOptions(o *pb.MaximizeOptions) (domain.MaximizeOptions, error) {
+	objective, err := domain.ParseObjective(enumName(objectives, o.GetObjective()))
+	if err != nil {
+		return domain.MaximizeOptions{}, err
+	}
+	tieBreak, err := domain.ParseTieBreak(enumName(tieBreaks, o.GetTieBreak()))
+	if err != nil {
+		return domain.MaximizeOptions{}, err
+	}
+	mode, err := domain.ParseMode(enumName(modes, o.GetMode()))
+	if err != nil {
+		return domain.MaximizeOptions{}, err
+	}
+
+	opts := domain.MaximizeOptions{
+		Objective: objective,
+		Weights: domain.Weights{
+			Profit:    o.GetWeights().GetProfit(),
+			Revenue:   o.GetWeights().GetRevenue(),
+			Occupancy: o.GetWeights().GetOccupancy(),
+		},
+		TieBreak:           tieBreak,
+		PreferredProviders: o.GetPreferredProviders(),
+		CounterOffers:      o.GetCounterOffers(),
+		Mode:               mode,
+		Budget:             time.Duration(o.GetBudgetMs()) * time.Millisecond,
+	}
+	if err := opts.Validate(); err != nil {
+		return domain.MaximizeOptions{}, err
+	}
+
+	return opts, nil
+}
+
+// enumName returns the domain name of a protobuf enum value
+// Values missing from names, sent by newer clients, keep their number so parsing them fails
+func enumName[E ~int32](names map[E]string, value E) string {
+	if name, ok := names[value]; ok {
+		return name
+	}
+
+	return fmt.Sprint(int32(value))
+}
+
+// newStatsResult maps a domain.StatsResult to its protobuf representation
+func newStatsResult(stats *domain.StatsResult) *pb.StatsResult {
+	return &pb.StatsResult{
+		AvgNight: stats.AvgNight,
+		MinNight: stats.MinNight,
+		MaxNight: stats.MaxNight,
+	}
+}
+
+// newMaximizeResult maps a domain.MaximizeResult to its protobuf representation
+func newMaximizeResult(result *domain.MaximizeResult) *pb.MaximizeResult {
+	response := &pb.MaximizeResult{
+		RequestIds:   result.RequestIDs,
+		Score:        result.Score,
+		TotalProfit:  result.TotalProfit,
+		TotalRevenue: result.TotalRevenue,
+		TotalNights:  int32(result.TotalNights),
+		AvgNight:     result.AvgNight,
+		MinNight:     result.MinNight,
+		MaxNight:     result.MaxNight,
+		Optimal:      result.Optimal,
+		UpperBound:   result.UpperBound,
+		Gap:          result.Gap,
+		Rejected:     make([]*pb.RejectedBooking, 0, len(result.Rejected)),
+	}
+	for value, name := range objectives {
+		if name != "" && name == string(result.Objective) {
+			response.Objective = value
+		}
+	}
+	for _, r := range result.Rejected {
+		response.Rejected = append(response.Rejected, &pb.RejectedBooking{
+			RequestId:        r.Booking.RequestID,
+			CounterOfferRate: r.CounterOfferRate,
+		})
+	}
+
+	return response
+}
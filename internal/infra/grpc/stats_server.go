@@ -0,0 +1,158 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"io"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/duksonn/stay-for-long/internal/domain"
+	"github.com/duksonn/stay-for-long/internal/infra/grpc/pb"
+	"github.com/duksonn/stay-for-long/internal/infra/limits"
+	"github.com/duksonn/stay-for-long/internal/ports"
+)
+
+var (
+	// ErrNilStatsService is returned when the stats service is nil
+	ErrNilStatsService = errors.New("stats service cannot be nil")
+)
+
+// StatsServer serves the StatsService gRPC API
+// It exposes the same stats and profit maximization as the HTTP adapter, under the same limits
+type StatsServer struct {
+	pb.UnimplementedStatsServiceServer
+
+	statsService ports.StatsService
+	limits       limits.Limits
+}
+
+// NewStatsServer creates a new instance of StatsServer
+// Returns ErrNilStatsService if the stats service is nil
+func NewStatsServer(statsSvc ports.StatsService, opts ...Option) (*StatsServer, error) {
+	if statsSvc == nil {
+		return nil, ErrNilStatsService
+	}
+
+	o := newOptions(opts)
+	return &StatsServer{statsService: statsSvc, limits: o.limits}, nil
+}
+
+// CalculateStats computes the average, minimum and maximum profit per night of the bookings
+func (s *StatsServer) CalculateStats(ctx context.Context, req *pb.CalculateStatsRequest) (*pb.StatsResult, error) {
+	if err := s.limits.CheckBookings(len(req.GetBookings())); err != nil {
+		return nil, statusError(err)
+	}
+	requests, err := parseBookings(req.GetBookings(), 0)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	ctx, cancel := s.limits.OptimizerContext(ctx)
+	defer cancel()
+
+	stats, err := s.statsService.CalculateStats(ctx, requests)
+	if err != nil {
+		return nil, statusError(err)
+	}
+
+	return newStatsResult(stats), nil
+}
+
+// MaximizeProfit finds the non-overlapping bookings that maximize the objective of the options
+func (s *StatsServer) MaximizeProfit(ctx context.Context, req *pb.MaximizeProfitRequest) (*pb.MaximizeResult, error) {
+	opts, lim, err := s.maximizeOptions(req.GetOptions())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	if err := lim.CheckBookings(len(req.GetBookings())); err != nil {
+		return nil, statusError(err)
+	}
+	requests, err := parseBookings(req.GetBookings(), 0)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	return s.maximize(ctx, requests, opts)
+}
+
+// MaximizeProfitStream is MaximizeProfit for bookings uploaded in chunks, taking the options from the
+// first message. The booking limit is checked as chunks arrive, so an oversized upload fails early
+func (s *StatsServer) MaximizeProfitStream(stream pb.StatsService_MaximizeProfitStreamServer) error {
+	var requests domain.Bookings
+	var opts domain.MaximizeOptions
+	var lim limits.Limits
+	for first := true; ; first = false {
+		req, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		if first {
+			if opts, lim, err = s.maximizeOptions(req.GetOptions()); err != nil {
+				return status.Error(codes.InvalidArgument, err.Error())
+			}
+		}
+		if err := lim.CheckBookings(len(requests) + len(req.GetBookings())); err != nil {
+			return statusError(err)
+		}
+		chunk, err := parseBookings(req.GetBookings(), len(requests))
+		if err != nil {
+			return status.Error(codes.InvalidArgument, err.Error())
+		}
+		requests = append(requests, chunk...)
+	}
+
+	result, err := s.maximize(stream.Context(), requests, opts)
+	if err != nil {
+		return err
+	}
+
+	return stream.SendAndClose(result)
+}
+
+// maximizeOptions parses the options of a maximize request and returns the limits of its mode
+func (s *StatsServer) maximizeOptions(o *pb.MaximizeOptions) (domain.MaximizeOptions, limits.Limits, error) {
+	opts, err := parseMaximizeOptions(o)
+	if err == nil {
+		err = s.limits.CheckBudget(opts.Budget)
+	}
+	if err != nil {
+		return domain.MaximizeOptions{}, limits.Limits{}, err
+	}
+
+	return opts, s.limits.ForMode(opts.Mode), nil
+}
+
+// maximize runs MaximizeProfit under the optimizer time limit
+func (s *StatsServer) maximize(ctx context.Context, requests domain.Bookings, opts domain.MaximizeOptions) (*pb.MaximizeResult, error) {
+	ctx, cancel := s.limits.OptimizerContext(ctx)
+	defer cancel()
+
+	result, err := s.statsService.MaximizeProfit(ctx, requests, opts)
+	if err != nil {
+		return nil, statusError(err)
+	}
+
+	return newMaximizeResult(result), nil
+}
+
+// statusError maps an error of a limit or of the stats service to its gRPC status
+// Limits answer ResourceExhausted, the deadline and cancellation of the client keep their own codes
+// and any other error answers Internal
+func statusError(err error) error {
+	code := codes.Internal
+	switch {
+	case errors.Is(err, limits.ErrTooManyBookings), errors.Is(err, limits.ErrOptimizerTimeExceeded), errors.Is(err, domain.ErrExactSearchTooLarge):
+		code = codes.ResourceExhausted
+	case errors.Is(err, context.DeadlineExceeded):
+		code = codes.DeadlineExceeded
+	case errors.Is(err, context.Canceled):
+		code = codes.Canceled
+	}
+
+	return status.Error(code, err.Error())
+}
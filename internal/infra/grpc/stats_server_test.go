@@ -0,0 +1,363 @@
+package grpc_test
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	"github.com/duksonn/stay-for-long/internal/domain"
+	internalgrpc "github.com/duksonn/stay-for-long/internal/infra/grpc"
+	"github.com/duksonn/stay-for-long/internal/infra/grpc/pb"
+	"github.com/duksonn/stay-for-long/internal/infra/limits"
+	"github.com/duksonn/stay-for-long/internal/mocks"
+	"github.com/duksonn/stay-for-long/internal/ports"
+)
+
+// newClient serves statsSvc over an in-memory connection and returns a client of it
+func newClient(t *testing.T, statsSvc ports.StatsService, l limits.Limits) pb.StatsServiceClient {
+	t.Helper()
+
+	statsServer, err := internalgrpc.NewStatsServer(statsSvc, internalgrpc.WithLimits(l))
+	require.NoError(t, err)
+
+	listener := bufconn.Listen(1 << 20)
+	server := grpc.NewServer()
+	pb.RegisterStatsServiceServer(server, statsServer)
+	go server.Serve(listener)
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return listener.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+
+	return pb.NewStatsServiceClient(conn)
+}
+
+func booking(id string, checkIn string, nights int32) *pb.Booking {
+	return &pb.Booking{RequestId: id, CheckIn: checkIn, Nights: nights, SellingRate: 200, Margin: 20}
+}
+
+func TestNewStatsServer(t *testing.T) {
+	tests := []struct {
+		name     string
+		statsSvc ports.StatsService
+		wantErr  error
+	}{
+		{
+			name:     "successful creation",
+			statsSvc: mocks.NewMockStatsService(gomock.NewController(t)),
+		},
+		{
+			name:     "nil service",
+			statsSvc: nil,
+			wantErr:  internalgrpc.ErrNilStatsService,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := internalgrpc.NewStatsServer(tt.statsSvc)
+			if tt.wantErr != nil {
+				assert.Equal(t, tt.wantErr, err)
+				assert.Nil(t, s)
+			} else {
+				assert.NoError(t, err)
+				assert.NotNil(t, s)
+			}
+		})
+	}
+}
+
+func TestStatsServer_CalculateStats(t *testing.T) {
+	tests := []struct {
+		name     string
+		bookings []*pb.Booking
+		mock     func(*mocks.MockStatsService)
+		want     *pb.StatsResult
+		wantCode codes.Code
+	}{
+		{
+			name:     "successful calculation",
+			bookings: []*pb.Booking{booking("bookata_XY123", "2020-01-01", 5)},
+			mock: func(m *mocks.MockStatsService) {
+				m.EXPECT().CalculateStats(gomock.Any(), gomock.Len(1)).
+					DoAndReturn(func(_ context.Context, requests domain.Bookings) (*domain.StatsResult, error) {
+						assert.Equal(t, "bookata_XY123", requests[0].RequestID)
+						assert.Equal(t, time.Date(2020, 1, 6, 0, 0, 0, 0, time.UTC), requests[0].CheckOut)
+						return &domain.StatsResult{AvgNight: 8, MinNight: 8, MaxNight: 8}, nil
+					})
+			},
+			want:     &pb.StatsResult{AvgNight: 8, MinNight: 8, MaxNight: 8},
+			wantCode: codes.OK,
+		},
+		{
+			name:     "invalid date",
+			bookings: []*pb.Booking{booking("bookata_XY123", "2020-01-01", 5), booking("kayete_PP234", "01/01/2020", 4)},
+			mock:     func(m *mocks.MockStatsService) {},
+			wantCode: codes.InvalidArgument,
+		},
+		{
+			name: "too many bookings",
+			bookings: []*pb.Booking{
+				booking("bookata_XY123", "2020-01-01", 5),
+				booking("kayete_PP234", "2020-01-04", 4),
+				booking("trivoltio_ZX69", "2020-01-07", 3),
+			},
+			mock:     func(m *mocks.MockStatsService) {},
+			wantCode: codes.ResourceExhausted,
+		},
+		{
+			name:     "service error",
+			bookings: []*pb.Booking{booking("bookata_XY123", "2020-01-01", 5)},
+			mock: func(m *mocks.MockStatsService) {
+				m.EXPECT().CalculateStats(gomock.Any(), gomock.Any()).Return(nil, assert.AnError)
+			},
+			wantCode: codes.Internal,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockSvc := mocks.NewMockStatsService(gomock.NewController(t))
+			tt.mock(mockSvc)
+			client := newClient(t, mockSvc, limits.Limits{MaxBookings: 2})
+
+			got, err := client.CalculateStats(context.Background(), &pb.CalculateStatsRequest{Bookings: tt.bookings})
+			assert.Equal(t, tt.wantCode, status.Code(err))
+			if tt.want != nil {
+				assert.Equal(t, tt.want.GetAvgNight(), got.GetAvgNight())
+				assert.Equal(t, tt.want.GetMinNight(), got.GetMinNight())
+				assert.Equal(t, tt.want.GetMaxNight(), got.GetMaxNight())
+			}
+		})
+	}
+}
+
+func TestStatsServer_MaximizeProfit(t *testing.T) {
+	first := &domain.Booking{RequestID: "bookata_XY123"}
+	second := &domain.Booking{RequestID: "kayete_PP234"}
+	result := &domain.MaximizeResult{
+		RequestIDs:  []string{"bookata_XY123"},
+		Objective:   domain.ObjectiveRevenue,
+		Score:       200,
+		TotalProfit: 40,
+		TotalNights: 5,
+		Optimal:     true,
+		Selected:    []*domain.Booking{first},
+		Rejected:    []*domain.RejectedBooking{{Booking: second, CounterOfferRate: 260}},
+	}
+
+	tests := []struct {
+		name     string
+		request  *pb.MaximizeProfitRequest
+		mock     func(*mocks.MockStatsService)
+		wantCode codes.Code
+	}{
+		{
+			name: "successful maximization",
+			request: &pb.MaximizeProfitRequest{
+				Options: &pb.MaximizeOptions{
+					Objective:          pb.Objective_OBJECTIVE_REVENUE,
+					TieBreak:           pb.TieBreak_TIE_BREAK_PREFERRED_PROVIDER,
+					PreferredProviders: []string{"kayete"},
+					CounterOffers:      true,
+				},
+				Bookings: []*pb.Booking{booking("bookata_XY123", "2020-01-01", 5), booking("kayete_PP234", "2020-01-04", 4)},
+			},
+			mock: func(m *mocks.MockStatsService) {
+				m.EXPECT().MaximizeProfit(gomock.Any(), gomock.Len(2), domain.MaximizeOptions{
+					Objective:          domain.ObjectiveRevenue,
+					TieBreak:           domain.TieBreakPreferredProvider,
+					PreferredProviders: []string{"kayete"},
+					CounterOffers:      true,
+					Mode:               domain.ModeExact,
+				}).Return(result, nil)
+			},
+			wantCode: codes.OK,
+		},
+		{
+			name:    "default options",
+			request: &pb.MaximizeProfitRequest{Bookings: []*pb.Booking{booking("bookata_XY123", "2020-01-01", 5)}},
+			mock: func(m *mocks.MockStatsService) {
+				m.EXPECT().MaximizeProfit(gomock.Any(), gomock.Len(1), gomock.Any()).Return(result, nil)
+			},
+			wantCode: codes.OK,
+		},
+		{
+			name: "weighted objective without weights",
+			request: &pb.MaximizeProfitRequest{
+				Options:  &pb.MaximizeOptions{Objective: pb.Objective_OBJECTIVE_WEIGHTED},
+				Bookings: []*pb.Booking{booking("bookata_XY123", "2020-01-01", 5)},
+			},
+			mock:     func(m *mocks.MockStatsService) {},
+			wantCode: codes.InvalidArgument,
+		},
+		{
+			name: "unknown objective",
+			request: &pb.MaximizeProfitRequest{
+				Options:  &pb.MaximizeOptions{Objective: pb.Objective(42)},
+				Bookings: []*pb.Booking{booking("bookata_XY123", "2020-01-01", 5)},
+			},
+			mock:     func(m *mocks.MockStatsService) {},
+			wantCode: codes.InvalidArgument,
+		},
+		{
+			name: "budget over the optimizer time",
+			request: &pb.MaximizeProfitRequest{
+				Options:  &pb.MaximizeOptions{Mode: pb.Mode_MODE_HEURISTIC, BudgetMs: 2000},
+				Bookings: []*pb.Booking{booking("bookata_XY123", "2020-01-01", 5)},
+			},
+			mock:     func(m *mocks.MockStatsService) {},
+			wantCode: codes.InvalidArgument,
+		},
+		{
+			name: "heuristic mode raises the booking limit",
+			request: &pb.MaximizeProfitRequest{
+				Options: &pb.MaximizeOptions{Mode: pb.Mode_MODE_HEURISTIC},
+				Bookings: []*pb.Booking{
+					booking("bookata_XY123", "2020-01-01", 5),
+					booking("kayete_PP234", "2020-01-04", 4),
+					booking("trivoltio_ZX69", "2020-01-07", 3),
+				},
+			},
+			mock: func(m *mocks.MockStatsService) {
+				m.EXPECT().MaximizeProfit(gomock.Any(), gomock.Len(3), gomock.Any()).Return(result, nil)
+			},
+			wantCode: codes.OK,
+		},
+		{
+			name: "too many bookings",
+			request: &pb.MaximizeProfitRequest{Bookings: []*pb.Booking{
+				booking("bookata_XY123", "2020-01-01", 5),
+				booking("kayete_PP234", "2020-01-04", 4),
+				booking("trivoltio_ZX69", "2020-01-07", 3),
+			}},
+			mock:     func(m *mocks.MockStatsService) {},
+			wantCode: codes.ResourceExhausted,
+		},
+		{
+			name:    "optimizer time exceeded",
+			request: &pb.MaximizeProfitRequest{Bookings: []*pb.Booking{booking("bookata_XY123", "2020-01-01", 5)}},
+			mock: func(m *mocks.MockStatsService) {
+				m.EXPECT().MaximizeProfit(gomock.Any(), gomock.Any(), gomock.Any()).
+					DoAndReturn(func(ctx context.Context, _ domain.Bookings, _ domain.MaximizeOptions) (*domain.MaximizeResult, error) {
+						<-ctx.Done()
+						return nil, context.Cause(ctx)
+					})
+			},
+			wantCode: codes.ResourceExhausted,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockSvc := mocks.NewMockStatsService(gomock.NewController(t))
+			tt.mock(mockSvc)
+			client := newClient(t, mockSvc, limits.Limits{
+				MaxBookings:          2,
+				MaxHeuristicBookings: 3,
+				MaxOptimizerTime:     time.Second,
+			})
+
+			got, err := client.MaximizeProfit(context.Background(), tt.request)
+			require.Equal(t, tt.wantCode, status.Code(err), err)
+			if tt.wantCode != codes.OK {
+				return
+			}
+			assert.Equal(t, []string{"bookata_XY123"}, got.GetRequestIds())
+			assert.Equal(t, pb.Objective_OBJECTIVE_REVENUE, got.GetObjective())
+			assert.Equal(t, 200.0, got.GetScore())
+			assert.Equal(t, int32(5), got.GetTotalNights())
+			assert.True(t, got.GetOptimal())
+			require.Len(t, got.GetRejected(), 1)
+			assert.Equal(t, "kayete_PP234", got.GetRejected()[0].GetRequestId())
+			assert.Equal(t, 260.0, got.GetRejected()[0].GetCounterOfferRate())
+		})
+	}
+}
+
+func TestStatsServer_MaximizeProfitStream(t *testing.T) {
+	tests := []struct {
+		name     string
+		chunks   []*pb.MaximizeProfitRequest
+		mock     func(*mocks.MockStatsService)
+		wantCode codes.Code
+	}{
+		{
+			name: "bookings gathered from every chunk with the options of the first",
+			chunks: []*pb.MaximizeProfitRequest{
+				{
+					Options:  &pb.MaximizeOptions{Objective: pb.Objective_OBJECTIVE_OCCUPANCY},
+					Bookings: []*pb.Booking{booking("bookata_XY123", "2020-01-01", 5)},
+				},
+				{
+					Options:  &pb.MaximizeOptions{Objective: pb.Objective_OBJECTIVE_REVENUE},
+					Bookings: []*pb.Booking{booking("kayete_PP234", "2020-01-04", 4)},
+				},
+			},
+			mock: func(m *mocks.MockStatsService) {
+				m.EXPECT().MaximizeProfit(gomock.Any(), gomock.Len(2), gomock.Any()).
+					DoAndReturn(func(_ context.Context, requests domain.Bookings, opts domain.MaximizeOptions) (*domain.MaximizeResult, error) {
+						assert.Equal(t, domain.ObjectiveOccupancy, opts.Objective)
+						assert.Equal(t, "kayete_PP234", requests[1].RequestID)
+						return &domain.MaximizeResult{RequestIDs: []string{"bookata_XY123"}, Objective: opts.Objective}, nil
+					})
+			},
+			wantCode: codes.OK,
+		},
+		{
+			name: "invalid booking in a later chunk",
+			chunks: []*pb.MaximizeProfitRequest{
+				{Bookings: []*pb.Booking{booking("bookata_XY123", "2020-01-01", 5)}},
+				{Bookings: []*pb.Booking{{RequestId: "kayete_PP234", CheckIn: "2020-01-04", Timezone: "Mars/Olympus"}}},
+			},
+			mock:     func(m *mocks.MockStatsService) {},
+			wantCode: codes.InvalidArgument,
+		},
+		{
+			name: "too many bookings across chunks",
+			chunks: []*pb.MaximizeProfitRequest{
+				{Bookings: []*pb.Booking{booking("bookata_XY123", "2020-01-01", 5), booking("kayete_PP234", "2020-01-04", 4)}},
+				{Bookings: []*pb.Booking{booking("trivoltio_ZX69", "2020-01-07", 3)}},
+			},
+			mock:     func(m *mocks.MockStatsService) {},
+			wantCode: codes.ResourceExhausted,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockSvc := mocks.NewMockStatsService(gomock.NewController(t))
+			tt.mock(mockSvc)
+			client := newClient(t, mockSvc, limits.Limits{MaxBookings: 2})
+
+			stream, err := client.MaximizeProfitStream(context.Background())
+			require.NoError(t, err)
+			for _, chunk := range tt.chunks {
+				// The server may reject the upload before the last chunk, which is then reported by CloseAndRecv
+				if err := stream.Send(chunk); err != nil {
+					break
+				}
+			}
+			got, err := stream.CloseAndRecv()
+			require.Equal(t, tt.wantCode, status.Code(err), err)
+			if tt.wantCode == codes.OK {
+				assert.Equal(t, []string{"bookata_XY123"}, got.GetRequestIds())
+				assert.Equal(t, pb.Objective_OBJECTIVE_OCCUPANCY, got.GetObjective())
+			}
+		})
+	}
+}
@@ -0,0 +1,92 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/duksonn/stay-for-long/internal/domain"
+	"github.com/duksonn/stay-for-long/internal/infra/dto"
+	"github.com/duksonn/stay-for-long/internal/infra/limits"
+)
+
+// streamedContentTypes lists the content types decoded as a stream
+// Any other content type is decoded as a JSON array
+var streamedContentTypes = map[string]bool{dto.ContentTypeCSV: true, dto.ContentTypeNDJSON: true, dto.ContentTypeCalendar: true}
+
+// codeInvalidInput is the error code reported when a line of a CSV, NDJSON or iCalendar body cannot be decoded
+const codeInvalidInput = "invalid_input"
+
+// LineError reports the line of a CSV, NDJSON or iCalendar body where a booking could not be decoded
+type LineError = dto.LineError
+
+// decodeBody reads the bookings and options of a request body in the given media type
+// The bookings are a bare list in any of the supported formats or, from version V2 on, a JSON envelope holding
+// them with their options. Options are read from the query string unless the envelope holds some, and limitsFor
+// returns the limits enforced for them; a nil limitsFor leaves the options unread and enforces l
+func decodeBody(w http.ResponseWriter, r *http.Request, mediaType string, version APIVersion, l limits.Limits,
+	columns dto.CSVColumns, limitsFor func(domain.MaximizeOptions) (limits.Limits, error)) ([]*domain.Booking, domain.MaximizeOptions, error) {
+	var opts domain.MaximizeOptions
+	lim := l
+	if limitsFor != nil {
+		var err error
+		if opts, err = parseMaximizeOptions(r); err != nil {
+			return nil, domain.MaximizeOptions{}, err
+		}
+		if lim, err = limitsFor(opts); err != nil {
+			return nil, domain.MaximizeOptions{}, err
+		}
+	}
+
+	if streamedContentTypes[mediaType] {
+		requests, err := decodeStreamedBookings(w, r, mediaType, lim, columns)
+		if err != nil {
+			return nil, domain.MaximizeOptions{}, err
+		}
+		return requests, opts, nil
+	}
+
+	body, err := readBody(w, r, l.MaxBodyBytes)
+	if err != nil {
+		return nil, domain.MaximizeOptions{}, err
+	}
+	var envelope bookingsEnvelope
+	if !isJSONObject(body) || version < V2 {
+		err = json.Unmarshal(body, &envelope.Bookings)
+	} else {
+		err = json.Unmarshal(body, &envelope)
+	}
+	if err != nil {
+		return nil, domain.MaximizeOptions{}, ErrInvalidJSON
+	}
+	if envelope.Options != nil && limitsFor != nil {
+		if opts, err = parseOptionsRequest(*envelope.Options); err != nil {
+			return nil, domain.MaximizeOptions{}, err
+		}
+		if lim, err = limitsFor(opts); err != nil {
+			return nil, domain.MaximizeOptions{}, err
+		}
+	}
+	if err := lim.CheckBookings(len(envelope.Bookings)); err != nil {
+		return nil, domain.MaximizeOptions{}, err
+	}
+	requests, err := dto.BookingsToDomain(envelope.Bookings)
+	if err != nil {
+		return nil, domain.MaximizeOptions{}, err
+	}
+
+	return requests, opts, nil
+}
+
+// decodeStreamedBookings reads a CSV, NDJSON or iCalendar request body as a list of bookings and converts it to
+// domain.Booking objects, enforcing the body size and booking count limits. The body is decoded as a stream, so the
+// limits stop the decoding as soon as they are exceeded and errors are reported with their line number
+func decodeStreamedBookings(w http.ResponseWriter, r *http.Request, mediaType string, l limits.Limits,
+	columns dto.CSVColumns) ([]*domain.Booking, error) {
+	bookings, err := dto.DecodeBookings(bodyReader(w, r, l.MaxBodyBytes), mediaType, l, columns)
+	if errors.Is(err, dto.ErrReadBookings) {
+		return nil, bodyReadError(err)
+	}
+
+	return bookings, err
+}
@@ -0,0 +1,199 @@
+package handler_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"github.com/duksonn/stay-for-long/internal/domain"
+	"github.com/duksonn/stay-for-long/internal/infra/http/handler"
+	"github.com/duksonn/stay-for-long/internal/infra/limits"
+	"github.com/duksonn/stay-for-long/internal/mocks"
+)
+
+func TestStatsHandler_InputFormats(t *testing.T) {
+	checkIn := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	expected := domain.Bookings{
+		{RequestID: "bookata_XY123", CheckIn: checkIn, CheckOut: checkIn.AddDate(0, 0, 5), Nights: 5, SellingRate: 200, Margin: 20},
+		{RequestID: "acme_AAAAA", CheckIn: checkIn.AddDate(0, 0, 9), CheckOut: checkIn.AddDate(0, 0, 13), Nights: 4, SellingRate: 160, Margin: 30},
+	}
+	aliases := map[string]string{"Booking ID": "request_id", "Arrival": "check_in"}
+	limits := limits.Limits{MaxBodyBytes: 512, MaxBookings: 2}
+
+	tests := []struct {
+		name           string
+		contentType    string
+		requestBody    string
+		expected       domain.Bookings
+		expectedStatus int
+		expectedCode   string
+		expectedError  string
+	}{
+		{
+			name:        "csv",
+			contentType: "text/csv; charset=utf-8",
+			requestBody: "request_id,check_in,nights,selling_rate,margin,notes\n" +
+				"bookata_XY123,2020-01-01,5,200,20,vip\n" +
+				"acme_AAAAA,2020-01-10,4,160,30,\n",
+			expected:       expected,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:        "csv with header aliases",
+			contentType: "text/csv",
+			requestBody: " booking id ,ARRIVAL,check_out,selling_rate,margin\n" +
+				"bookata_XY123,2020-01-01,2020-01-06,200,20\n" +
+				"acme_AAAAA,2020-01-10,2020-01-14,160,30",
+			expected:       expected,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:        "csv with property",
+			contentType: "text/csv",
+			requestBody: "request_id,property_id,check_in,nights,selling_rate,margin\n" +
+				"bookata_XY123,madrid,2020-01-01,5,200,20\n",
+			expected: domain.Bookings{
+				{RequestID: "bookata_XY123", PropertyID: "madrid", CheckIn: checkIn, CheckOut: checkIn.AddDate(0, 0, 5), Nights: 5, SellingRate: 200, Margin: 20},
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "empty csv",
+			contentType:    "text/csv",
+			requestBody:    "",
+			expected:       domain.Bookings{},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "csv without request_id column",
+			contentType:    "text/csv",
+			requestBody:    "check_in,nights\n2020-01-01,5\n",
+			expectedStatus: http.StatusBadRequest,
+			expectedCode:   "invalid_input",
+			expectedError:  "line 1: invalid csv headers: missing request_id column",
+		},
+		{
+			name:           "csv with invalid number",
+			contentType:    "text/csv",
+			requestBody:    "request_id,check_in,nights\nbookata_XY123,2020-01-01,5\nacme_AAAAA,2020-01-10,four\n",
+			expectedStatus: http.StatusBadRequest,
+			expectedCode:   "invalid_input",
+			expectedError:  `line 3: invalid number format: column "nights"`,
+		},
+		{
+			name:           "csv with invalid date",
+			contentType:    "text/csv",
+			requestBody:    "request_id,check_in,nights\nbookata_XY123,01/01/2020,5\n",
+			expectedStatus: http.StatusBadRequest,
+			expectedCode:   "invalid_input",
+			expectedError:  "line 2: invalid date format",
+		},
+		{
+			name:           "csv with missing field",
+			contentType:    "text/csv",
+			requestBody:    "request_id,check_in,nights\nbookata_XY123,2020-01-01\n",
+			expectedStatus: http.StatusBadRequest,
+			expectedCode:   "invalid_input",
+			expectedError:  "line 2: invalid request csv: wrong number of fields",
+		},
+		{
+			name:           "csv with too many bookings",
+			contentType:    "text/csv",
+			requestBody:    "request_id,check_in,nights\n" + strings.Repeat("bookata_XY123,2020-01-01,5\n", 3),
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedCode:   "too_many_bookings",
+		},
+		{
+			name:           "csv body too large",
+			contentType:    "text/csv",
+			requestBody:    "request_id,check_in,nights\n" + strings.Repeat("bookata_XY123,2020-01-01,5,", 20) + "\n",
+			expectedStatus: http.StatusRequestEntityTooLarge,
+			expectedCode:   "body_too_large",
+		},
+		{
+			name:        "ndjson",
+			contentType: "application/x-ndjson",
+			requestBody: `{"request_id":"bookata_XY123","check_in":"2020-01-01","nights":5,"selling_rate":200,"margin":20}` + "\n\n" +
+				`{"request_id":"acme_AAAAA","check_in":"2020-01-10","nights":4,"selling_rate":160,"margin":30}`,
+			expected:       expected,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:        "ndjson with invalid line",
+			contentType: "application/x-ndjson",
+			requestBody: `{"request_id":"bookata_XY123","check_in":"2020-01-01","nights":5}` + "\n" +
+				`{"request_id":"acme_AAAAA","nights":"four"}` + "\n",
+			expectedStatus: http.StatusBadRequest,
+			expectedCode:   "invalid_input",
+			expectedError:  "line 2: invalid request json",
+		},
+		{
+			name:           "ndjson with invalid timezone",
+			contentType:    "application/x-ndjson",
+			requestBody:    `{"request_id":"bookata_XY123","check_in":"2020-01-01","nights":5,"timezone":"Mars/Base"}`,
+			expectedStatus: http.StatusBadRequest,
+			expectedCode:   "invalid_input",
+			expectedError:  "line 1: invalid timezone",
+		},
+		{
+			name:           "ndjson with too many bookings",
+			contentType:    "application/x-ndjson",
+			requestBody:    strings.Repeat(`{"request_id":"bookata_XY123","check_in":"2020-01-01","nights":5}`+"\n", 3),
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedCode:   "too_many_bookings",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockStatsService := mocks.NewMockStatsService(ctrl)
+			h, err := handler.NewStatsHandler(mockStatsService, handler.WithLimits(limits), handler.WithCSVHeaders(aliases))
+			require.NoError(t, err)
+
+			if tt.expected != nil {
+				mockStatsService.EXPECT().
+					CalculateStats(gomock.Any(), gomock.Any()).
+					DoAndReturn(func(_ context.Context, bookings domain.Bookings) (*domain.StatsResult, error) {
+						assert.Equal(t, tt.expected, bookings)
+						return &domain.StatsResult{}, nil
+					})
+			}
+
+			req := httptest.NewRequest(http.MethodPost, "/stats", bytes.NewBufferString(tt.requestBody))
+			req.Header.Set("Content-Type", tt.contentType)
+			w := httptest.NewRecorder()
+
+			h.HandlerCalculateStats(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.expectedCode != "" {
+				var response map[string]interface{}
+				require.NoError(t, json.NewDecoder(w.Body).Decode(&response))
+				assert.Equal(t, tt.expectedCode, response["code"])
+				if tt.expectedError != "" {
+					assert.Equal(t, tt.expectedError, response["error"])
+				}
+			}
+		})
+	}
+}
+
+func TestNewStatsHandler_InvalidCSVHeaders(t *testing.T) {
+	mockStatsService := mocks.NewMockStatsService(gomock.NewController(t))
+
+	h, err := handler.NewStatsHandler(mockStatsService, handler.WithCSVHeaders(map[string]string{"Arrival": "arrival"}))
+	assert.ErrorIs(t, err, handler.ErrInvalidCSVHeaders)
+	assert.Nil(t, h)
+}
@@ -0,0 +1,283 @@
+package handler_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"github.com/duksonn/stay-for-long/internal/domain"
+	"github.com/duksonn/stay-for-long/internal/infra/http/handler"
+	"github.com/duksonn/stay-for-long/internal/mocks"
+)
+
+// calendar joins content lines with the CRLF line breaks used by iCalendar
+func calendar(lines ...string) string {
+	return strings.Join(lines, "\r\n") + "\r\n"
+}
+
+func TestStatsHandler_CalendarInput(t *testing.T) {
+	madrid, err := time.LoadLocation("Europe/Madrid")
+	require.NoError(t, err)
+	checkIn := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name           string
+		requestBody    string
+		expected       domain.Bookings
+		expectedStatus int
+		expectedError  string
+	}{
+		{
+			name: "all-day and timed events",
+			requestBody: calendar(
+				"BEGIN:VCALENDAR",
+				"VERSION:2.0",
+				"BEGIN:VEVENT",
+				"UID:event-1@example.com",
+				"X-REQUEST-ID:bookata_XY123",
+				"DTSTART;VALUE=DATE:20200101",
+				"DTEND;VALUE=DATE:20200106",
+				"X-SELLING-RATE:200",
+				"X-MARGIN:20",
+				"BEGIN:VALARM",
+				"DTSTART:20191231T090000Z",
+				"END:VALARM",
+				"END:VEVENT",
+				"BEGIN:VEVENT",
+				`UID:acme_AAAAA`,
+				`DTSTART;TZID="Europe/Madrid":20200110T150000`,
+				"DTEND:20200114T100000Z",
+				"X-SELLING-RATE:16",
+				" 0",
+				"X-MARGIN:30",
+				"END:VEVENT",
+				"END:VCALENDAR",
+			),
+			expected: domain.Bookings{
+				{RequestID: "bookata_XY123", CheckIn: checkIn, CheckOut: checkIn.AddDate(0, 0, 5), Nights: 5, SellingRate: 200, Margin: 20},
+				{
+					RequestID:   "acme_AAAAA",
+					CheckIn:     time.Date(2020, 1, 10, 15, 0, 0, 0, madrid),
+					CheckOut:    time.Date(2020, 1, 14, 11, 0, 0, 0, madrid),
+					Nights:      4,
+					SellingRate: 160,
+					Margin:      30,
+				},
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name: "all-day event without end lasts one night",
+			requestBody: calendar(
+				"BEGIN:VEVENT",
+				"UID:bookata_XY123",
+				"DTSTART;VALUE=DATE:20200101",
+				"END:VEVENT",
+			),
+			expected: domain.Bookings{
+				{RequestID: "bookata_XY123", CheckIn: checkIn, CheckOut: checkIn.AddDate(0, 0, 1), Nights: 1},
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name: "invalid date",
+			requestBody: calendar(
+				"BEGIN:VEVENT",
+				"UID:bookata_XY123",
+				"DTSTART;VALUE=DATE:2020-01-01",
+				"END:VEVENT",
+			),
+			expectedStatus: http.StatusBadRequest,
+			expectedError:  "line 3: invalid date format",
+		},
+		{
+			name: "invalid selling rate",
+			requestBody: calendar(
+				"BEGIN:VCALENDAR",
+				"BEGIN:VEVENT",
+				"UID:bookata_XY123",
+				"DTSTART;VALUE=DATE:20200101",
+				"X-SELLING-RATE:a lot",
+				"END:VEVENT",
+			),
+			expectedStatus: http.StatusBadRequest,
+			expectedError:  "line 2: invalid number format: X-SELLING-RATE",
+		},
+		{
+			name: "missing start",
+			requestBody: calendar(
+				"BEGIN:VEVENT",
+				"UID:bookata_XY123",
+				"END:VEVENT",
+			),
+			expectedStatus: http.StatusBadRequest,
+			expectedError:  "line 1: invalid request calendar: missing DTSTART",
+		},
+		{
+			name: "unterminated event",
+			requestBody: calendar(
+				"BEGIN:VCALENDAR",
+				"BEGIN:VEVENT",
+				"UID:bookata_XY123",
+			),
+			expectedStatus: http.StatusBadRequest,
+			expectedError:  "line 2: invalid request calendar: unterminated event",
+		},
+		{
+			name: "malformed line",
+			requestBody: calendar(
+				"BEGIN:VEVENT",
+				"just text",
+			),
+			expectedStatus: http.StatusBadRequest,
+			expectedError:  "line 2: invalid request calendar: malformed content line",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockStatsService := mocks.NewMockStatsService(ctrl)
+			h, err := handler.NewStatsHandler(mockStatsService)
+			require.NoError(t, err)
+
+			if tt.expected != nil {
+				mockStatsService.EXPECT().
+					CalculateStats(gomock.Any(), gomock.Any()).
+					DoAndReturn(func(_ context.Context, bookings domain.Bookings) (*domain.StatsResult, error) {
+						assert.Equal(t, tt.expected, bookings)
+						return &domain.StatsResult{}, nil
+					})
+			}
+
+			req := httptest.NewRequest(http.MethodPost, "/stats", bytes.NewBufferString(tt.requestBody))
+			req.Header.Set("Content-Type", "text/calendar")
+			w := httptest.NewRecorder()
+
+			h.HandlerCalculateStats(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.expectedError != "" {
+				var response map[string]interface{}
+				require.NoError(t, json.NewDecoder(w.Body).Decode(&response))
+				assert.Equal(t, "invalid_input", response["code"])
+				assert.Equal(t, tt.expectedError, response["error"])
+			}
+		})
+	}
+}
+
+func TestStatsHandler_CalendarOutput(t *testing.T) {
+	madrid, err := time.LoadLocation("Europe/Madrid")
+	require.NoError(t, err)
+	checkIn := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	selected := domain.Bookings{
+		{RequestID: "bookata_XY123", CheckIn: checkIn, Nights: 5, SellingRate: 200, Margin: 20},
+		{
+			RequestID:   "acme_AAAAA,with;separators",
+			CheckIn:     time.Date(2020, 1, 10, 15, 0, 0, 0, madrid),
+			CheckOut:    time.Date(2020, 1, 14, 11, 0, 0, 0, madrid),
+			Nights:      4,
+			SellingRate: 160.5,
+			Margin:      30,
+		},
+		{
+			// a late check-out makes the whole event timed, not only its end
+			RequestID:   "kayete_PP234",
+			CheckIn:     checkIn.AddDate(0, 0, 19),
+			CheckOut:    time.Date(2020, 1, 22, 11, 0, 0, 0, time.UTC),
+			Nights:      2,
+			SellingRate: 100,
+			Margin:      10,
+		},
+	}
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockStatsService := mocks.NewMockStatsService(ctrl)
+	h, err := handler.NewStatsHandler(mockStatsService)
+	require.NoError(t, err)
+	mockStatsService.EXPECT().
+		MaximizeProfit(gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(&domain.MaximizeResult{RequestIDs: selected.RequestIDs(), Selected: selected}, nil)
+
+	req := httptest.NewRequest(http.MethodPost, "/maximize", bytes.NewBufferString("[]"))
+	req.Header.Set("Accept", "text/calendar")
+	w := httptest.NewRecorder()
+	h.HandlerMaximizeProfit(w, req)
+
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "text/calendar; charset=utf-8", w.Header().Get("Content-Type"))
+	feed := regexp.MustCompile(`DTSTAMP:\d{8}T\d{6}Z`).ReplaceAllString(w.Body.String(), "DTSTAMP:<now>")
+	assert.Equal(t, calendar(
+		"BEGIN:VCALENDAR",
+		"VERSION:2.0",
+		"PRODID:-//stay-for-long//maximize//EN",
+		"CALSCALE:GREGORIAN",
+		"METHOD:PUBLISH",
+		"BEGIN:VEVENT",
+		"UID:bookata_XY123",
+		"DTSTAMP:<now>",
+		"DTSTART;VALUE=DATE:20200101",
+		"DTEND;VALUE=DATE:20200106",
+		"SUMMARY:bookata_XY123",
+		"X-REQUEST-ID:bookata_XY123",
+		"X-SELLING-RATE:200",
+		"X-MARGIN:20",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		`UID:acme_AAAAA\,with\;separators`,
+		"DTSTAMP:<now>",
+		"DTSTART:20200110T140000Z",
+		"DTEND:20200114T100000Z",
+		`SUMMARY:acme_AAAAA\,with\;separators`,
+		`X-REQUEST-ID:acme_AAAAA\,with\;separators`,
+		"X-SELLING-RATE:160.5",
+		"X-MARGIN:30",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"UID:kayete_PP234",
+		"DTSTAMP:<now>",
+		"DTSTART:20200120T000000Z",
+		"DTEND:20200122T110000Z",
+		"SUMMARY:kayete_PP234",
+		"X-REQUEST-ID:kayete_PP234",
+		"X-SELLING-RATE:100",
+		"X-MARGIN:10",
+		"END:VEVENT",
+		"END:VCALENDAR",
+	), feed)
+
+	// the exported feed is accepted back as input
+	mockStatsService.EXPECT().
+		CalculateStats(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, bookings domain.Bookings) (*domain.StatsResult, error) {
+			require.Len(t, bookings, 3)
+			for i, b := range bookings {
+				assert.Equal(t, selected[i].RequestID, b.RequestID)
+				assert.True(t, selected[i].CheckIn.Equal(b.CheckIn))
+				assert.True(t, selected[i].End().Equal(b.End()))
+				assert.Equal(t, selected[i].Nights, b.Nights)
+				assert.Equal(t, selected[i].SellingRate, b.SellingRate)
+			}
+			return &domain.StatsResult{}, nil
+		})
+	req = httptest.NewRequest(http.MethodPost, "/stats", bytes.NewBufferString(w.Body.String()))
+	req.Header.Set("Content-Type", "text/calendar")
+	w = httptest.NewRecorder()
+	h.HandlerCalculateStats(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+}
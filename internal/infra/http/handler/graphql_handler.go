@@ -0,0 +1,168 @@
+package handler
+
+import (
+	"context"
+	_ "embed" // GraphQL schema
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/graph-gophers/graphql-go"
+
+	"github.com/duksonn/stay-for-long/internal/domain"
+	"github.com/duksonn/stay-for-long/internal/infra/limits"
+	"github.com/duksonn/stay-for-long/internal/ports"
+)
+
+const (
+	// graphQLMaxDepth caps the nesting of the GraphQL queries
+	graphQLMaxDepth = 8
+	// graphQLMaxParallelism caps the fields of a query resolved at the same time
+	graphQLMaxParallelism = 4
+)
+
+//go:embed schema.graphql
+var graphQLSchema string
+
+// GraphQLHandler handles the GraphQL queries over bookings, their stats and the maximize results
+// A query runs under the same limits as a REST request: MaxOptimizerTime bounds all its fields together
+// and the bookings of its maximize fields add up against the booking limit of their mode
+type GraphQLHandler struct {
+	schema  *graphql.Schema
+	limits  limits.Limits
+	version APIVersion
+}
+
+// graphQLRequest represents the structure of a GraphQL request body
+type graphQLRequest struct {
+	Query         string         `json:"query"`
+	OperationName string         `json:"operationName"`
+	Variables     map[string]any `json:"variables"`
+}
+
+// graphQLError is a resolver error reported with a machine readable code in its extensions
+type graphQLError struct {
+	code string
+	err  error
+}
+
+// NewGraphQLHandler creates a new instance of GraphQLHandler
+// Returns ErrNilStatsService if the stats service is nil
+func NewGraphQLHandler(statsSvc ports.StatsService, opts ...Option) (*GraphQLHandler, error) {
+	if statsSvc == nil {
+		return nil, ErrNilStatsService
+	}
+
+	o := newOptions(opts)
+	schema, err := graphql.ParseSchema(graphQLSchema, &graphQLResolver{statsService: statsSvc, limits: o.limits, tracer: o.tracer},
+		graphql.MaxDepth(graphQLMaxDepth), graphql.MaxParallelism(graphQLMaxParallelism))
+	if err != nil {
+		return nil, err
+	}
+
+	return &GraphQLHandler{schema: schema, limits: o.limits, version: o.version}, nil
+}
+
+// HandlerGraphQL processes HTTP requests holding a GraphQL query
+// Queries that can be executed answer 200 with their data and errors, following the GraphQL over HTTP
+// convention; unreadable bodies answer 400 and bodies over MaxBodyBytes answer 413
+func (h *GraphQLHandler) HandlerGraphQL(w http.ResponseWriter, r *http.Request) {
+	var req graphQLRequest
+	body, err := readBody(w, r, h.limits.MaxBodyBytes)
+	if err != nil {
+		writeRequestError(w, h.version, err)
+		return
+	}
+	if err := json.Unmarshal(body, &req); err != nil || req.Query == "" {
+		writeError(w, h.version, http.StatusBadRequest, codeInvalidInput, ErrInvalidJSON)
+		return
+	}
+
+	ctx := context.WithValue(r.Context(), graphQLQueryKey{}, newGraphQLQuery(h.limits))
+	writeJSONResponse(w, http.StatusOK, h.schema.Exec(ctx, req.Query, req.OperationName, req.Variables))
+}
+
+// graphQLQueryKey is the context key of the graphQLQuery being executed
+type graphQLQueryKey struct{}
+
+// graphQLQuery holds the limits shared by the fields of a query, which resolve in parallel
+// The fields calling the stats service share a single optimizer deadline, and the bookings of the
+// maximize fields add up per mode
+type graphQLQuery struct {
+	mu       sync.Mutex
+	limits   limits.Limits
+	deadline time.Time
+	bookings map[domain.Mode]int
+}
+
+// newGraphQLQuery starts the MaxOptimizerTime of a query
+func newGraphQLQuery(l limits.Limits) *graphQLQuery {
+	q := &graphQLQuery{limits: l, bookings: make(map[domain.Mode]int)}
+	if l.MaxOptimizerTime > 0 {
+		q.deadline = time.Now().Add(l.MaxOptimizerTime)
+	}
+
+	return q
+}
+
+// queryOptimizerContext bounds ctx to the optimizer deadline of the query, using limits.ErrOptimizerTimeExceeded as
+// the cause. Outside a query, ctx is bounded to MaxOptimizerTime of l on its own
+func queryOptimizerContext(ctx context.Context, l limits.Limits) (context.Context, context.CancelFunc) {
+	q, ok := ctx.Value(graphQLQueryKey{}).(*graphQLQuery)
+	if !ok {
+		return l.OptimizerContext(ctx)
+	}
+	if q.deadline.IsZero() {
+		return context.WithCancel(ctx)
+	}
+
+	return context.WithDeadlineCause(ctx, q.deadline, limits.ErrOptimizerTimeExceeded)
+}
+
+// takeBookings adds count bookings optimized in mode to the query, failing with limits.ErrTooManyBookings once the
+// bookings of its fields in that mode exceed the limit of a single request. Outside a query there is nothing to add up
+func takeBookings(ctx context.Context, mode domain.Mode, count int) error {
+	q, ok := ctx.Value(graphQLQueryKey{}).(*graphQLQuery)
+	if !ok {
+		return nil
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	total := q.bookings[mode] + count
+	if err := q.limits.ForMode(mode).CheckBookings(total); err != nil {
+		return fmt.Errorf("maximize fields of the query: %w", err)
+	}
+	q.bookings[mode] = total
+
+	return nil
+}
+
+// newGraphQLServiceError adds the code reported by the REST endpoints to the errors of the stats service
+// caused by a limit
+func newGraphQLServiceError(err error) error {
+	if errors.Is(err, limits.ErrOptimizerTimeExceeded) {
+		return &graphQLError{code: codeOptimizerTimeExceeded, err: err}
+	}
+	if errors.Is(err, domain.ErrExactSearchTooLarge) {
+		return &graphQLError{code: codeTooManyBookings, err: err}
+	}
+
+	return err
+}
+
+func (e *graphQLError) Error() string {
+	return e.err.Error()
+}
+
+func (e *graphQLError) Unwrap() error {
+	return e.err
+}
+
+// Extensions adds the code of the error to its GraphQL representation
+func (e *graphQLError) Extensions() map[string]any {
+	return map[string]any{"code": e.code}
+}
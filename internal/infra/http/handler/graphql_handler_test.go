@@ -0,0 +1,266 @@
+package handler_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"github.com/duksonn/stay-for-long/internal/application"
+	"github.com/duksonn/stay-for-long/internal/domain"
+	"github.com/duksonn/stay-for-long/internal/infra/http/handler"
+	"github.com/duksonn/stay-for-long/internal/infra/limits"
+	"github.com/duksonn/stay-for-long/internal/mocks"
+	"github.com/duksonn/stay-for-long/internal/ports"
+)
+
+// graphQLBookings are the bookings of the GraphQL tests, as a variable of type [BookingInput!]!
+var graphQLBookings = []map[string]interface{}{
+	{"requestId": "bookata_XY123", "checkIn": "2020-01-01", "nights": 5, "sellingRate": 200, "margin": 20},
+	{"requestId": "kayete_PP234", "checkIn": "2020-01-04", "nights": 4, "sellingRate": 156, "margin": 5},
+	{"requestId": "acme_AAAAA", "propertyId": "villa", "checkIn": "2020-02-10", "nights": 4, "sellingRate": 160, "margin": 30},
+}
+
+// graphQLResponse is the decoded body of a GraphQL response
+type graphQLResponse struct {
+	Data   map[string]interface{} `json:"data"`
+	Errors []struct {
+		Message    string            `json:"message"`
+		Extensions map[string]string `json:"extensions"`
+	} `json:"errors"`
+}
+
+func TestNewGraphQLHandler(t *testing.T) {
+	tests := []struct {
+		name     string
+		statsSvc ports.StatsService
+		wantErr  error
+	}{
+		{name: "successful creation", statsSvc: mocks.NewMockStatsService(gomock.NewController(t))},
+		{name: "nil service", statsSvc: nil, wantErr: handler.ErrNilStatsService},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h, err := handler.NewGraphQLHandler(tt.statsSvc)
+			if tt.wantErr != nil {
+				assert.Equal(t, tt.wantErr, err)
+				assert.Nil(t, h)
+			} else {
+				assert.NoError(t, err)
+				assert.NotNil(t, h)
+			}
+		})
+	}
+}
+
+func TestGraphQLHandler_HandlerGraphQL(t *testing.T) {
+	tests := []struct {
+		name      string
+		query     string
+		bookings  []map[string]interface{}
+		wantData  string
+		wantError string
+		wantCode  string
+	}{
+		{
+			name:     "bookings with derived figures",
+			query:    `query($bookings: [BookingInput!]!) { bookings(bookings: $bookings) { requestId provider propertyId checkOut profit profitPerNight } }`,
+			bookings: graphQLBookings[1:],
+			wantData: `{"bookings": [
+				{"requestId": "kayete_PP234", "provider": "kayete", "propertyId": null, "checkOut": "2020-01-08T00:00:00Z", "profit": 7.8, "profitPerNight": 1.95},
+				{"requestId": "acme_AAAAA", "provider": "acme", "propertyId": "villa", "checkOut": "2020-02-14T00:00:00Z", "profit": 48, "profitPerNight": 12}
+			]}`,
+		},
+		{
+			name: "stats with breakdowns",
+			query: `query($bookings: [BookingInput!]!) {
+				stats(bookings: $bookings) {
+					count avgNight minNight maxNight
+					byProvider: groups(by: PROVIDER) { key count avgNight }
+					byProperty: groups(by: PROPERTY) { key count }
+					byMonth: groups(by: CHECK_IN_MONTH) { key minNight maxNight }
+				}
+			}`,
+			bookings: graphQLBookings,
+			wantData: `{"stats": {
+				"count": 3, "avgNight": 7.32, "minNight": 1.95, "maxNight": 12,
+				"byProvider": [
+					{"key": "acme", "count": 1, "avgNight": 12},
+					{"key": "bookata", "count": 1, "avgNight": 8},
+					{"key": "kayete", "count": 1, "avgNight": 1.95}
+				],
+				"byProperty": [{"key": "villa", "count": 1}, {"key": null, "count": 2}],
+				"byMonth": [{"key": "2020-01", "minNight": 1.95, "maxNight": 8}, {"key": "2020-02", "minNight": 12, "maxNight": 12}]
+			}}`,
+		},
+		{
+			name: "maximize with options",
+			query: `query($bookings: [BookingInput!]!) {
+				maximize(bookings: $bookings, options: {objective: REVENUE, counterOffers: true}) {
+					requestIds objective score optimal
+					selected { requestId }
+					rejected { booking { requestId } counterOfferRate }
+				}
+			}`,
+			bookings: graphQLBookings,
+			wantData: `{"maximize": {
+				"requestIds": ["bookata_XY123", "acme_AAAAA"], "objective": "REVENUE", "score": 360, "optimal": true,
+				"selected": [{"requestId": "bookata_XY123"}, {"requestId": "acme_AAAAA"}],
+				"rejected": [{"booking": {"requestId": "kayete_PP234"}, "counterOfferRate": 200.01}]
+			}}`,
+		},
+		{
+			name:      "invalid booking",
+			query:     `query($bookings: [BookingInput!]!) { stats(bookings: $bookings) { count } }`,
+			bookings:  append(graphQLBookings[:1:1], map[string]interface{}{"requestId": "kayete_PP234", "checkIn": "01/04/2020", "sellingRate": 156, "margin": 5}),
+			wantError: "booking 1: invalid date format",
+			wantCode:  "invalid_input",
+		},
+		{
+			name:      "too many bookings",
+			query:     `query($bookings: [BookingInput!]!) { bookings(bookings: $bookings) { requestId } }`,
+			bookings:  append(graphQLBookings, graphQLBookings...),
+			wantError: "too many bookings: got 6, limit is 4",
+			wantCode:  "too_many_bookings",
+		},
+		{
+			name: "maximize fields add up against the booking limit",
+			query: `query($bookings: [BookingInput!]!) {
+				first: maximize(bookings: $bookings) { score }
+				second: maximize(bookings: $bookings) { score }
+			}`,
+			bookings:  graphQLBookings,
+			wantError: "maximize fields of the query: too many bookings: got 6, limit is 4",
+			wantCode:  "too_many_bookings",
+		},
+		{
+			name:     "heuristic mode raises the booking limit",
+			query:    `query($bookings: [BookingInput!]!) { maximize(bookings: $bookings, options: {mode: HEURISTIC, budgetMs: 5}) { requestIds } }`,
+			bookings: append(graphQLBookings, graphQLBookings...),
+			wantData: `{"maximize": {"requestIds": ["bookata_XY123", "acme_AAAAA"]}}`,
+		},
+		{
+			name:      "invalid options",
+			query:     `query($bookings: [BookingInput!]!) { maximize(bookings: $bookings, options: {objective: WEIGHTED}) { score } }`,
+			bookings:  graphQLBookings,
+			wantError: "invalid objective weights: at least one weight must be positive",
+			wantCode:  "invalid_input",
+		},
+		{
+			name:      "unknown field",
+			query:     `{ revenue }`,
+			wantError: `Cannot query field "revenue" on type "Query".`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h, err := handler.NewGraphQLHandler(application.NewStatsService(), handler.WithLimits(limits.Limits{
+				MaxBookings:          4,
+				MaxHeuristicBookings: 10,
+				MaxOptimizerTime:     time.Second,
+			}))
+			require.NoError(t, err)
+
+			body, err := json.Marshal(map[string]interface{}{"query": tt.query, "variables": map[string]interface{}{"bookings": tt.bookings}})
+			require.NoError(t, err)
+			w := httptest.NewRecorder()
+			h.HandlerGraphQL(w, httptest.NewRequest(http.MethodPost, "/graphql", bytes.NewReader(body)))
+			require.Equal(t, http.StatusOK, w.Code)
+
+			var response graphQLResponse
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+			if tt.wantError != "" {
+				require.Len(t, response.Errors, 1)
+				assert.Equal(t, tt.wantError, response.Errors[0].Message)
+				assert.Equal(t, tt.wantCode, response.Errors[0].Extensions["code"])
+				return
+			}
+			require.Empty(t, response.Errors)
+			data, err := json.Marshal(response.Data)
+			require.NoError(t, err)
+			assert.JSONEq(t, tt.wantData, string(data))
+		})
+	}
+}
+
+func TestGraphQLHandler_HandlerGraphQL_OptimizerTimeExceeded(t *testing.T) {
+	mockService := mocks.NewMockStatsService(gomock.NewController(t))
+	mockService.EXPECT().MaximizeProfit(gomock.Any(), gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, _ domain.Bookings, _ domain.MaximizeOptions) (*domain.MaximizeResult, error) {
+			<-ctx.Done()
+			return nil, context.Cause(ctx)
+		})
+	h, err := handler.NewGraphQLHandler(mockService, handler.WithLimits(limits.Limits{MaxOptimizerTime: 10 * time.Millisecond}))
+	require.NoError(t, err)
+
+	w := httptest.NewRecorder()
+	body := `{"query":"{ maximize(bookings: []) { score } }"}`
+	h.HandlerGraphQL(w, httptest.NewRequest(http.MethodPost, "/graphql", strings.NewReader(body)))
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var response graphQLResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	require.Len(t, response.Errors, 1)
+	assert.Equal(t, "optimizer_time_exceeded", response.Errors[0].Extensions["code"], w.Body.String())
+}
+
+func TestGraphQLHandler_HandlerGraphQL_SharedOptimizerContext(t *testing.T) {
+	var mu sync.Mutex
+	var deadlines []time.Time
+	mockService := mocks.NewMockStatsService(gomock.NewController(t))
+	mockService.EXPECT().MaximizeProfit(gomock.Any(), gomock.Any(), gomock.Any()).Times(2).
+		DoAndReturn(func(ctx context.Context, _ domain.Bookings, _ domain.MaximizeOptions) (*domain.MaximizeResult, error) {
+			deadline, ok := ctx.Deadline()
+			assert.True(t, ok)
+			mu.Lock()
+			deadlines = append(deadlines, deadline)
+			mu.Unlock()
+			return &domain.MaximizeResult{}, nil
+		})
+	h, err := handler.NewGraphQLHandler(mockService, handler.WithLimits(limits.Limits{MaxOptimizerTime: time.Minute}))
+	require.NoError(t, err)
+
+	w := httptest.NewRecorder()
+	body := `{"query":"{ first: maximize(bookings: []) { score } second: maximize(bookings: []) { score } }"}`
+	h.HandlerGraphQL(w, httptest.NewRequest(http.MethodPost, "/graphql", strings.NewReader(body)))
+	require.Equal(t, http.StatusOK, w.Code)
+
+	// every field runs under the single MaxOptimizerTime of the query
+	require.Len(t, deadlines, 2)
+	assert.Equal(t, deadlines[0], deadlines[1])
+}
+
+func TestGraphQLHandler_HandlerGraphQL_InvalidRequest(t *testing.T) {
+	tests := []struct {
+		name           string
+		body           string
+		expectedStatus int
+	}{
+		{name: "invalid json", body: "{", expectedStatus: http.StatusBadRequest},
+		{name: "missing query", body: `{"variables":{}}`, expectedStatus: http.StatusBadRequest},
+		{name: "body too large", body: `{"query":"` + strings.Repeat(" ", 100) + `{ __typename }"}`, expectedStatus: http.StatusRequestEntityTooLarge},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h, err := handler.NewGraphQLHandler(mocks.NewMockStatsService(gomock.NewController(t)),
+				handler.WithLimits(limits.Limits{MaxBodyBytes: 64}))
+			require.NoError(t, err)
+
+			w := httptest.NewRecorder()
+			h.HandlerGraphQL(w, httptest.NewRequest(http.MethodPost, "/graphql", strings.NewReader(tt.body)))
+			assert.Equal(t, tt.expectedStatus, w.Code)
+		})
+	}
+}
@@ -0,0 +1,354 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/duksonn/stay-for-long/internal/domain"
+	"github.com/duksonn/stay-for-long/internal/infra/dto"
+	"github.com/duksonn/stay-for-long/internal/infra/limits"
+	"github.com/duksonn/stay-for-long/internal/ports"
+)
+
+// graphQLResolver resolves the Query type of the GraphQL schema
+type graphQLResolver struct {
+	statsService ports.StatsService
+	limits       limits.Limits
+	tracer       trace.Tracer
+}
+
+// bookingInput is the BookingInput type of the GraphQL schema
+type bookingInput struct {
+	RequestID    string
+	PropertyID   *string
+	CheckIn      string
+	CheckOut     *string
+	CheckInTime  *string
+	CheckOutTime *string
+	Timezone     *string
+	Nights       *int32
+	SellingRate  float64
+	Margin       float64
+}
+
+// weightsInput is the Weights type of the GraphQL schema
+type weightsInput struct {
+	Profit    *float64
+	Revenue   *float64
+	Occupancy *float64
+}
+
+// maximizeOptionsInput is the MaximizeOptions type of the GraphQL schema
+type maximizeOptionsInput struct {
+	Objective          *string
+	Weights            *weightsInput
+	TieBreak           *string
+	PreferredProviders *[]string
+	CounterOffers      *bool
+	Mode               *string
+	BudgetMs           *int32
+}
+
+// bookingsArgs are the arguments of the queries taking bookings
+type bookingsArgs struct {
+	Bookings []bookingInput
+}
+
+// maximizeArgs are the arguments of the maximize query
+type maximizeArgs struct {
+	Bookings []bookingInput
+	Options  *maximizeOptionsInput
+}
+
+// Bookings validates the bookings and returns them with their derived figures
+func (r *graphQLResolver) Bookings(ctx context.Context, args bookingsArgs) ([]*bookingResolver, error) {
+	requests, err := r.parseBookings(ctx, args.Bookings, r.limits)
+	if err != nil {
+		return nil, err
+	}
+
+	return newBookingResolvers(requests), nil
+}
+
+// Stats computes the profit per night statistics of the bookings
+func (r *graphQLResolver) Stats(ctx context.Context, args bookingsArgs) (*statsResolver, error) {
+	requests, err := r.parseBookings(ctx, args.Bookings, r.limits)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := queryOptimizerContext(ctx, r.limits)
+	defer cancel()
+
+	stats, err := r.statsService.CalculateStats(ctx, requests)
+	if err != nil {
+		return nil, newGraphQLServiceError(err)
+	}
+
+	return &statsResolver{statsService: r.statsService, limits: r.limits, bookings: requests, stats: stats}, nil
+}
+
+// Maximize finds the non-overlapping bookings that maximize the objective of the options
+// Its bookings count against the budget of the query, shared with the other maximize fields
+func (r *graphQLResolver) Maximize(ctx context.Context, args maximizeArgs) (*maximizeResultResolver, error) {
+	opts, err := args.Options.toDomain()
+	if err == nil {
+		err = r.limits.CheckBudget(opts.Budget)
+	}
+	if err != nil {
+		return nil, &graphQLError{code: codeInvalidInput, err: err}
+	}
+	requests, err := r.parseBookings(ctx, args.Bookings, r.limits.ForMode(opts.Mode))
+	if err != nil {
+		return nil, err
+	}
+	if err := takeBookings(ctx, opts.Mode, len(requests)); err != nil {
+		return nil, &graphQLError{code: codeTooManyBookings, err: err}
+	}
+
+	ctx, cancel := queryOptimizerContext(ctx, r.limits)
+	defer cancel()
+
+	result, err := r.statsService.MaximizeProfit(ctx, requests, opts)
+	if err != nil {
+		return nil, newGraphQLServiceError(err)
+	}
+
+	return &maximizeResultResolver{result: result}, nil
+}
+
+// parseBookings checks the number of bookings against l and converts them to domain.Booking objects
+// Errors name the position of the faulty booking, and the bookings of every field add up in the access log
+func (r *graphQLResolver) parseBookings(ctx context.Context, inputs []bookingInput, l limits.Limits) (domain.Bookings, error) {
+	span := startDecode(ctx, r.tracer, "")
+	requests, err := convertBookings(inputs, l)
+	endDecode(ctx, span, len(requests), err)
+
+	return requests, err
+}
+
+// convertBookings checks the number of bookings against l and converts them to domain.Booking objects
+func convertBookings(inputs []bookingInput, l limits.Limits) (domain.Bookings, error) {
+	if err := l.CheckBookings(len(inputs)); err != nil {
+		return nil, &graphQLError{code: codeTooManyBookings, err: err}
+	}
+
+	requests := make(domain.Bookings, 0, len(inputs))
+	locations := dto.Locations{}
+	for i, input := range inputs {
+		booking, err := input.toDTO().ToDomain(locations)
+		if err != nil {
+			return nil, &graphQLError{code: codeInvalidInput, err: fmt.Errorf("booking %d: %w", i, err)}
+		}
+		requests = append(requests, booking)
+	}
+
+	return requests, nil
+}
+
+// toDTO maps a GraphQL booking to the booking DTO shared with the REST API
+func (b bookingInput) toDTO() dto.Booking {
+	return dto.Booking{
+		RequestID:    b.RequestID,
+		PropertyID:   valueOrZero(b.PropertyID),
+		CheckIn:      b.CheckIn,
+		CheckOut:     valueOrZero(b.CheckOut),
+		CheckInTime:  valueOrZero(b.CheckInTime),
+		CheckOutTime: valueOrZero(b.CheckOutTime),
+		Timezone:     valueOrZero(b.Timezone),
+		Nights:       int(valueOrZero(b.Nights)),
+		SellingRate:  b.SellingRate,
+		Margin:       b.Margin,
+	}
+}
+
+// toDomain converts the GraphQL options to domain.MaximizeOptions and validates them
+// Omitted options take the same defaults as omitted query parameters of the REST API
+func (o *maximizeOptionsInput) toDomain() (domain.MaximizeOptions, error) {
+	if o == nil {
+		o = &maximizeOptionsInput{}
+	}
+	objective, err := domain.ParseObjective(strings.ToLower(valueOrZero(o.Objective)))
+	if err != nil {
+		return domain.MaximizeOptions{}, err
+	}
+	tieBreak, err := domain.ParseTieBreak(strings.ToLower(valueOrZero(o.TieBreak)))
+	if err != nil {
+		return domain.MaximizeOptions{}, err
+	}
+	mode, err := domain.ParseMode(strings.ToLower(valueOrZero(o.Mode)))
+	if err != nil {
+		return domain.MaximizeOptions{}, err
+	}
+
+	opts := domain.MaximizeOptions{
+		Objective:          objective,
+		TieBreak:           tieBreak,
+		PreferredProviders: valueOrZero(o.PreferredProviders),
+		CounterOffers:      valueOrZero(o.CounterOffers),
+		Mode:               mode,
+		Budget:             time.Duration(valueOrZero(o.BudgetMs)) * time.Millisecond,
+	}
+	if w := o.Weights; w != nil {
+		opts.Weights = domain.Weights{
+			Profit:    valueOrZero(w.Profit),
+			Revenue:   valueOrZero(w.Revenue),
+			Occupancy: valueOrZero(w.Occupancy),
+		}
+	}
+	if err := opts.Validate(); err != nil {
+		return domain.MaximizeOptions{}, err
+	}
+
+	return opts, nil
+}
+
+// valueOrZero returns the value of an optional argument, or its zero value when it was omitted
+func valueOrZero[T any](v *T) T {
+	if v == nil {
+		var zero T
+		return zero
+	}
+
+	return *v
+}
+
+// statsResolver resolves the Stats type of the GraphQL schema
+type statsResolver struct {
+	statsService ports.StatsService
+	limits       limits.Limits
+	bookings     domain.Bookings
+	stats        *domain.StatsResult
+}
+
+// groupArgs are the arguments of the groups field
+type groupArgs struct {
+	By string
+}
+
+func (r *statsResolver) Count() int32      { return int32(len(r.bookings)) }
+func (r *statsResolver) AvgNight() float64 { return r.stats.AvgNight }
+func (r *statsResolver) MinNight() float64 { return r.stats.MinNight }
+func (r *statsResolver) MaxNight() float64 { return r.stats.MaxNight }
+
+// Groups splits the bookings by the requested criterion and computes the statistics of each group
+// Groups are sorted by key, the group of the bookings without key coming last
+func (r *statsResolver) Groups(ctx context.Context, args groupArgs) ([]*statsGroupResolver, error) {
+	key, err := groupKey(args.By)
+	if err != nil {
+		return nil, &graphQLError{code: codeInvalidInput, err: err}
+	}
+
+	ctx, cancel := queryOptimizerContext(ctx, r.limits)
+	defer cancel()
+
+	groups, err := calculateGroupStats(ctx, r.statsService, r.bookings, key)
+	if err != nil {
+		return nil, newGraphQLServiceError(err)
+	}
+	resolvers := make([]*statsGroupResolver, 0, len(groups))
+	for _, g := range groups {
+		resolvers = append(resolvers, &statsGroupResolver{group: g})
+	}
+
+	return resolvers, nil
+}
+
+// statsGroupResolver resolves the StatsGroup type of the GraphQL schema
+type statsGroupResolver struct {
+	group statsGroup
+}
+
+func (r *statsGroupResolver) Key() *string      { return nilIfEmpty(r.group.key) }
+func (r *statsGroupResolver) Count() int32      { return int32(r.group.count) }
+func (r *statsGroupResolver) AvgNight() float64 { return r.group.stats.AvgNight }
+func (r *statsGroupResolver) MinNight() float64 { return r.group.stats.MinNight }
+func (r *statsGroupResolver) MaxNight() float64 { return r.group.stats.MaxNight }
+
+// bookingResolver resolves the Booking type of the GraphQL schema
+type bookingResolver struct {
+	booking *domain.Booking
+}
+
+// newBookingResolvers wraps every booking in a resolver
+func newBookingResolvers(bookings domain.Bookings) []*bookingResolver {
+	resolvers := make([]*bookingResolver, 0, len(bookings))
+	for _, b := range bookings {
+		resolvers = append(resolvers, &bookingResolver{booking: b})
+	}
+
+	return resolvers
+}
+
+func (r *bookingResolver) RequestID() string       { return r.booking.RequestID }
+func (r *bookingResolver) Provider() string        { return r.booking.Provider() }
+func (r *bookingResolver) PropertyID() *string     { return nilIfEmpty(r.booking.PropertyID) }
+func (r *bookingResolver) CheckIn() string         { return r.booking.CheckIn.Format(time.RFC3339) }
+func (r *bookingResolver) CheckOut() string        { return r.booking.End().Format(time.RFC3339) }
+func (r *bookingResolver) Nights() int32           { return int32(r.booking.Nights) }
+func (r *bookingResolver) SellingRate() float64    { return r.booking.SellingRate }
+func (r *bookingResolver) Margin() float64         { return r.booking.Margin }
+func (r *bookingResolver) Profit() float64         { return domain.Bookings{r.booking}.TotalProfit() }
+func (r *bookingResolver) ProfitPerNight() float64 { return r.booking.ProfitPerNight() }
+
+// maximizeResultResolver resolves the MaximizeResult type of the GraphQL schema
+type maximizeResultResolver struct {
+	result *domain.MaximizeResult
+}
+
+func (r *maximizeResultResolver) RequestIDs() []string { return r.result.RequestIDs }
+func (r *maximizeResultResolver) Objective() string {
+	return strings.ToUpper(string(r.result.Objective))
+}
+func (r *maximizeResultResolver) Score() float64        { return r.result.Score }
+func (r *maximizeResultResolver) TotalProfit() float64  { return r.result.TotalProfit }
+func (r *maximizeResultResolver) TotalRevenue() float64 { return r.result.TotalRevenue }
+func (r *maximizeResultResolver) TotalNights() int32    { return int32(r.result.TotalNights) }
+func (r *maximizeResultResolver) AvgNight() float64     { return r.result.AvgNight }
+func (r *maximizeResultResolver) MinNight() float64     { return r.result.MinNight }
+func (r *maximizeResultResolver) MaxNight() float64     { return r.result.MaxNight }
+func (r *maximizeResultResolver) Optimal() bool         { return r.result.Optimal }
+func (r *maximizeResultResolver) UpperBound() float64   { return r.result.UpperBound }
+func (r *maximizeResultResolver) Gap() float64          { return r.result.Gap }
+func (r *maximizeResultResolver) Selected() []*bookingResolver {
+	return newBookingResolvers(r.result.Selected)
+}
+
+func (r *maximizeResultResolver) Rejected() []*rejectedBookingResolver {
+	resolvers := make([]*rejectedBookingResolver, 0, len(r.result.Rejected))
+	for _, rejected := range r.result.Rejected {
+		resolvers = append(resolvers, &rejectedBookingResolver{rejected: rejected})
+	}
+
+	return resolvers
+}
+
+// rejectedBookingResolver resolves the RejectedBooking type of the GraphQL schema
+type rejectedBookingResolver struct {
+	rejected *domain.RejectedBooking
+}
+
+func (r *rejectedBookingResolver) Booking() *bookingResolver {
+	return &bookingResolver{booking: r.rejected.Booking}
+}
+
+func (r *rejectedBookingResolver) CounterOfferRate() *float64 {
+	if r.rejected.CounterOfferRate == 0 {
+		return nil
+	}
+
+	return &r.rejected.CounterOfferRate
+}
+
+// nilIfEmpty returns nil for an empty string, which GraphQL reports as null
+func nilIfEmpty(s string) *string {
+	if s == "" {
+		return nil
+	}
+
+	return &s
+}
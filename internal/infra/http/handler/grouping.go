@@ -0,0 +1,78 @@
+package handler
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+
+	"github.com/duksonn/stay-for-long/internal/domain"
+	"github.com/duksonn/stay-for-long/internal/ports"
+)
+
+// Criteria the stats can be grouped by, named after the GroupBy enum of the GraphQL schema
+const (
+	groupByProvider     = "PROVIDER"
+	groupByProperty     = "PROPERTY"
+	groupByCheckInMonth = "CHECK_IN_MONTH"
+)
+
+// queryGroupBy maps the values of the group_by query parameter of the stats endpoint to the GroupBy criteria
+var queryGroupBy = map[string]string{
+	"provider":       groupByProvider,
+	"property":       groupByProperty,
+	"check_in_month": groupByCheckInMonth,
+}
+
+// ErrInvalidGroupBy is returned when the stats grouping is unknown
+var ErrInvalidGroupBy = errors.New("invalid group by")
+
+// groupKey returns the function computing the group of a booking for a GroupBy criterion
+func groupKey(by string) (func(*domain.Booking) string, error) {
+	switch by {
+	case groupByProvider:
+		return (*domain.Booking).Provider, nil
+	case groupByProperty:
+		return func(b *domain.Booking) string { return b.PropertyID }, nil
+	case groupByCheckInMonth:
+		return func(b *domain.Booking) string { return b.CheckIn.Format("2006-01") }, nil
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrInvalidGroupBy, by)
+	}
+}
+
+// statsGroup holds the statistics of the bookings sharing a group key
+type statsGroup struct {
+	key   string
+	count int
+	stats *domain.StatsResult
+}
+
+// calculateGroupStats splits the bookings with key and computes the statistics of each group
+// Groups are sorted by key, the group of the bookings without key coming last
+func calculateGroupStats(ctx context.Context, statsService ports.StatsService, bookings domain.Bookings,
+	key func(*domain.Booking) string) ([]statsGroup, error) {
+	groups := make(map[string]domain.Bookings)
+	for _, b := range bookings {
+		groups[key(b)] = append(groups[key(b)], b)
+	}
+	keys := slices.SortedFunc(maps.Keys(groups), func(a, b string) int {
+		if (a == "") != (b == "") {
+			return cmp.Compare(b, a)
+		}
+		return cmp.Compare(a, b)
+	})
+
+	result := make([]statsGroup, 0, len(keys))
+	for _, k := range keys {
+		stats, err := statsService.CalculateStats(ctx, groups[k])
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, statsGroup{key: k, count: len(groups[k]), stats: stats})
+	}
+
+	return result, nil
+}
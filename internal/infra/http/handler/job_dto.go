@@ -0,0 +1,84 @@
+package handler
+
+import (
+	"time"
+
+	"github.com/duksonn/stay-for-long/internal/domain"
+	"github.com/duksonn/stay-for-long/internal/infra/dto"
+)
+
+// jobResponse represents the structure of an asynchronous optimization job as returned by the HTTP API
+type jobResponse struct {
+	ID         string                  `json:"id"`                   // Identifier used to poll or cancel the job
+	Status     string                  `json:"status"`               // One of queued, running, succeeded, failed or canceled
+	Progress   float64                 `json:"progress"`             // Fraction of the combinations evaluated, from 0 to 1
+	BestProfit float64                 `json:"best_profit"`          // Total profit of the best selection found so far
+	Result     *maximizeResultResponse `json:"result,omitempty"`     // Optimal selection, once the job succeeded
+	Error      string                  `json:"error,omitempty"`      // Reason the job failed
+	CreatedAt  time.Time               `json:"created_at"`           // Moment the job was submitted
+	StartedAt  *time.Time              `json:"started_at,omitempty"` // Moment a worker picked the job
+	UpdatedAt  time.Time               `json:"updated_at"`           // Moment the job last changed
+}
+
+// jobEventResponse represents the data of a server-sent event describing the progress of a job
+type jobEventResponse struct {
+	ID         string                  `json:"id"`               // Identifier of the job
+	Status     string                  `json:"status"`           // One of queued, running, succeeded, failed or canceled
+	Progress   float64                 `json:"progress"`         // Fraction of the combinations evaluated, from 0 to 1
+	BestProfit float64                 `json:"best_profit"`      // Total profit of the best selection found so far
+	ElapsedMs  int64                   `json:"elapsed_ms"`       // Time spent running the job, in milliseconds
+	Result     *maximizeResultResponse `json:"result,omitempty"` // Optimal selection, once the job succeeded
+	Error      string                  `json:"error,omitempty"`  // Reason the job failed
+}
+
+// newJobResponse converts a domain job to its response DTO
+func newJobResponse(job *domain.Job) jobResponse {
+	response := jobResponse{
+		ID:         job.ID,
+		Status:     string(job.Status),
+		Progress:   job.Progress,
+		BestProfit: job.BestProfit,
+		Result:     newOptionalMaximizeResultResponse(job.Result),
+		Error:      job.Error,
+		CreatedAt:  job.CreatedAt,
+		UpdatedAt:  job.UpdatedAt,
+	}
+	if !job.StartedAt.IsZero() {
+		response.StartedAt = &job.StartedAt
+	}
+
+	return response
+}
+
+// CalendarBookings returns the accepted bookings of a succeeded job, rendered as the events of an iCalendar feed
+// The feed has no events until the job succeeds, so calendars subscribed to a job fill in once it finishes
+func (r jobResponse) CalendarBookings() domain.Bookings {
+	if r.Result == nil {
+		return domain.Bookings{}
+	}
+
+	return r.Result.CalendarBookings()
+}
+
+// newJobEventResponse converts a domain job to the data of a progress event at the given moment
+func newJobEventResponse(job *domain.Job, now time.Time) jobEventResponse {
+	return jobEventResponse{
+		ID:         job.ID,
+		Status:     string(job.Status),
+		Progress:   job.Progress,
+		BestProfit: job.BestProfit,
+		ElapsedMs:  job.Elapsed(now).Milliseconds(),
+		Result:     newOptionalMaximizeResultResponse(job.Result),
+		Error:      job.Error,
+	}
+}
+
+// newOptionalMaximizeResultResponse converts a result that may not be available yet to its response DTO
+func newOptionalMaximizeResultResponse(result *domain.MaximizeResult) *maximizeResultResponse {
+	if result == nil {
+		return nil
+	}
+	response := dto.NewMaximizeResult(result)
+
+	return &response
+}
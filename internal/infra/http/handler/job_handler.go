@@ -0,0 +1,175 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/duksonn/stay-for-long/internal/domain"
+	"github.com/duksonn/stay-for-long/internal/infra/dto"
+	"github.com/duksonn/stay-for-long/internal/infra/limits"
+	"github.com/duksonn/stay-for-long/internal/ports"
+)
+
+// ErrNilJobService is returned when the job service is nil
+var ErrNilJobService = errors.New("job service cannot be nil")
+
+const (
+	// codeJobQueueFull is the error code reported when a job is rejected because the queue is full
+	codeJobQueueFull = "job_queue_full"
+	// eventsKeepAlive is how often a comment is sent on an idle event stream so proxies keep it open
+	eventsKeepAlive = 15 * time.Second
+)
+
+// JobHandler handles HTTP requests for asynchronous optimization jobs
+// It provides endpoints to submit, poll and cancel MaximizeProfit runs
+type JobHandler struct {
+	jobService ports.JobService
+	limits     limits.Limits
+	columns    dto.CSVColumns
+	version    APIVersion
+	tracer     trace.Tracer
+}
+
+// NewJobHandler creates a new instance of JobHandler
+// Returns ErrNilJobService if the job service is nil and ErrInvalidCSVHeaders if
+// the CSV header aliases target unknown fields
+func NewJobHandler(jobSvc ports.JobService, opts ...Option) (*JobHandler, error) {
+	if jobSvc == nil {
+		return nil, ErrNilJobService
+	}
+
+	o := newOptions(opts)
+	columns, err := dto.NewCSVColumns(o.csvHeaders)
+	if err != nil {
+		return nil, err
+	}
+
+	return &JobHandler{jobService: jobSvc, limits: o.limits, columns: columns, version: o.version, tracer: o.tracer}, nil
+}
+
+// HandlerSubmitJob processes HTTP requests to queue a MaximizeProfit run
+// It accepts the same body, including the V2 envelope, and query parameters as /maximize and answers 202 with the queued job
+func (h *JobHandler) HandlerSubmitJob(w http.ResponseWriter, r *http.Request) {
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	span := startDecode(r.Context(), h.tracer, mediaType)
+	requests, opts, err := decodeBody(w, r, mediaType, h.version, h.limits, h.columns, h.modeLimits)
+	endDecode(r.Context(), span, len(requests), err)
+	if err != nil {
+		writeRequestError(w, h.version, err)
+		return
+	}
+
+	job, err := h.jobService.Submit(r.Context(), requests, opts)
+	if err != nil {
+		writeJobError(w, h.version, err)
+		return
+	}
+	w.Header().Set("Location", r.URL.Path+"/"+job.ID)
+	writeJSONResponse(w, http.StatusAccepted, newJobResponse(job))
+}
+
+// modeLimits returns the limits enforced for a job searching in the mode of opts
+func (h *JobHandler) modeLimits(opts domain.MaximizeOptions) (limits.Limits, error) {
+	return h.limits.ForMode(opts.Mode), nil
+}
+
+// HandlerGetJob processes HTTP requests to poll the status, progress and result of a job
+// Clients preferring text/calendar get the accepted bookings as an iCalendar feed they can subscribe to
+func (h *JobHandler) HandlerGetJob(w http.ResponseWriter, r *http.Request) {
+	job, err := h.jobService.Get(r.Context(), mux.Vars(r)["id"])
+	if err != nil {
+		writeJobError(w, h.version, err)
+		return
+	}
+	writeResponse(w, r, http.StatusOK, newJobResponse(job))
+}
+
+// HandlerCancelJob processes HTTP requests to cancel a queued or running job
+func (h *JobHandler) HandlerCancelJob(w http.ResponseWriter, r *http.Request) {
+	job, err := h.jobService.Cancel(r.Context(), mux.Vars(r)["id"])
+	if err != nil {
+		writeJobError(w, h.version, err)
+		return
+	}
+	writeJSONResponse(w, http.StatusOK, newJobResponse(job))
+}
+
+// HandlerJobEvents streams the progress of a job as server-sent events
+// A progress event is sent every time the job changes, carrying the share of the search space explored,
+// the best profit found so far and the elapsed time. The stream ends with an event named after the final
+// status (succeeded, failed or canceled), the succeeded one carrying the result
+func (h *JobHandler) HandlerJobEvents(w http.ResponseWriter, r *http.Request) {
+	jobs, err := h.jobService.Watch(r.Context(), mux.Vars(r)["id"])
+	if err != nil {
+		writeJobError(w, h.version, err)
+		return
+	}
+
+	// the stream lasts as long as the job, so it is not bound by the server write timeout
+	rc := http.NewResponseController(w)
+	_ = rc.SetWriteDeadline(time.Time{})
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	_ = rc.Flush()
+
+	keepAlive := time.NewTicker(eventsKeepAlive)
+	defer keepAlive.Stop()
+	for {
+		select {
+		case job, ok := <-jobs:
+			if !ok {
+				return
+			}
+			if err := writeJobEvent(w, job); err != nil {
+				return
+			}
+		case <-keepAlive.C:
+			if _, err := fmt.Fprint(w, ": keep-alive\n\n"); err != nil {
+				return
+			}
+		}
+		if err := rc.Flush(); err != nil {
+			return
+		}
+	}
+}
+
+// writeJobEvent writes a job as a server-sent event, named progress until the job reaches a final status
+func writeJobEvent(w http.ResponseWriter, job *domain.Job) error {
+	data, err := json.Marshal(newJobEventResponse(job, time.Now()))
+	if err != nil {
+		return err
+	}
+	event := "progress"
+	if job.Status.Finished() {
+		event = string(job.Status)
+	}
+	_, err = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, data)
+
+	return err
+}
+
+// writeJobError maps an error returned by the job service to its HTTP status code
+// Unknown jobs answer 404, jobs that already finished answer 409 and a full queue answers 503
+func writeJobError(w http.ResponseWriter, version APIVersion, err error) {
+	switch {
+	case errors.Is(err, domain.ErrJobNotFound):
+		writeError(w, version, http.StatusNotFound, codeJobNotFound, err)
+	case errors.Is(err, domain.ErrJobFinished):
+		writeError(w, version, http.StatusConflict, codeJobFinished, err)
+	case errors.Is(err, domain.ErrJobQueueFull):
+		writeError(w, version, http.StatusServiceUnavailable, codeJobQueueFull, err)
+	default:
+		writeError(w, version, http.StatusInternalServerError, codeInternalError, err)
+	}
+}
@@ -0,0 +1,405 @@
+package handler_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"github.com/duksonn/stay-for-long/internal/domain"
+	"github.com/duksonn/stay-for-long/internal/infra/http/handler"
+	"github.com/duksonn/stay-for-long/internal/infra/limits"
+	"github.com/duksonn/stay-for-long/internal/mocks"
+)
+
+func TestNewJobHandler(t *testing.T) {
+	h, err := handler.NewJobHandler(mocks.NewMockJobService(gomock.NewController(t)))
+	assert.NoError(t, err)
+	assert.NotNil(t, h)
+
+	h, err = handler.NewJobHandler(nil)
+	assert.Equal(t, handler.ErrNilJobService, err)
+	assert.Nil(t, h)
+}
+
+func TestJobHandler_HandlerSubmitJob(t *testing.T) {
+	booking := `{"request_id":"bookata_XY123","check_in":"2020-01-01","nights":5,"selling_rate":200,"margin":20}`
+
+	tests := []struct {
+		name             string
+		version          handler.APIVersion
+		requestBody      string
+		mock             func(*mocks.MockJobService)
+		expectedStatus   int
+		expectedLocation string
+		expectedCode     string
+	}{
+		{
+			name:        "queued",
+			requestBody: "[" + booking + "]",
+			mock: func(m *mocks.MockJobService) {
+				m.EXPECT().
+					Submit(gomock.Any(), gomock.Len(1), gomock.Any()).
+					Return(&domain.Job{ID: "job1", Status: domain.JobQueued}, nil)
+			},
+			expectedStatus:   http.StatusAccepted,
+			expectedLocation: "/maximize/jobs/job1",
+		},
+		{
+			name:        "v2 envelope",
+			version:     handler.V2,
+			requestBody: `{"bookings":[` + booking + `],"options":{"objective":"revenue"}}`,
+			mock: func(m *mocks.MockJobService) {
+				m.EXPECT().
+					Submit(gomock.Any(), gomock.Len(1), gomock.Cond(func(opts domain.MaximizeOptions) bool {
+						return opts.Objective == domain.ObjectiveRevenue
+					})).
+					Return(&domain.Job{ID: "job1", Status: domain.JobQueued}, nil)
+			},
+			expectedStatus:   http.StatusAccepted,
+			expectedLocation: "/maximize/jobs/job1",
+		},
+		{
+			name:           "invalid json",
+			requestBody:    "invalid json",
+			mock:           func(m *mocks.MockJobService) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "too many bookings",
+			requestBody:    "[" + booking + "," + booking + "]",
+			mock:           func(m *mocks.MockJobService) {},
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedCode:   "too_many_bookings",
+		},
+		{
+			name:        "queue full",
+			requestBody: "[" + booking + "]",
+			mock: func(m *mocks.MockJobService) {
+				m.EXPECT().
+					Submit(gomock.Any(), gomock.Any(), gomock.Any()).
+					Return(nil, domain.ErrJobQueueFull)
+			},
+			expectedStatus: http.StatusServiceUnavailable,
+			expectedCode:   "job_queue_full",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockJobService := mocks.NewMockJobService(ctrl)
+			version := handler.WithAPIVersion(handler.V1)
+			if tt.version != 0 {
+				version = handler.WithAPIVersion(tt.version)
+			}
+			h, err := handler.NewJobHandler(mockJobService, handler.WithLimits(limits.Limits{MaxBookings: 1}), version)
+			require.NoError(t, err)
+
+			tt.mock(mockJobService)
+
+			req := httptest.NewRequest(http.MethodPost, "/maximize/jobs", bytes.NewBufferString(tt.requestBody))
+			w := httptest.NewRecorder()
+
+			h.HandlerSubmitJob(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			assert.Equal(t, tt.expectedLocation, w.Header().Get("Location"))
+
+			var response map[string]interface{}
+			require.NoError(t, json.NewDecoder(w.Body).Decode(&response))
+			if tt.expectedCode != "" {
+				assert.Equal(t, tt.expectedCode, response["code"])
+			}
+			if tt.expectedStatus == http.StatusAccepted {
+				assert.Equal(t, "job1", response["id"])
+				assert.Equal(t, "queued", response["status"])
+			}
+		})
+	}
+}
+
+func TestJobHandler_HandlerGetJob(t *testing.T) {
+	tests := []struct {
+		name           string
+		mock           func(*mocks.MockJobService)
+		expectedStatus int
+		expectedBody   map[string]interface{}
+	}{
+		{
+			name: "succeeded",
+			mock: func(m *mocks.MockJobService) {
+				m.EXPECT().
+					Get(gomock.Any(), "job1").
+					Return(&domain.Job{
+						ID:       "job1",
+						Status:   domain.JobSucceeded,
+						Progress: 1,
+						Result:   &domain.MaximizeResult{RequestIDs: []string{"bookata_XY123"}, TotalProfit: 40},
+					}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody: map[string]interface{}{
+				"id":       "job1",
+				"status":   "succeeded",
+				"progress": float64(1),
+			},
+		},
+		{
+			name: "running",
+			mock: func(m *mocks.MockJobService) {
+				m.EXPECT().
+					Get(gomock.Any(), "job1").
+					Return(&domain.Job{ID: "job1", Status: domain.JobRunning, Progress: 0.25}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody: map[string]interface{}{
+				"status":   "running",
+				"progress": 0.25,
+				"result":   nil,
+			},
+		},
+		{
+			name: "not found",
+			mock: func(m *mocks.MockJobService) {
+				m.EXPECT().
+					Get(gomock.Any(), "job1").
+					Return(nil, domain.ErrJobNotFound)
+			},
+			expectedStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockJobService := mocks.NewMockJobService(ctrl)
+			h, _ := handler.NewJobHandler(mockJobService)
+
+			tt.mock(mockJobService)
+
+			req := httptest.NewRequest(http.MethodGet, "/maximize/jobs/job1", nil)
+			req = mux.SetURLVars(req, map[string]string{"id": "job1"})
+			w := httptest.NewRecorder()
+
+			h.HandlerGetJob(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+
+			var response map[string]interface{}
+			require.NoError(t, json.NewDecoder(w.Body).Decode(&response))
+			for k, v := range tt.expectedBody {
+				assert.Equal(t, v, response[k])
+			}
+		})
+	}
+}
+
+func TestJobHandler_HandlerGetJob_Calendar(t *testing.T) {
+	checkIn := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name     string
+		job      *domain.Job
+		expected []string
+	}{
+		{
+			name: "succeeded",
+			job: &domain.Job{
+				ID:     "job1",
+				Status: domain.JobSucceeded,
+				Result: &domain.MaximizeResult{
+					RequestIDs: []string{"bookata_XY123"},
+					Selected:   domain.Bookings{{RequestID: "bookata_XY123", CheckIn: checkIn, Nights: 5}},
+				},
+			},
+			expected: []string{"UID:bookata_XY123"},
+		},
+		{
+			name:     "running",
+			job:      &domain.Job{ID: "job1", Status: domain.JobRunning},
+			expected: []string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockJobService := mocks.NewMockJobService(ctrl)
+			h, _ := handler.NewJobHandler(mockJobService)
+			mockJobService.EXPECT().Get(gomock.Any(), "job1").Return(tt.job, nil)
+
+			req := httptest.NewRequest(http.MethodGet, "/maximize/jobs/job1", nil)
+			req = mux.SetURLVars(req, map[string]string{"id": "job1"})
+			req.Header.Set("Accept", "text/calendar")
+			w := httptest.NewRecorder()
+
+			h.HandlerGetJob(w, req)
+
+			assert.Equal(t, http.StatusOK, w.Code)
+			assert.Equal(t, "text/calendar; charset=utf-8", w.Header().Get("Content-Type"))
+			var uids []string
+			for _, line := range strings.Split(w.Body.String(), "\r\n") {
+				if strings.HasPrefix(line, "UID:") {
+					uids = append(uids, line)
+				}
+			}
+			assert.ElementsMatch(t, tt.expected, uids)
+		})
+	}
+}
+
+func TestJobHandler_HandlerCancelJob(t *testing.T) {
+	tests := []struct {
+		name           string
+		mock           func(*mocks.MockJobService)
+		expectedStatus int
+	}{
+		{
+			name: "canceled",
+			mock: func(m *mocks.MockJobService) {
+				m.EXPECT().
+					Cancel(gomock.Any(), "job1").
+					Return(&domain.Job{ID: "job1", Status: domain.JobCanceled}, nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name: "already finished",
+			mock: func(m *mocks.MockJobService) {
+				m.EXPECT().
+					Cancel(gomock.Any(), "job1").
+					Return(nil, domain.ErrJobFinished)
+			},
+			expectedStatus: http.StatusConflict,
+		},
+		{
+			name: "not found",
+			mock: func(m *mocks.MockJobService) {
+				m.EXPECT().
+					Cancel(gomock.Any(), "job1").
+					Return(nil, domain.ErrJobNotFound)
+			},
+			expectedStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockJobService := mocks.NewMockJobService(ctrl)
+			h, _ := handler.NewJobHandler(mockJobService)
+
+			tt.mock(mockJobService)
+
+			req := httptest.NewRequest(http.MethodDelete, "/maximize/jobs/job1", nil)
+			req = mux.SetURLVars(req, map[string]string{"id": "job1"})
+			w := httptest.NewRecorder()
+
+			h.HandlerCancelJob(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+		})
+	}
+}
+
+func TestJobHandler_HandlerJobEvents(t *testing.T) {
+	start := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name           string
+		mock           func(*mocks.MockJobService)
+		expectedStatus int
+		expectedEvents []string
+	}{
+		{
+			name: "stream until succeeded",
+			mock: func(m *mocks.MockJobService) {
+				events := make(chan *domain.Job, 2)
+				events <- &domain.Job{ID: "job1", Status: domain.JobRunning, Progress: 0.5, BestProfit: 40, StartedAt: start}
+				events <- &domain.Job{
+					ID:         "job1",
+					Status:     domain.JobSucceeded,
+					Progress:   1,
+					BestProfit: 60,
+					Result:     &domain.MaximizeResult{RequestIDs: []string{"bookata_XY123"}, TotalProfit: 60},
+					StartedAt:  start,
+					UpdatedAt:  start.Add(1500 * time.Millisecond),
+				}
+				close(events)
+				m.EXPECT().
+					Watch(gomock.Any(), "job1").
+					Return(events, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedEvents: []string{"progress", "succeeded"},
+		},
+		{
+			name: "not found",
+			mock: func(m *mocks.MockJobService) {
+				m.EXPECT().
+					Watch(gomock.Any(), "job1").
+					Return(nil, domain.ErrJobNotFound)
+			},
+			expectedStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockJobService := mocks.NewMockJobService(ctrl)
+			h, _ := handler.NewJobHandler(mockJobService)
+
+			tt.mock(mockJobService)
+
+			req := httptest.NewRequest(http.MethodGet, "/maximize/jobs/job1/events", nil)
+			req = mux.SetURLVars(req, map[string]string{"id": "job1"})
+			w := httptest.NewRecorder()
+
+			h.HandlerJobEvents(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.expectedStatus != http.StatusOK {
+				return
+			}
+			assert.Equal(t, "text/event-stream", w.Header().Get("Content-Type"))
+			assert.True(t, w.Flushed)
+
+			var names []string
+			var data []map[string]interface{}
+			for _, event := range strings.Split(strings.TrimSpace(w.Body.String()), "\n\n") {
+				lines := strings.Split(event, "\n")
+				require.Len(t, lines, 2)
+				names = append(names, strings.TrimPrefix(lines[0], "event: "))
+				var payload map[string]interface{}
+				require.NoError(t, json.Unmarshal([]byte(strings.TrimPrefix(lines[1], "data: ")), &payload))
+				data = append(data, payload)
+			}
+			assert.Equal(t, tt.expectedEvents, names)
+			assert.Equal(t, 0.5, data[0]["progress"])
+			assert.Equal(t, float64(40), data[0]["best_profit"])
+			assert.Nil(t, data[0]["result"])
+			assert.Equal(t, float64(60), data[1]["best_profit"])
+			assert.Equal(t, float64(1500), data[1]["elapsed_ms"])
+			assert.NotNil(t, data[1]["result"])
+		})
+	}
+}
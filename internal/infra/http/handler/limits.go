@@ -0,0 +1,102 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/duksonn/stay-for-long/internal/infra/limits"
+)
+
+// Error codes reported in the body of the responses rejected by a limit
+const (
+	codeBodyTooLarge          = "body_too_large"
+	codeTooManyBookings       = "too_many_bookings"
+	codeOptimizerTimeExceeded = "optimizer_time_exceeded"
+)
+
+// Option configures optional behaviour of the handlers
+type Option func(*options)
+
+// options holds the optional configuration shared by the handlers
+type options struct {
+	limits     limits.Limits
+	csvHeaders map[string]string
+	version    APIVersion
+	tracer     trace.Tracer
+}
+
+// WithLimits sets the request limits enforced by the handler
+func WithLimits(l limits.Limits) Option {
+	return func(o *options) {
+		o.limits = l
+	}
+}
+
+// WithCSVHeaders sets aliases for the columns of CSV bodies, mapping a header name to the booking field
+// it holds, e.g. "Arrival" to "check_in". Fields can always be referred to by their own name
+func WithCSVHeaders(headers map[string]string) Option {
+	return func(o *options) {
+		o.csvHeaders = headers
+	}
+}
+
+// newOptions applies opts over the default options
+func newOptions(opts []Option) options {
+	o := options{version: V1, tracer: defaultTracer()}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	return o
+}
+
+// bodyReader returns the request body, failing with ErrBodyTooLarge once more than maxBytes are read
+// A zero maxBytes disables the limit
+func bodyReader(w http.ResponseWriter, r *http.Request, maxBytes int64) io.Reader {
+	if maxBytes > 0 {
+		r.Body = http.MaxBytesReader(w, r.Body, maxBytes)
+	}
+
+	return r.Body
+}
+
+// readBody reads the whole request body, failing with ErrBodyTooLarge once it exceeds maxBytes
+func readBody(w http.ResponseWriter, r *http.Request, maxBytes int64) ([]byte, error) {
+	body, err := io.ReadAll(bodyReader(w, r, maxBytes))
+	if err != nil {
+		return nil, bodyReadError(err)
+	}
+
+	return body, nil
+}
+
+// bodyReadError converts an error found while reading the request body
+func bodyReadError(err error) error {
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		return fmt.Errorf("%w: limit is %d bytes", ErrBodyTooLarge, maxBytesErr.Limit)
+	}
+
+	return ErrInvalidRequest
+}
+
+// writeRequestError maps an error found while reading a request to its HTTP status code
+// A body over the size limit answers 413 and too many bookings answer 422; any other error answers 400,
+// with the invalid_input code when it points to a line of the body
+func writeRequestError(w http.ResponseWriter, version APIVersion, err error) {
+	var lineErr *LineError
+	switch {
+	case errors.Is(err, ErrBodyTooLarge):
+		writeError(w, version, http.StatusRequestEntityTooLarge, codeBodyTooLarge, err)
+	case errors.Is(err, limits.ErrTooManyBookings):
+		writeError(w, version, http.StatusUnprocessableEntity, codeTooManyBookings, err)
+	case errors.As(err, &lineErr):
+		writeError(w, version, http.StatusBadRequest, codeInvalidInput, err)
+	default:
+		writeError(w, version, http.StatusBadRequest, codeInvalidRequest, err)
+	}
+}
@@ -0,0 +1,19 @@
+package handler
+
+import (
+	_ "embed" // OpenAPI document
+	"net/http"
+)
+
+// openAPISpec is the OpenAPI 3 document describing every endpoint of the HTTP API
+// It is maintained by hand next to the DTOs and checked against the handlers by the contract tests
+//
+//go:embed openapi.json
+var openAPISpec []byte
+
+// HandlerOpenAPI serves the OpenAPI document of the HTTP API
+func HandlerOpenAPI(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", contentTypeJSON)
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(openAPISpec)
+}
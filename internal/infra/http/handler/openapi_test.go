@@ -0,0 +1,308 @@
+package handler_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/getkin/kin-openapi/routers"
+	"github.com/getkin/kin-openapi/routers/gorillamux"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"github.com/duksonn/stay-for-long/internal/application"
+	"github.com/duksonn/stay-for-long/internal/domain"
+	"github.com/duksonn/stay-for-long/internal/infra/http/handler"
+	"github.com/duksonn/stay-for-long/internal/infra/limits"
+	"github.com/duksonn/stay-for-long/internal/mocks"
+)
+
+func init() {
+	openapi3filter.RegisterBodyDecoder("application/x-ndjson", openapi3filter.FileBodyDecoder)
+	openapi3filter.RegisterBodyDecoder("text/calendar", openapi3filter.FileBodyDecoder)
+}
+
+// loadOpenAPIRouter loads and validates the OpenAPI document and returns a router matching requests to its operations
+func loadOpenAPIRouter(t *testing.T) routers.Router {
+	t.Helper()
+
+	doc, err := openapi3.NewLoader().LoadFromFile("openapi.json")
+	require.NoError(t, err)
+	require.NoError(t, doc.Validate(context.Background()))
+	router, err := gorillamux.NewRouter(doc)
+	require.NoError(t, err)
+
+	return router
+}
+
+func TestHandlerOpenAPI(t *testing.T) {
+	spec, err := os.ReadFile("openapi.json")
+	require.NoError(t, err)
+
+	w := httptest.NewRecorder()
+	handler.HandlerOpenAPI(w, httptest.NewRequest(http.MethodGet, "/openapi.json", nil))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+	assert.JSONEq(t, string(spec), w.Body.String())
+}
+
+func TestOpenAPI_Contract(t *testing.T) {
+	spec := loadOpenAPIRouter(t)
+	bookings := `[
+		{"request_id":"bookata_XY123","check_in":"2020-01-01","nights":5,"selling_rate":200,"margin":20},
+		{"request_id":"kayete_PP234","check_in":"2020-01-04","nights":4,"selling_rate":156,"margin":5},
+		{"request_id":"acme_AAAAA","check_in":"2020-01-10","nights":4,"selling_rate":160,"margin":30}
+	]`
+	manyBookings := "[" + strings.TrimSuffix(strings.Repeat(`{"request_id":"bookata_XY123","check_in":"2020-01-01","nights":5},`, 5), ",") + "]"
+	now := time.Date(2020, 1, 1, 10, 0, 0, 0, time.UTC)
+	job := &domain.Job{
+		ID: "job1", Status: domain.JobSucceeded, Progress: 1, BestProfit: 88, CreatedAt: now, StartedAt: now, UpdatedAt: now,
+		Result: &domain.MaximizeResult{RequestIDs: []string{"bookata_XY123"}, Objective: domain.ObjectiveProfit, TotalProfit: 40, Optimal: true},
+	}
+
+	tests := []struct {
+		name           string
+		method         string
+		path           string
+		contentType    string
+		accept         string
+		body           string
+		version        handler.APIVersion
+		mock           func(*mocks.MockJobService)
+		expectedStatus int
+	}{
+		{name: "stats", method: http.MethodPost, path: "/stats", body: bookings, expectedStatus: http.StatusOK},
+		{name: "stats as csv", method: http.MethodPost, path: "/stats", accept: "text/csv", body: bookings, expectedStatus: http.StatusOK},
+		{
+			name: "stats from csv", method: http.MethodPost, path: "/stats", contentType: "text/csv",
+			body: "request_id,check_in,nights,selling_rate,margin\nbookata_XY123,2020-01-01,5,200,20\n", expectedStatus: http.StatusOK,
+		},
+		{
+			name: "stats grouped", method: http.MethodPost, path: "/stats?group_by=check_in_month", version: handler.V2,
+			body: bookings, expectedStatus: http.StatusOK,
+		},
+		{
+			name: "stats grouped as csv", method: http.MethodPost, path: "/stats?group_by=provider", version: handler.V2, accept: "text/csv",
+			body: bookings, expectedStatus: http.StatusOK,
+		},
+		{
+			name: "stats with invalid group", method: http.MethodPost, path: "/stats?group_by=city", version: handler.V2,
+			body: bookings, expectedStatus: http.StatusBadRequest,
+		},
+		{name: "stats with invalid json", method: http.MethodPost, path: "/stats", body: "{", expectedStatus: http.StatusBadRequest},
+		{
+			name: "stats with invalid ndjson line", method: http.MethodPost, path: "/stats", contentType: "application/x-ndjson",
+			body: "{\"request_id\":\"bookata_XY123\",\"nights\":\"five\"}\n", expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "stats with too many bookings", method: http.MethodPost, path: "/stats",
+			body: manyBookings, expectedStatus: http.StatusUnprocessableEntity,
+		},
+		{name: "maximize", method: http.MethodPost, path: "/maximize", body: bookings, expectedStatus: http.StatusOK},
+		{
+			name: "maximize with counter offers", method: http.MethodPost, path: "/maximize?counter_offers=true", version: handler.V2,
+			body: bookings, expectedStatus: http.StatusOK,
+		},
+		{
+			name: "maximize weighted", method: http.MethodPost, path: "/maximize?objective=weighted&profit_weight=1&occupancy_weight=2&tie_break=fewest_bookings",
+			version: handler.V2, body: bookings, expectedStatus: http.StatusOK,
+		},
+		{
+			name: "maximize heuristic", method: http.MethodPost, path: "/maximize?mode=heuristic&budget_ms=5", version: handler.V2,
+			body: bookings, expectedStatus: http.StatusOK,
+		},
+		{name: "maximize as calendar", method: http.MethodPost, path: "/maximize", accept: "text/calendar", body: bookings, expectedStatus: http.StatusOK},
+		{
+			name: "maximize with invalid objective", method: http.MethodPost, path: "/maximize?objective=fun", version: handler.V2,
+			body: bookings, expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "maximize envelope", method: http.MethodPost, path: "/maximize", version: handler.V2,
+			body:           `{"bookings":` + bookings + `,"options":{"objective":"weighted","weights":{"profit":1,"occupancy":2},"counter_offers":true}}`,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name: "maximize envelope with invalid options", method: http.MethodPost, path: "/maximize", version: handler.V2,
+			body: `{"bookings":` + bookings + `,"options":{"mode":"fast"}}`, expectedStatus: http.StatusBadRequest,
+		},
+		{name: "pareto", method: http.MethodPost, path: "/maximize/pareto", body: bookings, expectedStatus: http.StatusOK},
+		{
+			name: "pareto envelope", method: http.MethodPost, path: "/maximize/pareto", version: handler.V2,
+			body: `{"bookings":` + bookings + `}`, expectedStatus: http.StatusOK,
+		},
+		{name: "sensitivity", method: http.MethodPost, path: "/maximize/sensitivity", body: bookings, expectedStatus: http.StatusOK},
+		{
+			name: "scenarios", method: http.MethodPost, path: "/maximize/scenarios",
+			body: `{"bookings":` + bookings + `,"scenarios":[
+				{"name":"no kayete","patches":[{"op":"remove","request_id":"kayete_PP234"}]},
+				{"name":"blackout","patches":[{"op":"blackout","check_in":"2020-01-01","nights":3},{"op":"adjust_margin","provider":"acme","delta":5}]}
+			]}`,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name: "submit job", method: http.MethodPost, path: "/maximize/jobs", body: bookings,
+			mock: func(m *mocks.MockJobService) {
+				m.EXPECT().Submit(gomock.Any(), gomock.Any(), gomock.Any()).
+					Return(&domain.Job{ID: "job1", Status: domain.JobQueued, CreatedAt: now, UpdatedAt: now}, nil)
+			},
+			expectedStatus: http.StatusAccepted,
+		},
+		{
+			name: "get job", method: http.MethodGet, path: "/maximize/jobs/job1",
+			mock: func(m *mocks.MockJobService) {
+				m.EXPECT().Get(gomock.Any(), "job1").Return(job, nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name: "get unknown job", method: http.MethodGet, path: "/maximize/jobs/job2",
+			mock: func(m *mocks.MockJobService) {
+				m.EXPECT().Get(gomock.Any(), "job2").Return(nil, domain.ErrJobNotFound)
+			},
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name: "cancel finished job", method: http.MethodDelete, path: "/maximize/jobs/job1",
+			mock: func(m *mocks.MockJobService) {
+				m.EXPECT().Cancel(gomock.Any(), "job1").Return(nil, domain.ErrJobFinished)
+			},
+			expectedStatus: http.StatusConflict,
+		},
+		{
+			name: "graphql", method: http.MethodPost, path: "/graphql",
+			body: `{"query":"query($b:[BookingInput!]!){stats(bookings:$b){avgNight groups(by:PROVIDER){key count}}}",
+				"variables":{"b":[{"requestId":"bookata_XY123","checkIn":"2020-01-01","nights":5,"sellingRate":200,"margin":20}]}}`,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name: "graphql with errors", method: http.MethodPost, path: "/graphql",
+			body:           `{"query":"{maximize(bookings:[{requestId:\"bookata_XY123\",checkIn:\"01/01/2020\",sellingRate:200,margin:20}]){score}}"}`,
+			expectedStatus: http.StatusOK,
+		},
+		{name: "graphql without query", method: http.MethodPost, path: "/graphql", body: "{}", expectedStatus: http.StatusBadRequest},
+		{name: "openapi", method: http.MethodGet, path: "/openapi.json", expectedStatus: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockJobService := mocks.NewMockJobService(ctrl)
+			if tt.mock != nil {
+				tt.mock(mockJobService)
+			}
+			limits := handler.WithLimits(limits.Limits{MaxBookings: 4})
+			version := handler.WithAPIVersion(handler.V1)
+			if tt.version != 0 {
+				version = handler.WithAPIVersion(tt.version)
+			}
+			statsHandler, err := handler.NewStatsHandler(application.NewStatsService(), limits, version)
+			require.NoError(t, err)
+			jobHandler, err := handler.NewJobHandler(mockJobService, limits)
+			require.NoError(t, err)
+			graphQLHandler, err := handler.NewGraphQLHandler(application.NewStatsService(), limits)
+			require.NoError(t, err)
+
+			router := mux.NewRouter()
+			router.HandleFunc("/stats", statsHandler.HandlerCalculateStats).Methods(http.MethodPost)
+			router.HandleFunc("/maximize", statsHandler.HandlerMaximizeProfit).Methods(http.MethodPost)
+			router.HandleFunc("/maximize/pareto", statsHandler.HandlerParetoFrontier).Methods(http.MethodPost)
+			router.HandleFunc("/maximize/sensitivity", statsHandler.HandlerSensitivity).Methods(http.MethodPost)
+			router.HandleFunc("/maximize/scenarios", statsHandler.HandlerCompareScenarios).Methods(http.MethodPost)
+			router.HandleFunc("/maximize/jobs", jobHandler.HandlerSubmitJob).Methods(http.MethodPost)
+			router.HandleFunc("/maximize/jobs/{id}", jobHandler.HandlerGetJob).Methods(http.MethodGet)
+			router.HandleFunc("/maximize/jobs/{id}", jobHandler.HandlerCancelJob).Methods(http.MethodDelete)
+			router.HandleFunc("/graphql", graphQLHandler.HandlerGraphQL).Methods(http.MethodPost)
+			router.HandleFunc("/openapi.json", handler.HandlerOpenAPI).Methods(http.MethodGet)
+
+			var body io.Reader
+			if tt.body != "" {
+				body = bytes.NewBufferString(tt.body)
+			}
+			req := httptest.NewRequest(tt.method, tt.path, body)
+			if tt.body != "" {
+				contentType := tt.contentType
+				if contentType == "" {
+					contentType = "application/json"
+				}
+				req.Header.Set("Content-Type", contentType)
+			}
+			if tt.accept != "" {
+				req.Header.Set("Accept", tt.accept)
+			}
+
+			route, pathParams, err := spec.FindRoute(req)
+			require.NoError(t, err)
+			requestInput := &openapi3filter.RequestValidationInput{Request: req, PathParams: pathParams, Route: route}
+			if tt.expectedStatus < http.StatusBadRequest {
+				require.NoError(t, openapi3filter.ValidateRequest(context.Background(), requestInput))
+			}
+			if tt.body != "" {
+				req.Body = io.NopCloser(bytes.NewBufferString(tt.body))
+			}
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			require.Equal(t, tt.expectedStatus, w.Code, w.Body.String())
+
+			err = openapi3filter.ValidateResponse(context.Background(), &openapi3filter.ResponseValidationInput{
+				RequestValidationInput: requestInput,
+				Status:                 w.Code,
+				Header:                 w.Header(),
+				Body:                   io.NopCloser(bytes.NewReader(w.Body.Bytes())),
+				Options:                &openapi3filter.Options{IncludeResponseStatus: true},
+			})
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestOpenAPI_JobEvents(t *testing.T) {
+	doc, err := openapi3.NewLoader().LoadFromFile("openapi.json")
+	require.NoError(t, err)
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	now := time.Now()
+	jobs := make(chan *domain.Job, 2)
+	jobs <- &domain.Job{ID: "job1", Status: domain.JobRunning, Progress: 0.5, BestProfit: 40, CreatedAt: now, StartedAt: now, UpdatedAt: now}
+	jobs <- &domain.Job{
+		ID: "job1", Status: domain.JobSucceeded, Progress: 1, BestProfit: 88, CreatedAt: now, StartedAt: now, UpdatedAt: now,
+		Result: &domain.MaximizeResult{RequestIDs: []string{"bookata_XY123", "acme_AAAAA"}, Objective: domain.ObjectiveProfit, TotalProfit: 88, Optimal: true},
+	}
+	close(jobs)
+	mockJobService := mocks.NewMockJobService(ctrl)
+	mockJobService.EXPECT().Watch(gomock.Any(), "job1").Return((<-chan *domain.Job)(jobs), nil)
+	h, err := handler.NewJobHandler(mockJobService)
+	require.NoError(t, err)
+
+	req := mux.SetURLVars(httptest.NewRequest(http.MethodGet, "/maximize/jobs/job1/events", nil), map[string]string{"id": "job1"})
+	w := httptest.NewRecorder()
+	h.HandlerJobEvents(w, req)
+
+	var events int
+	for _, line := range strings.Split(w.Body.String(), "\n") {
+		data, ok := strings.CutPrefix(line, "data: ")
+		if !ok {
+			continue
+		}
+		var event interface{}
+		require.NoError(t, json.Unmarshal([]byte(data), &event))
+		assert.NoError(t, doc.Components.Schemas["JobEvent"].Value.VisitJSON(event))
+		events++
+	}
+	assert.Equal(t, 2, events)
+}
@@ -0,0 +1,49 @@
+package handler
+
+import (
+	"context"
+	"sync"
+)
+
+// requestLogKey is the context key of the RequestLog of a request
+type requestLogKey struct{}
+
+// RequestLog gathers what the handlers learn about a request for its access log
+// It is safe for concurrent use, as the fields of a GraphQL query are resolved in parallel
+type RequestLog struct {
+	mu       sync.Mutex
+	bookings int
+	counted  bool
+}
+
+// WithRequestLog returns a copy of ctx carrying log, for the handlers serving the request to fill
+func WithRequestLog(ctx context.Context, log *RequestLog) context.Context {
+	return context.WithValue(ctx, requestLogKey{}, log)
+}
+
+// Bookings returns the number of bookings the request held, reporting false when it held none
+// because the endpoint does not receive bookings or the body could not be decoded
+func (l *RequestLog) Bookings() (int, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.bookings, l.counted
+}
+
+// addBookings adds count bookings received by the request, doing nothing for a request without log
+func (l *RequestLog) addBookings(count int) {
+	if l == nil {
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.bookings += count
+	l.counted = true
+}
+
+// requestLog returns the RequestLog carried by ctx, or nil when there is none
+func requestLog(ctx context.Context) *RequestLog {
+	log, _ := ctx.Value(requestLogKey{}).(*RequestLog)
+	return log
+}
@@ -0,0 +1,66 @@
+package handler_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/duksonn/stay-for-long/internal/application"
+	"github.com/duksonn/stay-for-long/internal/infra/http/handler"
+)
+
+func TestRequestLog_Bookings(t *testing.T) {
+	graphQLBooking := `{requestId: \"bookata_XY123\", checkIn: \"2020-01-01\", nights: 5, sellingRate: 200, margin: 20}`
+	booking := `{"request_id":"bookata_XY123","check_in":"2020-01-01","nights":5,"selling_rate":200,"margin":20}`
+	statsHandler, err := handler.NewStatsHandler(application.NewStatsService())
+	require.NoError(t, err)
+	graphQLHandler, err := handler.NewGraphQLHandler(application.NewStatsService())
+	require.NoError(t, err)
+
+	tests := []struct {
+		name         string
+		handler      http.HandlerFunc
+		contentType  string
+		body         string
+		wantBookings int
+		wantCounted  bool
+	}{
+		{name: "json", handler: statsHandler.HandlerCalculateStats, body: "[" + booking + "," + booking + "]", wantBookings: 2, wantCounted: true},
+		{
+			name: "csv", handler: statsHandler.HandlerMaximizeProfit, contentType: "text/csv",
+			body: "request_id,check_in,nights\nbookata_XY123,2020-01-01,5\n", wantBookings: 1, wantCounted: true,
+		},
+		{
+			name: "scenarios", handler: statsHandler.HandlerCompareScenarios,
+			body: `{"bookings":[` + booking + `],"scenarios":[]}`, wantBookings: 1, wantCounted: true,
+		},
+		{
+			name: "graphql fields add up", handler: graphQLHandler.HandlerGraphQL,
+			body: `{"query":"{ a: bookings(bookings: [` + graphQLBooking + `]) { requestId } ` +
+				`b: stats(bookings: [` + graphQLBooking + `, ` + graphQLBooking + `]) { count } }"}`,
+			wantBookings: 3, wantCounted: true,
+		},
+		{name: "invalid body", handler: statsHandler.HandlerCalculateStats, body: "{"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			log := &handler.RequestLog{}
+			req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(tt.body))
+			req = req.WithContext(handler.WithRequestLog(context.Background(), log))
+			if tt.contentType != "" {
+				req.Header.Set("Content-Type", tt.contentType)
+			}
+			tt.handler(httptest.NewRecorder(), req)
+
+			bookings, counted := log.Bookings()
+			assert.Equal(t, tt.wantBookings, bookings)
+			assert.Equal(t, tt.wantCounted, counted)
+		})
+	}
+}
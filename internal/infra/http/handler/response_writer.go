@@ -0,0 +1,102 @@
+package handler
+
+import (
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/duksonn/stay-for-long/internal/domain"
+	"github.com/duksonn/stay-for-long/internal/infra/dto"
+)
+
+// contentTypeJSON is the default content type of the responses
+const contentTypeJSON = dto.ContentTypeJSON
+
+// csvResponse is implemented by the response DTOs that can also be rendered as CSV
+// The first record is the header
+type csvResponse interface {
+	CSVRecords() [][]string
+}
+
+// calendarResponse is implemented by the response DTOs that can also be rendered as an iCalendar feed
+type calendarResponse interface {
+	CalendarBookings() domain.Bookings
+}
+
+// writeResponse writes data in the format preferred by the Accept header of the request
+// Responses implementing csvResponse or calendarResponse are also offered as text/csv or text/calendar;
+// any other response, or a request without preference, gets JSON
+func writeResponse(w http.ResponseWriter, r *http.Request, statusCode int, data interface{}) {
+	offers := []string{contentTypeJSON}
+	csvData, isCSV := data.(csvResponse)
+	if isCSV {
+		offers = append(offers, dto.ContentTypeCSV)
+	}
+	calendarData, isCalendar := data.(calendarResponse)
+	if isCalendar {
+		offers = append(offers, dto.ContentTypeCalendar)
+	}
+	if len(offers) > 1 {
+		w.Header().Add("Vary", "Accept")
+	}
+
+	switch negotiateContentType(r.Header.Get("Accept"), offers) {
+	case dto.ContentTypeCSV:
+		writeCSVResponse(w, statusCode, csvData.CSVRecords())
+	case dto.ContentTypeCalendar:
+		writeCalendarResponse(w, statusCode, calendarData.CalendarBookings(), time.Now())
+	default:
+		writeJSONResponse(w, statusCode, data)
+	}
+}
+
+// negotiateContentType picks the offer with the highest quality in an Accept header
+// Earlier offers win ties, so wildcards and missing or malformed headers get the first one
+func negotiateContentType(accept string, offers []string) string {
+	qualities := make([]float64, len(offers))
+	for _, mediaRange := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(mediaRange)
+		if err != nil {
+			continue
+		}
+		quality := 1.0
+		if q, ok := params["q"]; ok {
+			if quality, err = strconv.ParseFloat(q, 64); err != nil {
+				continue
+			}
+		}
+		for i, offer := range offers {
+			group, _, _ := strings.Cut(offer, "/")
+			if mediaType == offer || mediaType == group+"/*" || mediaType == "*/*" {
+				qualities[i] = max(qualities[i], quality)
+			}
+		}
+	}
+
+	best := 0
+	for i, quality := range qualities {
+		if quality > qualities[best] {
+			best = i
+		}
+	}
+
+	return offers[best]
+}
+
+// writeCSVResponse writes records as a UTF-8 CSV document
+// Text cells that spreadsheets would evaluate as formulas are escaped with a leading quote
+func writeCSVResponse(w http.ResponseWriter, statusCode int, records [][]string) {
+	w.Header().Set("Content-Type", dto.ContentTypeCSV+"; charset=utf-8")
+	w.WriteHeader(statusCode)
+	_ = dto.WriteCSV(w, records)
+}
+
+// writeCalendarResponse writes bookings as an iCalendar feed with one all-day or timed event per booking
+// Events keep the request ID as UID, so subscribers update them in place when the selection changes
+func writeCalendarResponse(w http.ResponseWriter, statusCode int, bookings domain.Bookings, now time.Time) {
+	w.Header().Set("Content-Type", dto.ContentTypeCalendar+"; charset=utf-8")
+	w.WriteHeader(statusCode)
+	_ = dto.WriteCalendar(w, bookings, now)
+}
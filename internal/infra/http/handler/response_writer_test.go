@@ -0,0 +1,127 @@
+package handler_test
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"github.com/duksonn/stay-for-long/internal/domain"
+	"github.com/duksonn/stay-for-long/internal/infra/http/handler"
+	"github.com/duksonn/stay-for-long/internal/mocks"
+)
+
+func TestStatsHandler_CSVResponses(t *testing.T) {
+	checkIn := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	requestBody := `[
+		{"request_id":"bookata_XY123","check_in":"2020-01-01","nights":5,"selling_rate":200,"margin":20},
+		{"request_id":"=cmd_AA930","check_in":"2020-01-04","nights":4,"selling_rate":150,"margin":6},
+		{"request_id":"acme_AAAAA","check_in":"2020-01-10","nights":4,"selling_rate":160,"margin":30}
+	]`
+
+	tests := []struct {
+		name                string
+		path                string
+		accept              string
+		mock                func(*mocks.MockStatsService)
+		expectedContentType string
+		expectedBody        string
+	}{
+		{
+			name:   "stats as csv",
+			path:   "/stats",
+			accept: "text/csv",
+			mock: func(m *mocks.MockStatsService) {
+				m.EXPECT().
+					CalculateStats(gomock.Any(), gomock.Any()).
+					Return(&domain.StatsResult{AvgNight: 10.5, MinNight: 2.25, MaxNight: 12}, nil)
+			},
+			expectedContentType: "text/csv; charset=utf-8",
+			expectedBody:        "group,avg_night,min_night,max_night\nall,10.5,2.25,12\n",
+		},
+		{
+			name:   "maximize as csv",
+			path:   "/maximize",
+			accept: "application/json;q=0.5, text/csv",
+			mock: func(m *mocks.MockStatsService) {
+				m.EXPECT().
+					MaximizeProfit(gomock.Any(), gomock.Any(), gomock.Any()).
+					Return(&domain.MaximizeResult{
+						RequestIDs: []string{"bookata_XY123", "acme_AAAAA"},
+						Selected: domain.Bookings{
+							{RequestID: "bookata_XY123", CheckIn: checkIn, Nights: 5, SellingRate: 200, Margin: 20},
+							{RequestID: "acme_AAAAA", CheckIn: checkIn.AddDate(0, 0, 9), Nights: 4, SellingRate: 160, Margin: 30},
+						},
+						Rejected: []*domain.RejectedBooking{{
+							Booking: &domain.Booking{
+								RequestID: "=cmd_AA930", CheckIn: checkIn.AddDate(0, 0, 3), Nights: 4, SellingRate: 150, Margin: 6,
+							},
+							CounterOfferRate: 666.59,
+						}},
+					}, nil)
+			},
+			expectedContentType: "text/csv; charset=utf-8",
+			expectedBody: "request_id,status,check_in,check_out,nights,selling_rate,margin,profit,profit_per_night,counter_offer_rate\n" +
+				"bookata_XY123,accepted,2020-01-01,2020-01-06,5,200,20,40,8,\n" +
+				"acme_AAAAA,accepted,2020-01-10,2020-01-14,4,160,30,48,12,\n" +
+				"'=cmd_AA930,rejected,2020-01-04,2020-01-08,4,150,6,9,2.25,666.59\n",
+		},
+		{
+			name:   "wildcard prefers json",
+			path:   "/stats",
+			accept: "*/*",
+			mock: func(m *mocks.MockStatsService) {
+				m.EXPECT().
+					CalculateStats(gomock.Any(), gomock.Any()).
+					Return(&domain.StatsResult{AvgNight: 10.5}, nil)
+			},
+			expectedContentType: "application/json",
+			expectedBody:        `{"avg_night":10.5,"min_night":0,"max_night":0}` + "\n",
+		},
+		{
+			name:   "csv with lower quality than json",
+			path:   "/stats",
+			accept: "text/csv;q=0.2, application/json",
+			mock: func(m *mocks.MockStatsService) {
+				m.EXPECT().
+					CalculateStats(gomock.Any(), gomock.Any()).
+					Return(&domain.StatsResult{AvgNight: 10.5}, nil)
+			},
+			expectedContentType: "application/json",
+			expectedBody:        `{"avg_night":10.5,"min_night":0,"max_night":0}` + "\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockStatsService := mocks.NewMockStatsService(ctrl)
+			h, err := handler.NewStatsHandler(mockStatsService)
+			require.NoError(t, err)
+
+			tt.mock(mockStatsService)
+
+			routes := map[string]http.HandlerFunc{
+				"/stats":    h.HandlerCalculateStats,
+				"/maximize": h.HandlerMaximizeProfit,
+			}
+			req := httptest.NewRequest(http.MethodPost, tt.path, bytes.NewBufferString(requestBody))
+			req.Header.Set("Accept", tt.accept)
+			w := httptest.NewRecorder()
+
+			routes[tt.path](w, req)
+
+			assert.Equal(t, http.StatusOK, w.Code)
+			assert.Equal(t, tt.expectedContentType, w.Header().Get("Content-Type"))
+			assert.Equal(t, "Accept", w.Header().Get("Vary"))
+			assert.Equal(t, tt.expectedBody, w.Body.String())
+		})
+	}
+}
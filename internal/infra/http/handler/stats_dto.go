@@ -1,29 +1,258 @@
 package handler
 
+import (
+	"math"
+
+	"github.com/duksonn/stay-for-long/internal/domain"
+	"github.com/duksonn/stay-for-long/internal/infra/dto"
+)
+
 // bookingRequest represents the structure of a booking request as received from the HTTP API
-// It contains all necessary information to create a domain.Booking object
-type bookingRequest struct {
-	RequestID   string  `json:"request_id"`   // Unique identifier for the booking request
-	CheckIn     string  `json:"check_in"`     // Check-in date in YYYY-MM-DD format
-	Nights      int     `json:"nights"`       // Number of nights for the stay
-	SellingRate float64 `json:"selling_rate"` // Total selling rate for the entire stay
-	Margin      float64 `json:"margin"`       // Profit margin percentage
+type bookingRequest = dto.Booking
+
+// bookingsEnvelope represents the JSON body accepted from V2 on in place of a bare list of bookings
+// It holds the bookings together with the options they are processed with
+type bookingsEnvelope struct {
+	Bookings []bookingRequest        `json:"bookings"` // Bookings to process
+	Options  *maximizeOptionsRequest `json:"options"`  // Options replacing those of the query string, when present
+}
+
+// maximizeOptionsRequest represents the maximize options of a JSON body, named like the query parameters
+type maximizeOptionsRequest struct {
+	Objective          string          `json:"objective"`           // Objective to maximize, profit when empty
+	Weights            *weightsRequest `json:"weights"`             // Weights of the weighted objective
+	TieBreak           string          `json:"tie_break"`           // Policy settling ties, canonical when empty
+	PreferredProviders []string        `json:"preferred_providers"` // Providers in priority order for preferred_provider
+	CounterOffers      bool            `json:"counter_offers"`      // Whether to compute counter-offers for rejected bookings
+	Mode               string          `json:"mode"`                // Solver mode, exact when empty
+	BudgetMS           int             `json:"budget_ms"`           // Heuristic time budget in milliseconds
+}
+
+// weightsRequest represents the weights of the weighted objective
+type weightsRequest struct {
+	Profit    float64 `json:"profit"`    // Weight of the total profit
+	Revenue   float64 `json:"revenue"`   // Weight of the total selling rate
+	Occupancy float64 `json:"occupancy"` // Weight of the occupied nights
 }
 
 // statsResultResponse represents the structure of the stats calculation response
 // It contains the calculated statistics for a set of bookings
-type statsResultResponse struct {
-	AvgNight float64 `json:"avg_night"` // Average nightly rate
-	MinNight float64 `json:"min_night"` // Minimum nightly rate
-	MaxNight float64 `json:"max_night"` // Maximum nightly rate
-}
+type statsResultResponse = dto.StatsResult
+
+// statsGroupResponse represents the statistics of the bookings sharing a group key
+type statsGroupResponse = dto.StatsGroup
 
 // maximizeResultResponse represents the structure of the profit maximization response
 // It contains the optimal booking combination and its associated statistics
-type maximizeResultResponse struct {
+type maximizeResultResponse = dto.MaximizeResult
+
+// maximizeResultV1Response represents the profit maximization response of V1, kept as it was first released
+// CSV and iCalendar renderings are those of maximizeResultResponse
+type maximizeResultV1Response struct {
 	RequestIDs  []string `json:"request_ids"`  // List of request IDs that maximize profit
 	TotalProfit float64  `json:"total_profit"` // Total profit for the selected bookings
 	AvgNight    float64  `json:"avg_night"`    // Average nightly rate for selected bookings
 	MinNight    float64  `json:"min_night"`    // Minimum nightly rate for selected bookings
 	MaxNight    float64  `json:"max_night"`    // Maximum nightly rate for selected bookings
+
+	result maximizeResultResponse // Full result, used by the CSV and iCalendar renderings
+}
+
+// paretoResultResponse represents the structure of the pareto frontier response
+// Each point is a selection described like a maximizeResultResponse, ordered by occupied nights
+type paretoResultResponse struct {
+	Points []maximizeResultResponse `json:"points"` // Non-dominated selections
+}
+
+// sensitivityResultResponse represents the structure of the sensitivity analysis response
+// It contains the optimal selection and the sensitivity of every booking
+type sensitivityResultResponse struct {
+	Selection maximizeResultResponse       `json:"selection"` // Optimal selection the analysis refers to
+	Bookings  []bookingSensitivityResponse `json:"bookings"`  // Sensitivity of each booking
+}
+
+// bookingSensitivityResponse represents how far a booking's values can move before the selection changes
+// For accepted bookings the deltas are the maximum decrease, for rejected bookings the required increase.
+// A null delta means that no change of that value alters the selection
+type bookingSensitivityResponse struct {
+	RequestID        string   `json:"request_id"`         // Request ID of the booking
+	Accepted         bool     `json:"accepted"`           // Whether the booking belongs to the selection
+	SellingRateDelta *float64 `json:"selling_rate_delta"` // Selling rate change that alters the selection
+	MarginDelta      *float64 `json:"margin_delta"`       // Margin change that alters the selection
+}
+
+// scenariosRequest represents the body of a what-if comparison
+// It contains the base bookings and the named scenarios built by patching them
+type scenariosRequest struct {
+	Bookings  []bookingRequest        `json:"bookings"`  // Base bookings
+	Scenarios []scenarioRequest       `json:"scenarios"` // Scenarios compared against the base
+	Options   *maximizeOptionsRequest `json:"options"`   // Options replacing those of the query string, from V2 on
+}
+
+// scenarioRequest represents a named list of patches applied, in order, to the base bookings
+type scenarioRequest struct {
+	Name    string         `json:"name"`    // Unique scenario name
+	Patches []patchRequest `json:"patches"` // Changes applied to the base bookings
+}
+
+// patchRequest represents a single change of a scenario, the fields used depend on Op:
+// remove uses request_id, adjust_margin uses provider and delta, blackout uses check_in with
+// check_out or nights and an optional timezone, and add uses booking
+type patchRequest struct {
+	Op        string          `json:"op"`         // One of remove, adjust_margin, blackout or add
+	RequestID string          `json:"request_id"` // Booking removed by remove
+	Provider  string          `json:"provider"`   // Provider adjusted by adjust_margin, all when empty
+	Delta     float64         `json:"delta"`      // Margin percentage points added by adjust_margin
+	CheckIn   string          `json:"check_in"`   // First blackout date in YYYY-MM-DD format
+	CheckOut  string          `json:"check_out"`  // Date the blackout ends in YYYY-MM-DD format
+	Nights    int             `json:"nights"`     // Blackout length, alternative to check_out
+	Timezone  string          `json:"timezone"`   // Optional IANA timezone of the blackout dates
+	Booking   *bookingRequest `json:"booking"`    // Booking added by add
+}
+
+// scenarioComparisonResponse represents the structure of the what-if comparison response
+type scenarioComparisonResponse struct {
+	Base      scenarioResultResponse   `json:"base"`      // Result for the unpatched bookings
+	Scenarios []scenarioResultResponse `json:"scenarios"` // Result for each scenario, in request order
+}
+
+// scenarioResultResponse represents the optimal selection and stats of a scenario
+type scenarioResultResponse struct {
+	Name     string                 `json:"name"`           // Scenario name, base for the unpatched bookings
+	Maximize maximizeResultResponse `json:"maximize"`       // Optimal selection
+	Stats    statsResultResponse    `json:"stats"`          // Stats over all the scenario bookings
+	Diff     *scenarioDiffResponse  `json:"diff,omitempty"` // Differences against the base, absent for the base
+}
+
+// scenarioDiffResponse represents the differences of a scenario against the base, as scenario minus base
+type scenarioDiffResponse struct {
+	Score        float64             `json:"score"`         // Objective value difference
+	TotalProfit  float64             `json:"total_profit"`  // Total profit difference
+	TotalRevenue float64             `json:"total_revenue"` // Total selling rate difference
+	TotalNights  int                 `json:"total_nights"`  // Occupied nights difference
+	AvgNight     float64             `json:"avg_night"`     // Average nightly rate difference of the selection
+	MinNight     float64             `json:"min_night"`     // Minimum nightly rate difference of the selection
+	MaxNight     float64             `json:"max_night"`     // Maximum nightly rate difference of the selection
+	Stats        statsResultResponse `json:"stats"`         // Stats difference over all bookings
+	Added        []string            `json:"added"`         // Request IDs entering the selection
+	Removed      []string            `json:"removed"`       // Request IDs leaving the selection
+}
+
+// newMaximizeResultResponse maps a domain.MaximizeResult to the HTTP representation of the given version
+func newMaximizeResultResponse(version APIVersion, result *domain.MaximizeResult) any {
+	response := dto.NewMaximizeResult(result)
+	if version >= V2 {
+		return response
+	}
+
+	return maximizeResultV1Response{
+		RequestIDs:  response.RequestIDs,
+		TotalProfit: response.TotalProfit,
+		AvgNight:    response.AvgNight,
+		MinNight:    response.MinNight,
+		MaxNight:    response.MaxNight,
+		result:      response,
+	}
+}
+
+// CSVRecords renders the full result, as in V2
+func (r maximizeResultV1Response) CSVRecords() [][]string {
+	return r.result.CSVRecords()
+}
+
+// CalendarBookings returns the accepted bookings, as in V2
+func (r maximizeResultV1Response) CalendarBookings() domain.Bookings {
+	return r.result.CalendarBookings()
+}
+
+// newSensitivityResultResponse maps a domain.SensitivityResult to its HTTP representation
+func newSensitivityResultResponse(result *domain.SensitivityResult) sensitivityResultResponse {
+	response := sensitivityResultResponse{
+		Selection: dto.NewMaximizeResult(result.Selection),
+		Bookings:  make([]bookingSensitivityResponse, 0, len(result.Bookings)),
+	}
+	for _, s := range result.Bookings {
+		response.Bookings = append(response.Bookings, bookingSensitivityResponse{
+			RequestID:        s.Booking.RequestID,
+			Accepted:         s.Accepted,
+			SellingRateDelta: finiteOrNil(s.SellingRateDelta),
+			MarginDelta:      finiteOrNil(s.MarginDelta),
+		})
+	}
+
+	return response
+}
+
+// finiteOrNil returns nil for infinite values, which cannot be encoded as JSON numbers
+func finiteOrNil(v float64) *float64 {
+	if math.IsInf(v, 0) {
+		return nil
+	}
+
+	return &v
+}
+
+// newStatsGroupsResponse maps the stats of each group to their HTTP representation
+func newStatsGroupsResponse(groups []statsGroup) []statsGroupResponse {
+	response := make([]statsGroupResponse, 0, len(groups))
+	for _, g := range groups {
+		response = append(response, statsGroupResponse{
+			Key:      g.key,
+			Count:    g.count,
+			AvgNight: g.stats.AvgNight,
+			MinNight: g.stats.MinNight,
+			MaxNight: g.stats.MaxNight,
+		})
+	}
+
+	return response
+}
+
+// newScenarioComparisonResponse maps a domain.ScenarioComparison to its HTTP representation
+func newScenarioComparisonResponse(comparison *domain.ScenarioComparison) scenarioComparisonResponse {
+	response := scenarioComparisonResponse{
+		Base:      newScenarioResultResponse(comparison.Base),
+		Scenarios: make([]scenarioResultResponse, 0, len(comparison.Scenarios)),
+	}
+	for _, s := range comparison.Scenarios {
+		response.Scenarios = append(response.Scenarios, newScenarioResultResponse(s))
+	}
+
+	return response
+}
+
+// newScenarioResultResponse maps a domain.ScenarioResult to its HTTP representation
+func newScenarioResultResponse(result *domain.ScenarioResult) scenarioResultResponse {
+	response := scenarioResultResponse{
+		Name:     result.Name,
+		Maximize: dto.NewMaximizeResult(result.Maximize),
+		Stats:    dto.NewStatsResult(result.Stats),
+	}
+	if d := result.Diff; d != nil {
+		response.Diff = &scenarioDiffResponse{
+			Score:        d.Score,
+			TotalProfit:  d.TotalProfit,
+			TotalRevenue: d.TotalRevenue,
+			TotalNights:  d.TotalNights,
+			AvgNight:     d.AvgNight,
+			MinNight:     d.MinNight,
+			MaxNight:     d.MaxNight,
+			Stats:        dto.NewStatsResult(&d.Stats),
+			Added:        d.Added,
+			Removed:      d.Removed,
+		}
+	}
+
+	return response
+}
+
+// errorResponse represents the structure of an error response that carries a machine readable code
+type errorResponse struct {
+	Code  string `json:"code"`  // Stable identifier of the error, e.g. too_many_bookings
+	Error string `json:"error"` // Human readable description of the error
+}
+
+// newErrorResponse converts an error to its response DTO
+func newErrorResponse(code string, err error) errorResponse {
+	return errorResponse{Code: code, Error: err.Error()}
 }
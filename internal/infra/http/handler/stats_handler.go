@@ -1,13 +1,22 @@
 package handler
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
-	"io"
+	"fmt"
+	"mime"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 
+	"go.opentelemetry.io/otel/trace"
+
 	"github.com/duksonn/stay-for-long/internal/domain"
+	"github.com/duksonn/stay-for-long/internal/infra/dto"
+	"github.com/duksonn/stay-for-long/internal/infra/limits"
 	"github.com/duksonn/stay-for-long/internal/ports"
 )
 
@@ -17,112 +26,448 @@ var (
 	// ErrInvalidRequest is returned when the request body is invalid
 	ErrInvalidRequest = errors.New("invalid request body")
 	// ErrInvalidJSON is returned when the json is invalid
-	ErrInvalidJSON = errors.New("invalid request json")
+	ErrInvalidJSON = dto.ErrInvalidJSON
 	// ErrInvalidDateFormat is returned when the date has invalid format
-	ErrInvalidDateFormat = errors.New("invalid date format")
+	ErrInvalidDateFormat = dto.ErrInvalidDateFormat
+	// ErrInvalidTimeFormat is returned when a time of day has invalid format
+	ErrInvalidTimeFormat = dto.ErrInvalidTimeFormat
+	// ErrInvalidTimezone is returned when the timezone is not a known IANA location
+	ErrInvalidTimezone = dto.ErrInvalidTimezone
+	// ErrInvalidCheckOut is returned when the check-out is not after the check-in or contradicts nights
+	ErrInvalidCheckOut = dto.ErrInvalidCheckOut
+	// ErrInvalidWeightFormat is returned when an objective weight is not a number
+	ErrInvalidWeightFormat = errors.New("invalid weight format")
+	// ErrInvalidPatch is returned when a scenario patch has an unknown operation
+	ErrInvalidPatch = errors.New("invalid scenario patch")
+	// ErrInvalidCounterOffers is returned when the counter_offers flag is not a boolean
+	ErrInvalidCounterOffers = errors.New("invalid counter_offers flag")
+	// ErrInvalidBudgetFormat is returned when the heuristic budget is not a whole number of milliseconds
+	ErrInvalidBudgetFormat = errors.New("invalid budget_ms format")
+	// ErrInvalidNumberFormat is returned when a numeric CSV value is not a number
+	ErrInvalidNumberFormat = dto.ErrInvalidNumberFormat
+	// ErrInvalidCSV is returned when a CSV body is malformed
+	ErrInvalidCSV = dto.ErrInvalidCSV
+	// ErrInvalidCSVHeaders is returned when the CSV header mapping or the header of a CSV body is unusable
+	ErrInvalidCSVHeaders = dto.ErrInvalidCSVHeaders
+	// ErrInvalidCalendar is returned when an iCalendar body is malformed
+	ErrInvalidCalendar = dto.ErrInvalidCalendar
+	// ErrBodyTooLarge is returned when the request body exceeds the configured size
+	ErrBodyTooLarge = errors.New("request body too large")
 )
 
 // StatsHandler handles HTTP requests for stats-related operations
 // It provides endpoints for calculating booking statistics and maximizing profit
 type StatsHandler struct {
 	statsService ports.StatsService
+	limits       limits.Limits
+	columns      dto.CSVColumns
+	version      APIVersion
+	tracer       trace.Tracer
 }
 
 // NewStatsHandler creates a new instance of StatsHandler
-// Returns ErrNilStatsService if the stats service is nil
-func NewStatsHandler(statsSvc ports.StatsService) (*StatsHandler, error) {
+// Returns ErrNilStatsService if the stats service is nil and ErrInvalidCSVHeaders if
+// the CSV header aliases target unknown fields
+func NewStatsHandler(statsSvc ports.StatsService, opts ...Option) (*StatsHandler, error) {
 	if statsSvc == nil {
 		return nil, ErrNilStatsService
 	}
 
-	return &StatsHandler{statsService: statsSvc}, nil
+	o := newOptions(opts)
+	columns, err := dto.NewCSVColumns(o.csvHeaders)
+	if err != nil {
+		return nil, err
+	}
+
+	return &StatsHandler{statsService: statsSvc, limits: o.limits, columns: columns, version: o.version, tracer: o.tracer}, nil
 }
 
 // HandlerCalculateStats processes HTTP requests to calculate booking statistics
-// It accepts a list of booking requests and returns average, minimum, and maximum nightly rates
+// It accepts a list of booking requests and returns average, minimum, and maximum nightly rates,
+// broken down, from V2 on, by provider, property or check-in month when the group_by query parameter asks for it
 func (h *StatsHandler) HandlerCalculateStats(w http.ResponseWriter, r *http.Request) {
-	var dtos []bookingRequest
-	body, err := io.ReadAll(r.Body)
+	var key func(*domain.Booking) string
+	if h.version >= V2 {
+		var err error
+		if key, err = parseGroupBy(r); err != nil {
+			writeRequestError(w, h.version, err)
+			return
+		}
+	}
+	requests, _, err := h.decodeRequest(w, r, nil)
+	if err != nil {
+		writeRequestError(w, h.version, err)
+		return
+	}
+
+	ctx, cancel := h.limits.OptimizerContext(r.Context())
+	defer cancel()
+
+	stats, err := h.statsService.CalculateStats(ctx, requests)
+	if err != nil {
+		writeServiceError(w, h.version, err)
+		return
+	}
+	response := dto.NewStatsResult(stats)
+	if key != nil {
+		groups, err := calculateGroupStats(ctx, h.statsService, requests, key)
+		if err != nil {
+			writeServiceError(w, h.version, err)
+			return
+		}
+		response.Groups = newStatsGroupsResponse(groups)
+	}
+	writeResponse(w, r, http.StatusOK, response)
+}
+
+// HandlerMaximizeProfit processes HTTP requests to find the optimal booking combination
+// that maximizes the requested objective while avoiding booking overlaps
+// V1 keeps its original contract: the options are ignored and the result is the most profitable selection
+func (h *StatsHandler) HandlerMaximizeProfit(w http.ResponseWriter, r *http.Request) {
+	limitsFor := h.modeLimits
+	if h.version < V2 {
+		limitsFor = nil
+	}
+	requests, opts, err := h.decodeRequest(w, r, limitsFor)
 	if err != nil {
-		writeJSONResponse(w, http.StatusBadRequest, ErrInvalidRequest)
+		writeRequestError(w, h.version, err)
 		return
 	}
-	if err := json.Unmarshal(body, &dtos); err != nil {
-		writeJSONResponse(w, http.StatusBadRequest, ErrInvalidJSON)
+
+	ctx, cancel := h.limits.OptimizerContext(r.Context())
+	defer cancel()
+
+	result, err := h.statsService.MaximizeProfit(ctx, requests, opts)
+	if err != nil {
+		writeServiceError(w, h.version, err)
 		return
 	}
+	writeResponse(w, r, http.StatusOK, newMaximizeResultResponse(h.version, result))
+}
 
-	requests, err := parseBookingRequests(dtos)
+// HandlerParetoFrontier processes HTTP requests to find the selections that trade profit
+// against occupied nights without being dominated by any other selection
+func (h *StatsHandler) HandlerParetoFrontier(w http.ResponseWriter, r *http.Request) {
+	requests, _, err := h.decodeRequest(w, r, nil)
 	if err != nil {
-		writeJSONResponse(w, http.StatusBadRequest, err)
+		writeRequestError(w, h.version, err)
 		return
 	}
 
-	stats := h.statsService.CalculateStats(requests)
-	response := statsResultResponse{
-		AvgNight: stats.AvgNight,
-		MinNight: stats.MinNight,
-		MaxNight: stats.MaxNight,
+	ctx, cancel := h.limits.OptimizerContext(r.Context())
+	defer cancel()
+
+	frontier, err := h.statsService.ParetoFrontier(ctx, requests)
+	if err != nil {
+		writeServiceError(w, h.version, err)
+		return
+	}
+	response := paretoResultResponse{Points: make([]maximizeResultResponse, 0, len(frontier))}
+	for _, point := range frontier {
+		response.Points = append(response.Points, dto.NewMaximizeResult(point))
 	}
 	writeJSONResponse(w, http.StatusOK, response)
 }
 
-// HandlerMaximizeProfit processes HTTP requests to find the optimal booking combination
-// that maximizes profit while avoiding booking overlaps
-func (h *StatsHandler) HandlerMaximizeProfit(w http.ResponseWriter, r *http.Request) {
-	var dtos []bookingRequest
-	body, err := io.ReadAll(r.Body)
+// HandlerSensitivity processes HTTP requests to compute the optimal booking combination together with
+// how far each booking's selling rate or margin can move before that combination changes
+func (h *StatsHandler) HandlerSensitivity(w http.ResponseWriter, r *http.Request) {
+	requests, opts, err := h.decodeRequest(w, r, h.exactLimits)
 	if err != nil {
-		writeJSONResponse(w, http.StatusBadRequest, ErrInvalidRequest)
+		writeRequestError(w, h.version, err)
 		return
 	}
-	if err := json.Unmarshal(body, &dtos); err != nil {
-		writeJSONResponse(w, http.StatusBadRequest, ErrInvalidJSON)
+
+	ctx, cancel := h.limits.OptimizerContext(r.Context())
+	defer cancel()
+
+	result, err := h.statsService.Sensitivity(ctx, requests, opts)
+	if err != nil {
+		writeServiceError(w, h.version, err)
 		return
 	}
+	writeJSONResponse(w, http.StatusOK, newSensitivityResultResponse(result))
+}
 
-	requests, err := parseBookingRequests(dtos)
+// HandlerCompareScenarios processes HTTP requests to compare a base list of bookings against named what-if
+// scenarios, returning the optimal selection and stats of each one and its differences against the base
+func (h *StatsHandler) HandlerCompareScenarios(w http.ResponseWriter, r *http.Request) {
+	span := startDecode(r.Context(), h.tracer, "")
+	base, scenarios, opts, err := h.decodeScenarios(w, r)
+	endDecode(r.Context(), span, len(base), err)
 	if err != nil {
-		writeJSONResponse(w, http.StatusBadRequest, err)
+		writeRequestError(w, h.version, err)
 		return
 	}
 
-	result := h.statsService.MaximizeProfit(requests)
-	response := maximizeResultResponse{
-		RequestIDs:  result.RequestIDs,
-		TotalProfit: result.TotalProfit,
-		AvgNight:    result.AvgNight,
-		MinNight:    result.MinNight,
-		MaxNight:    result.MaxNight,
+	ctx, cancel := h.limits.OptimizerContext(r.Context())
+	defer cancel()
+
+	comparison, err := h.statsService.CompareScenarios(ctx, base, scenarios, opts)
+	if err != nil {
+		writeServiceError(w, h.version, err)
+		return
 	}
-	writeJSONResponse(w, http.StatusOK, response)
+	writeJSONResponse(w, http.StatusOK, newScenarioComparisonResponse(comparison))
+}
+
+// decodeRequest reads the bookings of a request together with the options they are processed with, as decodeBody
+// A nil limitsFor leaves the options unread and enforces h.limits
+func (h *StatsHandler) decodeRequest(w http.ResponseWriter, r *http.Request,
+	limitsFor func(domain.MaximizeOptions) (limits.Limits, error)) ([]*domain.Booking, domain.MaximizeOptions, error) {
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	span := startDecode(r.Context(), h.tracer, mediaType)
+	requests, opts, err := decodeBody(w, r, mediaType, h.version, h.limits, h.columns, limitsFor)
+	endDecode(r.Context(), span, len(requests), err)
+
+	return requests, opts, err
+}
+
+// decodeScenarios reads the base bookings and the scenarios of a request together with the options they
+// are compared with, read from the query string unless, from V2 on, the body holds some
+func (h *StatsHandler) decodeScenarios(w http.ResponseWriter, r *http.Request) ([]*domain.Booking, []domain.Scenario, domain.MaximizeOptions, error) {
+	opts, err := parseMaximizeOptions(r)
+	if err != nil {
+		return nil, nil, domain.MaximizeOptions{}, err
+	}
+
+	var request scenariosRequest
+	body, err := readBody(w, r, h.limits.MaxBodyBytes)
+	if err != nil {
+		return nil, nil, domain.MaximizeOptions{}, err
+	}
+	if err := json.Unmarshal(body, &request); err != nil {
+		return nil, nil, domain.MaximizeOptions{}, ErrInvalidJSON
+	}
+	if request.Options != nil && h.version >= V2 {
+		if opts, err = parseOptionsRequest(*request.Options); err != nil {
+			return nil, nil, domain.MaximizeOptions{}, err
+		}
+	}
+	lim, err := h.modeLimits(opts)
+	if err != nil {
+		return nil, nil, domain.MaximizeOptions{}, err
+	}
+
+	base, err := dto.BookingsToDomain(request.Bookings)
+	if err != nil {
+		return nil, nil, domain.MaximizeOptions{}, err
+	}
+	scenarios, err := parseScenarioRequests(request.Scenarios)
+	if err != nil {
+		return nil, nil, domain.MaximizeOptions{}, err
+	}
+	if err := lim.CheckScenarioBookings(base, scenarios); err != nil {
+		return nil, nil, domain.MaximizeOptions{}, err
+	}
+
+	return base, scenarios, opts, nil
+}
+
+// modeLimits returns the limits of the mode in opts, rejecting heuristic budgets over MaxOptimizerTime
+func (h *StatsHandler) modeLimits(opts domain.MaximizeOptions) (limits.Limits, error) {
+	if err := h.limits.CheckBudget(opts.Budget); err != nil {
+		return limits.Limits{}, err
+	}
+
+	return h.limits.ForMode(opts.Mode), nil
+}
+
+// exactLimits returns the limits of the exact mode, for the computations that always search in it
+func (h *StatsHandler) exactLimits(domain.MaximizeOptions) (limits.Limits, error) {
+	return h.limits, nil
+}
+
+// isJSONObject reports whether body holds a JSON object rather than an array
+func isJSONObject(body []byte) bool {
+	return bytes.HasPrefix(bytes.TrimSpace(body), []byte("{"))
+}
+
+// parseMaximizeOptions reads the optimization objective, its weights, the tie-break policy, whether to compute
+// counter-offers and the solver mode from the query string. Supported parameters are objective, profit_weight,
+// revenue_weight, occupancy_weight, tie_break, counter_offers, mode, budget_ms and preferred_provider, the latter
+// accepting a comma separated list in priority order
+func parseMaximizeOptions(r *http.Request) (domain.MaximizeOptions, error) {
+	query := r.URL.Query()
+	objective, err := domain.ParseObjective(query.Get("objective"))
+	if err != nil {
+		return domain.MaximizeOptions{}, err
+	}
+	tieBreak, err := domain.ParseTieBreak(query.Get("tie_break"))
+	if err != nil {
+		return domain.MaximizeOptions{}, err
+	}
+	mode, err := domain.ParseMode(query.Get("mode"))
+	if err != nil {
+		return domain.MaximizeOptions{}, err
+	}
+
+	opts := domain.MaximizeOptions{Objective: objective, TieBreak: tieBreak, Mode: mode}
+	if raw := query.Get("budget_ms"); raw != "" {
+		budget, err := strconv.Atoi(raw)
+		if err != nil {
+			return domain.MaximizeOptions{}, ErrInvalidBudgetFormat
+		}
+		opts.Budget = time.Duration(budget) * time.Millisecond
+	}
+	for _, value := range query["preferred_provider"] {
+		for _, provider := range strings.Split(value, ",") {
+			if provider = strings.TrimSpace(provider); provider != "" {
+				opts.PreferredProviders = append(opts.PreferredProviders, provider)
+			}
+		}
+	}
+	weights := map[string]*float64{
+		"profit_weight":    &opts.Weights.Profit,
+		"revenue_weight":   &opts.Weights.Revenue,
+		"occupancy_weight": &opts.Weights.Occupancy,
+	}
+	if raw := query.Get("counter_offers"); raw != "" {
+		if opts.CounterOffers, err = strconv.ParseBool(raw); err != nil {
+			return domain.MaximizeOptions{}, ErrInvalidCounterOffers
+		}
+	}
+	for param, weight := range weights {
+		raw := query.Get(param)
+		if raw == "" {
+			continue
+		}
+		if *weight, err = strconv.ParseFloat(raw, 64); err != nil {
+			return domain.MaximizeOptions{}, ErrInvalidWeightFormat
+		}
+	}
+	if err := opts.Validate(); err != nil {
+		return domain.MaximizeOptions{}, err
+	}
+
+	return opts, nil
+}
+
+// parseGroupBy returns the function computing the group of a booking for the group_by query parameter
+// It returns nil when the parameter is absent, the stats then covering the whole request
+func parseGroupBy(r *http.Request) (func(*domain.Booking) string, error) {
+	raw := r.URL.Query().Get("group_by")
+	if raw == "" {
+		return nil, nil
+	}
+	by, ok := queryGroupBy[raw]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidGroupBy, raw)
+	}
+
+	return groupKey(by)
+}
+
+// parseOptionsRequest converts the maximizeOptionsRequest DTO of a JSON body to domain.MaximizeOptions
+func parseOptionsRequest(dto maximizeOptionsRequest) (domain.MaximizeOptions, error) {
+	objective, err := domain.ParseObjective(dto.Objective)
+	if err != nil {
+		return domain.MaximizeOptions{}, err
+	}
+	tieBreak, err := domain.ParseTieBreak(dto.TieBreak)
+	if err != nil {
+		return domain.MaximizeOptions{}, err
+	}
+	mode, err := domain.ParseMode(dto.Mode)
+	if err != nil {
+		return domain.MaximizeOptions{}, err
+	}
+
+	opts := domain.MaximizeOptions{
+		Objective:          objective,
+		TieBreak:           tieBreak,
+		PreferredProviders: dto.PreferredProviders,
+		CounterOffers:      dto.CounterOffers,
+		Mode:               mode,
+		Budget:             time.Duration(dto.BudgetMS) * time.Millisecond,
+	}
+	if dto.Weights != nil {
+		opts.Weights = domain.Weights{Profit: dto.Weights.Profit, Revenue: dto.Weights.Revenue, Occupancy: dto.Weights.Occupancy}
+	}
+	if err := opts.Validate(); err != nil {
+		return domain.MaximizeOptions{}, err
+	}
+
+	return opts, nil
 }
 
-// parseBookingRequests converts a slice of bookingRequest DTOs to domain.Booking objects
-// It handles date parsing and validation of the input data
-func parseBookingRequests(dtos []bookingRequest) ([]*domain.Booking, error) {
-	requests := make([]*domain.Booking, 0, len(dtos))
-	for _, dto := range dtos {
-		checkIn, err := time.Parse(time.DateOnly, dto.CheckIn)
+// parseScenarioRequests converts scenarioRequest DTOs to domain.Scenario objects
+func parseScenarioRequests(dtos []scenarioRequest) ([]domain.Scenario, error) {
+	scenarios := make([]domain.Scenario, 0, len(dtos))
+	locations := dto.Locations{}
+	for _, request := range dtos {
+		scenario := domain.Scenario{Name: request.Name, Patches: make([]domain.Patch, 0, len(request.Patches))}
+		for _, p := range request.Patches {
+			patch, err := parsePatchRequest(p, locations)
+			if err != nil {
+				return nil, err
+			}
+			scenario.Patches = append(scenario.Patches, patch)
+		}
+		scenarios = append(scenarios, scenario)
+	}
+
+	return scenarios, nil
+}
+
+// parsePatchRequest converts a patchRequest DTO to the domain.Patch matching its operation
+// Blackout periods and added bookings are parsed like any other booking request
+func parsePatchRequest(dto patchRequest, locations dto.Locations) (domain.Patch, error) {
+	switch dto.Op {
+	case "remove":
+		return domain.RemoveBooking{RequestID: dto.RequestID}, nil
+	case "adjust_margin":
+		return domain.AdjustMargin{Provider: dto.Provider, Delta: dto.Delta}, nil
+	case "blackout":
+		period, err := bookingRequest{
+			CheckIn:  dto.CheckIn,
+			CheckOut: dto.CheckOut,
+			Nights:   dto.Nights,
+			Timezone: dto.Timezone,
+		}.ToDomain(locations)
 		if err != nil {
-			return nil, ErrInvalidDateFormat
+			return nil, err
 		}
-		requests = append(requests, &domain.Booking{
-			RequestID:   dto.RequestID,
-			CheckIn:     checkIn,
-			Nights:      dto.Nights,
-			SellingRate: dto.SellingRate,
-			Margin:      dto.Margin,
-		})
+		return domain.Blackout{Period: period}, nil
+	case "add":
+		if dto.Booking == nil {
+			return nil, ErrInvalidPatch
+		}
+		booking, err := dto.Booking.ToDomain(locations)
+		if err != nil {
+			return nil, err
+		}
+		return domain.AddBooking{Booking: booking}, nil
+	default:
+		return nil, ErrInvalidPatch
 	}
+}
 
-	return requests, nil
+// writeServiceError maps an error returned by the stats service to its HTTP status code
+// Computations stopped by the optimizer time limit or holding too many bookings for the exact search answer 422, those aborted by
+// another deadline answer 504, those canceled (e.g. the client went away) answer 503 and business
+// rule violations answer 400
+func writeServiceError(w http.ResponseWriter, version APIVersion, err error) {
+	switch {
+	case errors.Is(err, limits.ErrOptimizerTimeExceeded):
+		writeError(w, version, http.StatusUnprocessableEntity, codeOptimizerTimeExceeded, err)
+	case errors.Is(err, domain.ErrExactSearchTooLarge):
+		writeError(w, version, http.StatusUnprocessableEntity, codeTooManyBookings, err)
+	case errors.Is(err, context.DeadlineExceeded):
+		writeError(w, version, http.StatusGatewayTimeout, codeRequestTimeout, err)
+	case errors.Is(err, context.Canceled):
+		writeError(w, version, http.StatusServiceUnavailable, codeRequestCanceled, err)
+	case errors.Is(err, domain.ErrInvalidScenario), errors.Is(err, domain.ErrUnknownRequestID):
+		writeError(w, version, http.StatusBadRequest, codeInvalidRequest, err)
+	default:
+		writeError(w, version, http.StatusInternalServerError, codeInternalError, err)
+	}
 }
 
 // writeJSONResponse is a helper function to write JSON responses
 // It sets the appropriate headers and handles JSON encoding errors
 func writeJSONResponse(w http.ResponseWriter, statusCode int, data interface{}) {
-	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Type", contentTypeJSON)
 	w.WriteHeader(statusCode)
 	if err := json.NewEncoder(w).Encode(data); err != nil {
 		http.Error(w, "failed to encode response", http.StatusInternalServerError)
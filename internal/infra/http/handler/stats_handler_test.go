@@ -2,16 +2,24 @@ package handler_test
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"fmt"
+	"math"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"go.uber.org/mock/gomock"
 
+	"github.com/duksonn/stay-for-long/internal/application"
 	"github.com/duksonn/stay-for-long/internal/domain"
 	"github.com/duksonn/stay-for-long/internal/infra/http/handler"
+	"github.com/duksonn/stay-for-long/internal/infra/limits"
 	"github.com/duksonn/stay-for-long/internal/mocks"
 	"github.com/duksonn/stay-for-long/internal/ports"
 )
@@ -80,12 +88,12 @@ func TestStatsHandler_HandlerCalculateStats(t *testing.T) {
 			},
 			mock: func(m *mocks.MockStatsService) {
 				m.EXPECT().
-					CalculateStats(gomock.Any()).
+					CalculateStats(gomock.Any(), gomock.Any()).
 					Return(&domain.StatsResult{
 						AvgNight: 178,
 						MinNight: 156,
 						MaxNight: 200,
-					})
+					}, nil)
 			},
 			expectedStatus: http.StatusOK,
 			expectedBody: map[string]interface{}{
@@ -157,13 +165,15 @@ func TestStatsHandler_HandlerCalculateStats(t *testing.T) {
 func TestStatsHandler_HandlerMaximizeProfit(t *testing.T) {
 	tests := []struct {
 		name           string
+		version        handler.APIVersion
 		requestBody    []map[string]interface{}
 		mock           func(*mocks.MockStatsService)
 		expectedStatus int
 		expectedBody   map[string]interface{}
 	}{
 		{
-			name: "successful maximization",
+			name:    "successful maximization",
+			version: handler.V2,
 			requestBody: []map[string]interface{}{
 				{
 					"request_id":   "bookata_XY123",
@@ -182,14 +192,55 @@ func TestStatsHandler_HandlerMaximizeProfit(t *testing.T) {
 			},
 			mock: func(m *mocks.MockStatsService) {
 				m.EXPECT().
-					MaximizeProfit(gomock.Any()).
+					MaximizeProfit(gomock.Any(), gomock.Any(), gomock.Any()).
 					Return(&domain.MaximizeResult{
 						RequestIDs:  []string{"bookata_XY123"},
 						TotalProfit: 200,
 						AvgNight:    200,
 						MinNight:    200,
 						MaxNight:    200,
-					})
+						Rejected: []*domain.RejectedBooking{
+							{Booking: &domain.Booking{RequestID: "kayete_PP234"}, CounterOfferRate: 312.5},
+						},
+					}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody: map[string]interface{}{
+				"request_ids":  []interface{}{"bookata_XY123"},
+				"total_profit": float64(200),
+				"avg_night":    float64(200),
+				"min_night":    float64(200),
+				"max_night":    float64(200),
+				"rejected": []interface{}{
+					map[string]interface{}{"request_id": "kayete_PP234", "counter_offer_rate": 312.5},
+				},
+			},
+		},
+		{
+			name: "v1 keeps the original response",
+			requestBody: []map[string]interface{}{
+				{
+					"request_id":   "bookata_XY123",
+					"check_in":     "2020-01-01",
+					"nights":       5,
+					"selling_rate": 200,
+					"margin":       20,
+				},
+			},
+			mock: func(m *mocks.MockStatsService) {
+				m.EXPECT().
+					MaximizeProfit(gomock.Any(), gomock.Any(), gomock.Any()).
+					Return(&domain.MaximizeResult{
+						RequestIDs:  []string{"bookata_XY123"},
+						Objective:   domain.ObjectiveProfit,
+						Score:       200,
+						TotalProfit: 200,
+						AvgNight:    200,
+						MinNight:    200,
+						MaxNight:    200,
+						Optimal:     true,
+						UpperBound:  200,
+					}, nil)
 			},
 			expectedStatus: http.StatusOK,
 			expectedBody: map[string]interface{}{
@@ -222,6 +273,28 @@ func TestStatsHandler_HandlerMaximizeProfit(t *testing.T) {
 			expectedStatus: http.StatusBadRequest,
 			expectedBody:   nil,
 		},
+		{
+			name:        "optimizer timeout",
+			requestBody: []map[string]interface{}{},
+			mock: func(m *mocks.MockStatsService) {
+				m.EXPECT().
+					MaximizeProfit(gomock.Any(), gomock.Any(), gomock.Any()).
+					Return(nil, fmt.Errorf("%w: %w", domain.ErrOptimizationAborted, context.DeadlineExceeded))
+			},
+			expectedStatus: http.StatusGatewayTimeout,
+			expectedBody:   nil,
+		},
+		{
+			name:        "client canceled",
+			requestBody: []map[string]interface{}{},
+			mock: func(m *mocks.MockStatsService) {
+				m.EXPECT().
+					MaximizeProfit(gomock.Any(), gomock.Any(), gomock.Any()).
+					Return(nil, fmt.Errorf("%w: %w", domain.ErrOptimizationAborted, context.Canceled))
+			},
+			expectedStatus: http.StatusServiceUnavailable,
+			expectedBody:   nil,
+		},
 	}
 
 	for _, tt := range tests {
@@ -230,7 +303,11 @@ func TestStatsHandler_HandlerMaximizeProfit(t *testing.T) {
 			defer ctrl.Finish()
 
 			mockStatsService := mocks.NewMockStatsService(ctrl)
-			h, _ := handler.NewStatsHandler(mockStatsService)
+			version := handler.WithAPIVersion(handler.V1)
+			if tt.version != 0 {
+				version = handler.WithAPIVersion(tt.version)
+			}
+			h, _ := handler.NewStatsHandler(mockStatsService, version)
 
 			tt.mock(mockStatsService)
 
@@ -256,6 +333,877 @@ func TestStatsHandler_HandlerMaximizeProfit(t *testing.T) {
 			for k, v := range tt.expectedBody {
 				assert.Equal(t, v, response[k])
 			}
+			if tt.version < handler.V2 && tt.expectedBody != nil {
+				assert.Len(t, response, len(tt.expectedBody))
+			}
+		})
+	}
+}
+
+func TestStatsHandler_HandlerMaximizeProfit_Options(t *testing.T) {
+	tests := []struct {
+		name           string
+		version        handler.APIVersion
+		query          string
+		expectedOpts   *domain.MaximizeOptions
+		expectedStatus int
+	}{
+		{
+			name:           "v1 ignores the options",
+			version:        handler.V1,
+			query:          "?objective=occupancy&mode=fast",
+			expectedOpts:   &domain.MaximizeOptions{},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "default objective",
+			query:          "",
+			expectedOpts:   &domain.MaximizeOptions{Objective: domain.ObjectiveProfit, TieBreak: domain.TieBreakRequestIDs, Mode: domain.ModeExact},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "occupancy objective",
+			query:          "?objective=occupancy",
+			expectedOpts:   &domain.MaximizeOptions{Objective: domain.ObjectiveOccupancy, TieBreak: domain.TieBreakRequestIDs, Mode: domain.ModeExact},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:  "weighted objective",
+			query: "?objective=weighted&profit_weight=1&occupancy_weight=2.5",
+			expectedOpts: &domain.MaximizeOptions{
+				Objective: domain.ObjectiveWeighted,
+				Weights:   domain.Weights{Profit: 1, Occupancy: 2.5},
+				TieBreak:  domain.TieBreakRequestIDs,
+				Mode:      domain.ModeExact,
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:  "preferred provider tie-break",
+			query: "?tie_break=preferred_provider&preferred_provider=acme,bookata&preferred_provider=kayete",
+			expectedOpts: &domain.MaximizeOptions{
+				Objective:          domain.ObjectiveProfit,
+				TieBreak:           domain.TieBreakPreferredProvider,
+				PreferredProviders: []string{"acme", "bookata", "kayete"},
+				Mode:               domain.ModeExact,
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:  "counter offers",
+			query: "?counter_offers=true",
+			expectedOpts: &domain.MaximizeOptions{
+				Objective:     domain.ObjectiveProfit,
+				TieBreak:      domain.TieBreakRequestIDs,
+				CounterOffers: true,
+				Mode:          domain.ModeExact,
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:  "heuristic mode",
+			query: "?mode=heuristic&budget_ms=250",
+			expectedOpts: &domain.MaximizeOptions{
+				Objective: domain.ObjectiveProfit,
+				TieBreak:  domain.TieBreakRequestIDs,
+				Mode:      domain.ModeHeuristic,
+				Budget:    250 * time.Millisecond,
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "unknown mode",
+			query:          "?mode=fast",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "invalid budget",
+			query:          "?mode=heuristic&budget_ms=soon",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "negative budget",
+			query:          "?mode=heuristic&budget_ms=-1",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "heuristic mode with counter offers",
+			query:          "?mode=heuristic&counter_offers=true",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "invalid counter offers flag",
+			query:          "?counter_offers=maybe",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "unknown tie-break",
+			query:          "?tie_break=random",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "preferred provider tie-break without providers",
+			query:          "?tie_break=preferred_provider",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "unknown objective",
+			query:          "?objective=margin",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "weighted objective without weights",
+			query:          "?objective=weighted",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "invalid weight",
+			query:          "?objective=weighted&profit_weight=abc",
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockStatsService := mocks.NewMockStatsService(ctrl)
+			version := handler.WithAPIVersion(handler.V2)
+			if tt.version != 0 {
+				version = handler.WithAPIVersion(tt.version)
+			}
+			h, _ := handler.NewStatsHandler(mockStatsService, version)
+
+			if tt.expectedOpts != nil {
+				mockStatsService.EXPECT().
+					MaximizeProfit(gomock.Any(), gomock.Any(), *tt.expectedOpts).
+					Return(&domain.MaximizeResult{RequestIDs: []string{}, Objective: tt.expectedOpts.Objective}, nil)
+			}
+
+			req := httptest.NewRequest(http.MethodPost, "/maximize"+tt.query, bytes.NewBufferString("[]"))
+			w := httptest.NewRecorder()
+
+			h.HandlerMaximizeProfit(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.expectedOpts != nil {
+				var response map[string]interface{}
+				assert.NoError(t, json.NewDecoder(w.Body).Decode(&response))
+				if tt.version == handler.V1 {
+					assert.NotContains(t, response, "objective")
+				} else {
+					assert.Equal(t, string(tt.expectedOpts.Objective), response["objective"])
+				}
+			}
+		})
+	}
+}
+
+func TestStatsHandler_HandlerParetoFrontier(t *testing.T) {
+	tests := []struct {
+		name           string
+		requestBody    string
+		mock           func(*mocks.MockStatsService)
+		expectedStatus int
+		expectedPoints int
+	}{
+		{
+			name:        "successful frontier",
+			requestBody: `[{"request_id":"long","check_in":"2020-01-01","nights":10,"selling_rate":2000,"margin":5}]`,
+			mock: func(m *mocks.MockStatsService) {
+				m.EXPECT().
+					ParetoFrontier(gomock.Any(), gomock.Any()).
+					Return([]*domain.MaximizeResult{
+						{RequestIDs: []string{"short1", "short2"}, TotalProfit: 300, TotalNights: 4},
+						{RequestIDs: []string{"long"}, TotalProfit: 100, TotalNights: 10},
+					}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedPoints: 2,
+		},
+		{
+			name:           "invalid json",
+			requestBody:    "invalid json",
+			mock:           func(m *mocks.MockStatsService) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockStatsService := mocks.NewMockStatsService(ctrl)
+			h, _ := handler.NewStatsHandler(mockStatsService)
+
+			tt.mock(mockStatsService)
+
+			req := httptest.NewRequest(http.MethodPost, "/maximize/pareto", bytes.NewBufferString(tt.requestBody))
+			w := httptest.NewRecorder()
+
+			h.HandlerParetoFrontier(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.expectedStatus == http.StatusOK {
+				var response struct {
+					Points []map[string]interface{} `json:"points"`
+				}
+				assert.NoError(t, json.NewDecoder(w.Body).Decode(&response))
+				assert.Len(t, response.Points, tt.expectedPoints)
+			}
+		})
+	}
+}
+
+func TestStatsHandler_HandlerCalculateStats_GroupBy(t *testing.T) {
+	requestBody := `[
+		{"request_id":"bookata_XY123","property_id":"villa","check_in":"2020-01-01","nights":5,"selling_rate":200,"margin":20},
+		{"request_id":"kayete_PP234","check_in":"2020-01-04","nights":4,"selling_rate":156,"margin":5},
+		{"request_id":"acme_AAAAA","property_id":"villa","check_in":"2020-02-10","nights":4,"selling_rate":160,"margin":30}
+	]`
+
+	tests := []struct {
+		name           string
+		version        handler.APIVersion
+		query          string
+		accept         string
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:           "v1 ignores the grouping",
+			version:        handler.V1,
+			query:          "?group_by=city",
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"avg_night":7.32,"min_night":1.95,"max_night":12}`,
+		},
+		{
+			name:           "grouped by provider",
+			query:          "?group_by=provider",
+			expectedStatus: http.StatusOK,
+			expectedBody: `{"avg_night":7.32,"min_night":1.95,"max_night":12,"groups":[
+				{"key":"acme","count":1,"avg_night":12,"min_night":12,"max_night":12},
+				{"key":"bookata","count":1,"avg_night":8,"min_night":8,"max_night":8},
+				{"key":"kayete","count":1,"avg_night":1.95,"min_night":1.95,"max_night":1.95}
+			]}`,
+		},
+		{
+			name:           "grouped by property as csv, bookings without property last",
+			query:          "?group_by=property",
+			accept:         "text/csv",
+			expectedStatus: http.StatusOK,
+			expectedBody:   "group,avg_night,min_night,max_night\nvilla,10,8,12\n,1.95,1.95,1.95\n",
+		},
+		{
+			name:           "not grouped",
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"avg_night":7.32,"min_night":1.95,"max_night":12}`,
+		},
+		{
+			name:           "unknown group",
+			query:          "?group_by=city",
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"code":"invalid_request","error":"invalid group by: \"city\""}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			version := handler.WithAPIVersion(handler.V2)
+			if tt.version != 0 {
+				version = handler.WithAPIVersion(tt.version)
+			}
+			h, err := handler.NewStatsHandler(application.NewStatsService(), version)
+			require.NoError(t, err)
+
+			req := httptest.NewRequest(http.MethodPost, "/stats"+tt.query, strings.NewReader(requestBody))
+			req.Header.Set("Accept", tt.accept)
+			w := httptest.NewRecorder()
+
+			h.HandlerCalculateStats(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.accept == "text/csv" {
+				assert.Equal(t, tt.expectedBody, w.Body.String())
+			} else {
+				assert.JSONEq(t, tt.expectedBody, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestStatsHandler_HandlerCalculateStats_DateHandling(t *testing.T) {
+	madrid, err := time.LoadLocation("Europe/Madrid")
+	require.NoError(t, err)
+
+	tests := []struct {
+		name             string
+		requestBody      string
+		expectedStatus   int
+		expectedCheckIn  time.Time
+		expectedCheckOut time.Time
+		expectedNights   int
+	}{
+		{
+			name:             "nights in utc",
+			requestBody:      `[{"request_id":"a","check_in":"2024-01-01","nights":3}]`,
+			expectedStatus:   http.StatusOK,
+			expectedCheckIn:  time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+			expectedCheckOut: time.Date(2024, 1, 4, 0, 0, 0, 0, time.UTC),
+			expectedNights:   3,
+		},
+		{
+			name: "check-out date with times and timezone",
+			requestBody: `[{"request_id":"a","check_in":"2024-03-30","check_out":"2024-04-01",` +
+				`"check_in_time":"15:00","check_out_time":"12:30","timezone":"Europe/Madrid"}]`,
+			expectedStatus:   http.StatusOK,
+			expectedCheckIn:  time.Date(2024, 3, 30, 15, 0, 0, 0, madrid),
+			expectedCheckOut: time.Date(2024, 4, 1, 12, 30, 0, 0, madrid),
+			expectedNights:   2,
+		},
+		{
+			name:           "invalid timezone",
+			requestBody:    `[{"request_id":"a","check_in":"2024-01-01","nights":3,"timezone":"Mars/Olympus"}]`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "invalid time of day",
+			requestBody:    `[{"request_id":"a","check_in":"2024-01-01","nights":3,"check_in_time":"3pm"}]`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "check-out before check-in",
+			requestBody:    `[{"request_id":"a","check_in":"2024-01-05","check_out":"2024-01-01"}]`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "check-out contradicts nights",
+			requestBody:    `[{"request_id":"a","check_in":"2024-01-01","check_out":"2024-01-03","nights":5}]`,
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockStatsService := mocks.NewMockStatsService(ctrl)
+			h, _ := handler.NewStatsHandler(mockStatsService)
+
+			if tt.expectedStatus == http.StatusOK {
+				mockStatsService.EXPECT().
+					CalculateStats(gomock.Any(), gomock.Any()).
+					DoAndReturn(func(_ context.Context, requests domain.Bookings) (*domain.StatsResult, error) {
+						require.Len(t, requests, 1)
+						assert.True(t, tt.expectedCheckIn.Equal(requests[0].CheckIn))
+						assert.True(t, tt.expectedCheckOut.Equal(requests[0].End()))
+						assert.Equal(t, tt.expectedNights, requests[0].Nights)
+						return &domain.StatsResult{}, nil
+					})
+			}
+
+			req := httptest.NewRequest(http.MethodPost, "/stats", bytes.NewBufferString(tt.requestBody))
+			w := httptest.NewRecorder()
+
+			h.HandlerCalculateStats(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+		})
+	}
+}
+
+func TestStatsHandler_HandlerSensitivity(t *testing.T) {
+	tests := []struct {
+		name           string
+		query          string
+		requestBody    string
+		mock           func(*mocks.MockStatsService)
+		expectedStatus int
+		expectedBody   map[string]interface{}
+	}{
+		{
+			name:        "successful analysis",
+			requestBody: `[{"request_id":"bookata_XY123","check_in":"2020-01-01","nights":5,"selling_rate":200,"margin":20}]`,
+			mock: func(m *mocks.MockStatsService) {
+				booking := &domain.Booking{RequestID: "bookata_XY123"}
+				m.EXPECT().
+					Sensitivity(gomock.Any(), gomock.Any(), gomock.Any()).
+					Return(&domain.SensitivityResult{
+						Selection: &domain.MaximizeResult{RequestIDs: []string{"bookata_XY123"}, TotalProfit: 40},
+						Bookings: []*domain.BookingSensitivity{
+							{Booking: booking, Accepted: true, SellingRateDelta: 161.03, MarginDelta: math.Inf(1)},
+						},
+					}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody: map[string]interface{}{
+				"bookings": []interface{}{
+					map[string]interface{}{
+						"request_id":         "bookata_XY123",
+						"accepted":           true,
+						"selling_rate_delta": 161.03,
+						"margin_delta":       nil,
+					},
+				},
+			},
+		},
+		{
+			name:           "invalid objective",
+			query:          "?objective=margin",
+			requestBody:    "[]",
+			mock:           func(m *mocks.MockStatsService) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "invalid json",
+			requestBody:    "invalid json",
+			mock:           func(m *mocks.MockStatsService) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockStatsService := mocks.NewMockStatsService(ctrl)
+			h, _ := handler.NewStatsHandler(mockStatsService)
+
+			tt.mock(mockStatsService)
+
+			req := httptest.NewRequest(http.MethodPost, "/maximize/sensitivity"+tt.query, bytes.NewBufferString(tt.requestBody))
+			w := httptest.NewRecorder()
+
+			h.HandlerSensitivity(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+
+			var response map[string]interface{}
+			assert.NoError(t, json.NewDecoder(w.Body).Decode(&response))
+			for k, v := range tt.expectedBody {
+				assert.Equal(t, v, response[k])
+			}
+		})
+	}
+}
+
+func TestStatsHandler_HandlerCompareScenarios(t *testing.T) {
+	const bookings = `[{"request_id":"kayete_PP234","check_in":"2020-01-04","nights":4,"selling_rate":156,"margin":5}]`
+
+	tests := []struct {
+		name           string
+		requestBody    string
+		mock           func(*mocks.MockStatsService)
+		expectedStatus int
+	}{
+		{
+			name: "successful comparison",
+			requestBody: `{"bookings":` + bookings + `,"scenarios":[{"name":"what if","patches":[` +
+				`{"op":"remove","request_id":"kayete_PP234"},` +
+				`{"op":"adjust_margin","provider":"acme","delta":5},` +
+				`{"op":"blackout","check_in":"2020-01-01","nights":7},` +
+				`{"op":"add","booking":{"request_id":"new_1","check_in":"2020-02-01","nights":2}}]}]}`,
+			mock: func(m *mocks.MockStatsService) {
+				m.EXPECT().
+					CompareScenarios(gomock.Any(), gomock.Len(1), gomock.Any(), gomock.Any()).
+					DoAndReturn(func(_ context.Context, base domain.Bookings, scenarios []domain.Scenario, _ domain.MaximizeOptions) (*domain.ScenarioComparison, error) {
+						require.Len(t, scenarios, 1)
+						require.Len(t, scenarios[0].Patches, 4)
+						assert.Equal(t, domain.RemoveBooking{RequestID: "kayete_PP234"}, scenarios[0].Patches[0])
+						assert.Equal(t, domain.AdjustMargin{Provider: "acme", Delta: 5}, scenarios[0].Patches[1])
+						assert.IsType(t, domain.Blackout{}, scenarios[0].Patches[2])
+						assert.IsType(t, domain.AddBooking{}, scenarios[0].Patches[3])
+
+						result := &domain.ScenarioResult{
+							Maximize: &domain.MaximizeResult{RequestIDs: []string{}},
+							Stats:    &domain.StatsResult{},
+						}
+						return &domain.ScenarioComparison{
+							Base: result,
+							Scenarios: []*domain.ScenarioResult{{
+								Name:     "what if",
+								Maximize: result.Maximize,
+								Stats:    result.Stats,
+								Diff:     &domain.ScenarioDiff{Added: []string{}, Removed: []string{"kayete_PP234"}},
+							}},
+						}, nil
+					})
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "unknown patch operation",
+			requestBody:    `{"bookings":` + bookings + `,"scenarios":[{"name":"a","patches":[{"op":"explode"}]}]}`,
+			mock:           func(m *mocks.MockStatsService) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "invalid blackout date",
+			requestBody:    `{"bookings":[],"scenarios":[{"name":"a","patches":[{"op":"blackout","check_in":"soon"}]}]}`,
+			mock:           func(m *mocks.MockStatsService) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:        "service error",
+			requestBody: `{"bookings":[],"scenarios":[{"name":"a","patches":[{"op":"remove","request_id":"x"}]}]}`,
+			mock: func(m *mocks.MockStatsService) {
+				m.EXPECT().
+					CompareScenarios(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+					Return(nil, domain.ErrUnknownRequestID)
+			},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "invalid json",
+			requestBody:    "invalid json",
+			mock:           func(m *mocks.MockStatsService) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockStatsService := mocks.NewMockStatsService(ctrl)
+			h, _ := handler.NewStatsHandler(mockStatsService)
+
+			tt.mock(mockStatsService)
+
+			req := httptest.NewRequest(http.MethodPost, "/maximize/scenarios", bytes.NewBufferString(tt.requestBody))
+			w := httptest.NewRecorder()
+
+			h.HandlerCompareScenarios(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.expectedStatus == http.StatusOK {
+				var response struct {
+					Base      map[string]interface{}   `json:"base"`
+					Scenarios []map[string]interface{} `json:"scenarios"`
+				}
+				assert.NoError(t, json.NewDecoder(w.Body).Decode(&response))
+				assert.NotContains(t, response.Base, "diff")
+				require.Len(t, response.Scenarios, 1)
+				assert.Equal(t, "what if", response.Scenarios[0]["name"])
+				assert.Contains(t, response.Scenarios[0], "diff")
+			}
+		})
+	}
+}
+
+func TestStatsHandler_Limits(t *testing.T) {
+	booking := `{"request_id":"bookata_XY123","check_in":"2020-01-01","nights":5,"selling_rate":200,"margin":20}`
+	limits := limits.Limits{MaxBodyBytes: 512, MaxBookings: 2, MaxHeuristicBookings: 3, MaxOptimizerTime: 10 * time.Millisecond}
+
+	tests := []struct {
+		name           string
+		version        handler.APIVersion
+		path           string
+		requestBody    string
+		mock           func(*mocks.MockStatsService)
+		expectedStatus int
+		expectedCode   string
+	}{
+		{
+			name:        "within limits",
+			path:        "/maximize",
+			requestBody: "[" + booking + "," + booking + "]",
+			mock: func(m *mocks.MockStatsService) {
+				m.EXPECT().
+					MaximizeProfit(gomock.Any(), gomock.Any(), gomock.Any()).
+					Return(&domain.MaximizeResult{RequestIDs: []string{}}, nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "body too large",
+			path:           "/maximize",
+			requestBody:    "[" + strings.Repeat(booking+",", 5) + booking + "]",
+			mock:           func(m *mocks.MockStatsService) {},
+			expectedStatus: http.StatusRequestEntityTooLarge,
+			expectedCode:   "body_too_large",
+		},
+		{
+			name:           "too many bookings",
+			path:           "/stats",
+			requestBody:    "[" + booking + "," + booking + "," + booking + "]",
+			mock:           func(m *mocks.MockStatsService) {},
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedCode:   "too_many_bookings",
+		},
+		{
+			name:        "heuristic mode uses its own booking limit",
+			version:     handler.V2,
+			path:        "/maximize?mode=heuristic&budget_ms=5",
+			requestBody: "[" + booking + "," + booking + "," + booking + "]",
+			mock: func(m *mocks.MockStatsService) {
+				m.EXPECT().
+					MaximizeProfit(gomock.Any(), gomock.Any(), gomock.Any()).
+					Return(&domain.MaximizeResult{RequestIDs: []string{}}, nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "too many bookings in heuristic mode",
+			version:        handler.V2,
+			path:           "/maximize?mode=heuristic",
+			requestBody:    "[" + strings.Repeat(booking+",", 3) + booking + "]",
+			mock:           func(m *mocks.MockStatsService) {},
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedCode:   "too_many_bookings",
+		},
+		{
+			name:           "heuristic budget over the optimizer time",
+			version:        handler.V2,
+			path:           "/maximize?mode=heuristic&budget_ms=20",
+			requestBody:    "[" + booking + "]",
+			mock:           func(m *mocks.MockStatsService) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "too many bookings in a scenario",
+			path: "/maximize/scenarios",
+			requestBody: `{"bookings":[` + booking + `,` + booking + `],"scenarios":[{"name":"more","patches":[` +
+				`{"op":"add","booking":` + booking + `}]}]}`,
+			mock:           func(m *mocks.MockStatsService) {},
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedCode:   "too_many_bookings",
+		},
+		{
+			name:        "optimizer time exceeded",
+			path:        "/maximize",
+			requestBody: "[" + booking + "]",
+			mock: func(m *mocks.MockStatsService) {
+				m.EXPECT().
+					MaximizeProfit(gomock.Any(), gomock.Any(), gomock.Any()).
+					DoAndReturn(func(ctx context.Context, _ domain.Bookings, _ domain.MaximizeOptions) (*domain.MaximizeResult, error) {
+						<-ctx.Done()
+						return nil, fmt.Errorf("%w: %w: %w", domain.ErrOptimizationAborted, ctx.Err(), context.Cause(ctx))
+					})
+			},
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedCode:   "optimizer_time_exceeded",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockStatsService := mocks.NewMockStatsService(ctrl)
+			version := handler.WithAPIVersion(handler.V1)
+			if tt.version != 0 {
+				version = handler.WithAPIVersion(tt.version)
+			}
+			h, err := handler.NewStatsHandler(mockStatsService, handler.WithLimits(limits), version)
+			require.NoError(t, err)
+
+			tt.mock(mockStatsService)
+
+			routes := map[string]http.HandlerFunc{
+				"/stats":              h.HandlerCalculateStats,
+				"/maximize":           h.HandlerMaximizeProfit,
+				"/maximize/scenarios": h.HandlerCompareScenarios,
+			}
+			req := httptest.NewRequest(http.MethodPost, tt.path, bytes.NewBufferString(tt.requestBody))
+			w := httptest.NewRecorder()
+
+			routes[req.URL.Path](w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.expectedCode != "" {
+				var response map[string]interface{}
+				require.NoError(t, json.NewDecoder(w.Body).Decode(&response))
+				assert.Equal(t, tt.expectedCode, response["code"])
+				assert.NotEmpty(t, response["error"])
+			}
+		})
+	}
+}
+
+func TestStatsHandler_RequestEnvelope(t *testing.T) {
+	booking := `{"request_id":"bookata_XY123","check_in":"2020-01-01","nights":5,"selling_rate":200,"margin":20}`
+	twoBookings := booking + `,{"request_id":"acme_AAAAA","check_in":"2020-01-10","nights":2,"selling_rate":100,"margin":10}`
+	limits := limits.Limits{MaxBookings: 1, MaxHeuristicBookings: 3, MaxOptimizerTime: time.Second}
+	maximizeWith := func(t *testing.T, want domain.MaximizeOptions) func(*mocks.MockStatsService) {
+		return func(m *mocks.MockStatsService) {
+			m.EXPECT().
+				MaximizeProfit(gomock.Any(), gomock.Any(), gomock.Any()).
+				DoAndReturn(func(_ context.Context, _ domain.Bookings, opts domain.MaximizeOptions) (*domain.MaximizeResult, error) {
+					assert.Equal(t, want, opts)
+					return &domain.MaximizeResult{RequestIDs: []string{}}, nil
+				})
+		}
+	}
+	defaults := domain.MaximizeOptions{Objective: domain.ObjectiveProfit, TieBreak: domain.TieBreakRequestIDs, Mode: domain.ModeExact}
+
+	tests := []struct {
+		name           string
+		version        handler.APIVersion
+		path           string
+		requestBody    string
+		mock           func(*mocks.MockStatsService)
+		expectedStatus int
+		expectedCode   string
+	}{
+		{
+			name:        "stats envelope",
+			version:     handler.V2,
+			path:        "/stats",
+			requestBody: `{"bookings":[` + booking + `]}`,
+			mock: func(m *mocks.MockStatsService) {
+				m.EXPECT().
+					CalculateStats(gomock.Any(), gomock.Len(1)).
+					Return(&domain.StatsResult{}, nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:        "legacy array",
+			version:     handler.V2,
+			path:        "/stats",
+			requestBody: `[` + booking + `]`,
+			mock: func(m *mocks.MockStatsService) {
+				m.EXPECT().
+					CalculateStats(gomock.Any(), gomock.Len(1)).
+					Return(&domain.StatsResult{}, nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "envelope not accepted by v1",
+			version:        handler.V1,
+			path:           "/stats",
+			requestBody:    `{"bookings":[` + booking + `]}`,
+			mock:           func(m *mocks.MockStatsService) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:        "envelope without options keeps the query string ones",
+			version:     handler.V2,
+			path:        "/maximize?objective=revenue",
+			requestBody: `{"bookings":[` + booking + `]}`,
+			mock: maximizeWith(t, domain.MaximizeOptions{
+				Objective: domain.ObjectiveRevenue, TieBreak: domain.TieBreakRequestIDs, Mode: domain.ModeExact,
+			}),
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:    "envelope options replace the query string ones",
+			version: handler.V2,
+			path:    "/maximize?objective=revenue&counter_offers=true",
+			requestBody: `{"bookings":[` + twoBookings + `],"options":{"objective":"weighted","weights":{"occupancy":2},` +
+				`"tie_break":"preferred_provider","preferred_providers":["acme"],"mode":"heuristic","budget_ms":5}}`,
+			mock: maximizeWith(t, domain.MaximizeOptions{
+				Objective:          domain.ObjectiveWeighted,
+				Weights:            domain.Weights{Occupancy: 2},
+				TieBreak:           domain.TieBreakPreferredProvider,
+				PreferredProviders: []string{"acme"},
+				Mode:               domain.ModeHeuristic,
+				Budget:             5 * time.Millisecond,
+			}),
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "empty options",
+			version:        handler.V2,
+			path:           "/maximize?objective=revenue",
+			requestBody:    `{"bookings":[],"options":{}}`,
+			mock:           maximizeWith(t, defaults),
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "invalid options",
+			version:        handler.V2,
+			path:           "/maximize",
+			requestBody:    `{"bookings":[],"options":{"objective":"fun"}}`,
+			mock:           func(m *mocks.MockStatsService) {},
+			expectedStatus: http.StatusBadRequest,
+			expectedCode:   "invalid_request",
+		},
+		{
+			name:           "budget over the optimizer time limit",
+			version:        handler.V2,
+			path:           "/maximize",
+			requestBody:    `{"bookings":[],"options":{"mode":"heuristic","budget_ms":2000}}`,
+			mock:           func(m *mocks.MockStatsService) {},
+			expectedStatus: http.StatusBadRequest,
+			expectedCode:   "invalid_request",
+		},
+		{
+			name:           "too many bookings for the mode of the options",
+			version:        handler.V2,
+			path:           "/maximize?mode=heuristic",
+			requestBody:    `{"bookings":[` + twoBookings + `],"options":{"mode":"exact"}}`,
+			mock:           func(m *mocks.MockStatsService) {},
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedCode:   "too_many_bookings",
+		},
+		{
+			name:           "sensitivity keeps the exact limits",
+			version:        handler.V2,
+			path:           "/maximize/sensitivity",
+			requestBody:    `{"bookings":[` + twoBookings + `],"options":{"mode":"heuristic"}}`,
+			mock:           func(m *mocks.MockStatsService) {},
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedCode:   "too_many_bookings",
+		},
+		{
+			name:        "scenario options",
+			version:     handler.V2,
+			path:        "/maximize/scenarios?objective=occupancy",
+			requestBody: `{"bookings":[],"scenarios":[],"options":{"objective":"revenue"}}`,
+			mock: func(m *mocks.MockStatsService) {
+				m.EXPECT().
+					CompareScenarios(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+					DoAndReturn(func(_ context.Context, _ domain.Bookings, _ []domain.Scenario, opts domain.MaximizeOptions) (*domain.ScenarioComparison, error) {
+						assert.Equal(t, domain.ObjectiveRevenue, opts.Objective)
+						return nil, domain.ErrInvalidScenario
+					})
+			},
+			expectedStatus: http.StatusBadRequest,
+			expectedCode:   "invalid_request",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockStatsService := mocks.NewMockStatsService(ctrl)
+			h, err := handler.NewStatsHandler(mockStatsService, handler.WithLimits(limits), handler.WithAPIVersion(tt.version))
+			require.NoError(t, err)
+
+			tt.mock(mockStatsService)
+
+			req := httptest.NewRequest(http.MethodPost, tt.path, strings.NewReader(tt.requestBody))
+			w := httptest.NewRecorder()
+			switch req.URL.Path {
+			case "/stats":
+				h.HandlerCalculateStats(w, req)
+			case "/maximize":
+				h.HandlerMaximizeProfit(w, req)
+			case "/maximize/sensitivity":
+				h.HandlerSensitivity(w, req)
+			case "/maximize/scenarios":
+				h.HandlerCompareScenarios(w, req)
+			}
+
+			assert.Equal(t, tt.expectedStatus, w.Code, w.Body.String())
+			if tt.expectedCode != "" {
+				var response struct {
+					Code string `json:"code"`
+				}
+				require.NoError(t, json.NewDecoder(w.Body).Decode(&response))
+				assert.Equal(t, tt.expectedCode, response.Code)
+			}
 		})
 	}
 }
@@ -0,0 +1,55 @@
+package handler
+
+import (
+	"cmp"
+	"context"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+// tracerName names the tracer of the handlers
+const tracerName = "github.com/duksonn/stay-for-long/internal/infra/http/handler"
+
+// Attributes of the spans recorded by the handlers
+const (
+	attrMediaType = attribute.Key("request.media_type")
+	attrBookings  = attribute.Key("bookings.count")
+)
+
+// WithTracerProvider records a span for the decoding of every request with a tracer of tp
+// Nothing is recorded by default
+func WithTracerProvider(tp trace.TracerProvider) Option {
+	return func(o *options) {
+		o.tracer = tp.Tracer(tracerName)
+	}
+}
+
+// defaultTracer records nothing, for the handlers created without WithTracerProvider
+func defaultTracer() trace.Tracer {
+	return noop.NewTracerProvider().Tracer(tracerName)
+}
+
+// startDecode starts the span decoding the bookings of a request in the given media type, JSON when empty
+func startDecode(ctx context.Context, tracer trace.Tracer, mediaType string) trace.Span {
+	_, span := tracer.Start(ctx, "decode request",
+		trace.WithAttributes(attrMediaType.String(cmp.Or(mediaType, "application/json"))))
+
+	return span
+}
+
+// endDecode ends the span started by startDecode, reporting the bookings decoded to the span and the access
+// log or the error that prevented it to the span
+func endDecode(ctx context.Context, span trace.Span, bookings int, err error) {
+	defer span.End()
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return
+	}
+
+	span.SetAttributes(attrBookings.Int(bookings))
+	requestLog(ctx).addBookings(bookings)
+}
@@ -0,0 +1,69 @@
+package handler_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"github.com/duksonn/stay-for-long/internal/application"
+	"github.com/duksonn/stay-for-long/internal/infra/http/handler"
+)
+
+func TestHandlers_DecodeSpan(t *testing.T) {
+	booking := `{"request_id":"bookata_XY123","check_in":"2020-01-01","nights":5,"selling_rate":200,"margin":20}`
+
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		wantAttrs   []attribute.KeyValue
+		wantStatus  codes.Code
+	}{
+		{
+			name:      "json",
+			body:      "[" + booking + "," + booking + "]",
+			wantAttrs: []attribute.KeyValue{attribute.String("request.media_type", "application/json"), attribute.Int("bookings.count", 2)},
+		},
+		{
+			name:        "csv",
+			contentType: "text/csv; charset=utf-8",
+			body:        "request_id,check_in,nights\nbookata_XY123,2020-01-01,5\n",
+			wantAttrs:   []attribute.KeyValue{attribute.String("request.media_type", "text/csv"), attribute.Int("bookings.count", 1)},
+		},
+		{
+			name:       "invalid",
+			body:       "{",
+			wantAttrs:  []attribute.KeyValue{attribute.String("request.media_type", "application/json")},
+			wantStatus: codes.Error,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			exporter := tracetest.NewInMemoryExporter()
+			tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+			h, err := handler.NewStatsHandler(application.NewStatsService(), handler.WithTracerProvider(tp))
+			require.NoError(t, err)
+
+			req := httptest.NewRequest(http.MethodPost, "/maximize", strings.NewReader(tt.body))
+			if tt.contentType != "" {
+				req.Header.Set("Content-Type", tt.contentType)
+			}
+			h.HandlerMaximizeProfit(httptest.NewRecorder(), req)
+
+			spans := exporter.GetSpans()
+			require.Len(t, spans, 1)
+			assert.Equal(t, "decode request", spans[0].Name)
+			assert.Equal(t, tt.wantAttrs, spans[0].Attributes)
+			assert.Equal(t, tt.wantStatus, spans[0].Status.Code)
+		})
+	}
+}
@@ -0,0 +1,51 @@
+package handler
+
+import "net/http"
+
+// APIVersion identifies the contract of the responses written by a handler
+type APIVersion int
+
+const (
+	// V1 is the original contract, where only the errors caused by a limit or a faulty line carry a code
+	V1 APIVersion = 1
+	// V2 reports every error with a code
+	V2 APIVersion = 2
+)
+
+// Error codes reported from V2 on by the errors that had none in V1
+const (
+	codeInvalidRequest  = "invalid_request"
+	codeJobNotFound     = "job_not_found"
+	codeJobFinished     = "job_finished"
+	codeRequestTimeout  = "request_timeout"
+	codeRequestCanceled = "request_canceled"
+	codeInternalError   = "internal_error"
+)
+
+// v1ErrorCodes are the codes V1 reports, its other errors keeping their original empty body
+var v1ErrorCodes = map[string]bool{
+	codeBodyTooLarge:          true,
+	codeTooManyBookings:       true,
+	codeOptimizerTimeExceeded: true,
+	codeInvalidInput:          true,
+	codeJobQueueFull:          true,
+}
+
+// WithAPIVersion sets the contract of the responses written by the handler, V1 by default
+func WithAPIVersion(version APIVersion) Option {
+	return func(o *options) {
+		o.version = version
+	}
+}
+
+// writeError writes an error response in the contract of version
+// V1 reports the code and message only for the codes it was released with and an empty object for
+// the other errors, while V2 reports both for every error
+func writeError(w http.ResponseWriter, version APIVersion, statusCode int, code string, err error) {
+	if version == V1 && !v1ErrorCodes[code] {
+		writeJSONResponse(w, statusCode, err)
+		return
+	}
+
+	writeJSONResponse(w, statusCode, newErrorResponse(code, err))
+}
@@ -0,0 +1,215 @@
+package http
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/duksonn/stay-for-long/internal/infra/http/handler"
+	"github.com/duksonn/stay-for-long/internal/infra/metrics"
+)
+
+const (
+	// requestIDHeader carries the ID tracing a request across the services it goes through
+	requestIDHeader = "X-Request-ID"
+	// maxRequestIDLength bounds the request IDs accepted from the clients
+	maxRequestIDLength = 128
+)
+
+// withTimeout bounds the request context to the given duration, so handlers stop computing once
+// the server can no longer write the response. A zero or negative timeout leaves the context untouched
+func withTimeout(timeout time.Duration) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if timeout <= 0 {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			ctx, cancel := context.WithTimeout(r.Context(), timeout)
+			defer cancel()
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// unversionedDeprecation is when the unversioned paths were deprecated in favour of /v1
+var unversionedDeprecation = time.Date(2026, time.October, 18, 0, 0, 0, 0, time.UTC)
+
+// deprecated flags the responses of the unversioned paths as deprecated, following RFC 9745, and links
+// to the same path under successor, the version prefix clients should move to
+func deprecated(successor string) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Deprecation", "@"+strconv.FormatInt(unversionedDeprecation.Unix(), 10))
+			w.Header().Add("Link", fmt.Sprintf("<%s%s>; rel=\"successor-version\"", successor, r.URL.EscapedPath()))
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// withRequestID propagates the X-Request-ID header of the request to its response, replacing it with a
+// random ID when the client sent none or an unusable one, so every request can be traced in the logs
+func withRequestID() mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			id := r.Header.Get(requestIDHeader)
+			if !validRequestID(id) {
+				id = rand.Text()
+				r.Header.Set(requestIDHeader, id)
+			}
+			w.Header().Set(requestIDHeader, id)
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// validRequestID reports whether id is a non-empty run of at most maxRequestIDLength printable ASCII characters
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] <= ' ' || id[i] > '~' {
+			return false
+		}
+	}
+
+	return true
+}
+
+// withTracing records a server span for every request, named after the template of its route and continuing
+// the trace of the W3C traceparent header when the client sent one. Server errors set the span status to error
+func withTracing(tp trace.TracerProvider) mux.MiddlewareFunc {
+	tracer := tp.Tracer("github.com/duksonn/stay-for-long/internal/infra/http")
+	propagator := propagation.TraceContext{}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			route, err := mux.CurrentRoute(r).GetPathTemplate()
+			if err != nil {
+				route = "unknown"
+			}
+			ctx := propagator.Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+			ctx, span := tracer.Start(ctx, r.Method+" "+route, trace.WithSpanKind(trace.SpanKindServer),
+				trace.WithAttributes(
+					attribute.String("http.request.method", r.Method),
+					attribute.String("http.route", route),
+					attribute.String("url.path", r.URL.Path),
+					attribute.String("request.id", r.Header.Get(requestIDHeader)),
+				))
+			defer span.End()
+			recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+
+			next.ServeHTTP(recorder, r.WithContext(ctx))
+			span.SetAttributes(attribute.Int("http.response.status_code", recorder.status))
+			if recorder.status >= http.StatusInternalServerError {
+				span.SetStatus(codes.Error, http.StatusText(recorder.status))
+			}
+		})
+	}
+}
+
+// withAccessLog logs every request once served, with its ID, method, path, status code, duration, the
+// number of bookings it held when it held any and the ID of its trace when recorded. Server errors are
+// logged at the error level
+func withAccessLog(logger *slog.Logger) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			log := &handler.RequestLog{}
+			recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+
+			start := time.Now()
+			next.ServeHTTP(recorder, r.WithContext(handler.WithRequestLog(r.Context(), log)))
+
+			attrs := []slog.Attr{
+				slog.String("request_id", r.Header.Get(requestIDHeader)),
+				slog.String("method", r.Method),
+				slog.String("path", r.URL.Path),
+				slog.Int("status", recorder.status),
+				slog.Float64("duration_ms", float64(time.Since(start).Microseconds())/1000),
+			}
+			if bookings, ok := log.Bookings(); ok {
+				attrs = append(attrs, slog.Int("bookings", bookings))
+			}
+			if sc := trace.SpanContextFromContext(r.Context()); sc.IsValid() {
+				attrs = append(attrs, slog.String("trace_id", sc.TraceID().String()))
+			}
+			level := slog.LevelInfo
+			if recorder.status >= http.StatusInternalServerError {
+				level = slog.LevelError
+			}
+			logger.LogAttrs(r.Context(), level, "request", attrs...)
+		})
+	}
+}
+
+// withMetrics reports every request to m once served, labeled with the template of its route so the
+// paths holding a job ID are counted together
+func withMetrics(m *metrics.Metrics) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			route, err := mux.CurrentRoute(r).GetPathTemplate()
+			if err != nil {
+				route = "unknown"
+			}
+			body := &countingReader{ReadCloser: r.Body}
+			r.Body = body
+			recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+
+			start := time.Now()
+			next.ServeHTTP(recorder, r)
+			m.ObserveRequest(route, r.Method, recorder.status, time.Since(start), body.read)
+		})
+	}
+}
+
+// statusRecorder remembers the status code of the response written through it
+type statusRecorder struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+}
+
+// WriteHeader records the status code before writing it
+func (r *statusRecorder) WriteHeader(status int) {
+	if !r.wroteHeader {
+		r.status = status
+		r.wroteHeader = true
+	}
+	r.ResponseWriter.WriteHeader(status)
+}
+
+// Write writes the body, the status code being 200 unless written before
+func (r *statusRecorder) Write(b []byte) (int, error) {
+	r.wroteHeader = true
+	return r.ResponseWriter.Write(b)
+}
+
+// Unwrap returns the wrapped writer, so http.ResponseController can still flush streamed responses
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
+
+// countingReader counts the bytes read from a request body
+type countingReader struct {
+	io.ReadCloser
+	read int64
+}
+
+// Read reads from the body, adding the bytes read to the count
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.ReadCloser.Read(p)
+	c.read += int64(n)
+	return n, err
+}
@@ -5,21 +5,106 @@ import (
 
 	"github.com/gorilla/mux"
 
+	"github.com/duksonn/stay-for-long/cmd/config"
 	"github.com/duksonn/stay-for-long/cmd/di"
 	"github.com/duksonn/stay-for-long/internal/infra/http/handler"
+	"github.com/duksonn/stay-for-long/internal/infra/limits"
 )
 
+// apiHandlers holds the handlers serving one version of the API
+type apiHandlers struct {
+	stats   *handler.StatsHandler
+	jobs    *handler.JobHandler
+	graphQL *handler.GraphQLHandler
+}
+
+// Routes mounts every version of the API under its own path prefix
+// The unversioned paths keep serving v1 for the clients written before versioning, flagged as deprecated
 func Routes(deps *di.Dependencies) (*mux.Router, error) {
+	v1, err := newAPIHandlers(deps, handler.V1)
+	if err != nil {
+		return nil, err
+	}
+	v2, err := newAPIHandlers(deps, handler.V2)
+	if err != nil {
+		return nil, err
+	}
+
 	router := mux.NewRouter()
+	router.Use(withRequestID(), withTracing(deps.Tracing), withAccessLog(deps.Logger), withMetrics(deps.Metrics))
+	mountAPI(router.PathPrefix("/v1").Subrouter(), v1, deps.Config)
+	mountAPI(router.PathPrefix("/v2").Subrouter(), v2, deps.Config)
+
+	// API documentation, shared by every version
+	docs := router.NewRoute().Subrouter()
+	docs.Use(withTimeout(deps.Config.WriteTimeout))
+	docs.HandleFunc("/openapi.json", handler.HandlerOpenAPI).Methods(http.MethodGet)
+
+	// Prometheus metrics, outside of any version
+	router.Handle("/metrics", deps.Metrics.Handler()).Methods(http.MethodGet)
+
+	unversioned := router.NewRoute().Subrouter()
+	unversioned.Use(deprecated("/v1"))
+	mountAPI(unversioned, v1, deps.Config)
+
+	return router, nil
+}
 
+// newAPIHandlers creates the handlers serving the given version of the API
+func newAPIHandlers(deps *di.Dependencies, version handler.APIVersion) (*apiHandlers, error) {
 	// Stats endpoints
-	statsHandler, err := handler.NewStatsHandler(deps.StatsSvc)
+	statsHandler, err := handler.NewStatsHandler(deps.StatsSvc, handler.WithLimits(limits.Limits{
+		MaxBodyBytes:         deps.Config.MaxBodyBytes,
+		MaxBookings:          deps.Config.MaxBookings,
+		MaxHeuristicBookings: deps.Config.MaxHeuristicBookings,
+		MaxOptimizerTime:     deps.Config.MaxOptimizerTime,
+	}), handler.WithCSVHeaders(deps.Config.CSVHeaders), handler.WithAPIVersion(version),
+		handler.WithTracerProvider(deps.Tracing))
 	if err != nil {
 		return nil, err
 	}
 
-	router.HandleFunc("/stats", statsHandler.HandlerCalculateStats).Methods(http.MethodPost)
-	router.HandleFunc("/maximize", statsHandler.HandlerMaximizeProfit).Methods(http.MethodPost)
+	// Job endpoints
+	jobHandler, err := handler.NewJobHandler(deps.JobSvc, handler.WithLimits(limits.Limits{
+		MaxBodyBytes:         deps.Config.MaxBodyBytes,
+		MaxBookings:          deps.Config.MaxJobBookings,
+		MaxHeuristicBookings: deps.Config.MaxHeuristicBookings,
+	}), handler.WithCSVHeaders(deps.Config.CSVHeaders), handler.WithAPIVersion(version),
+		handler.WithTracerProvider(deps.Tracing))
+	if err != nil {
+		return nil, err
+	}
 
-	return router, nil
+	// GraphQL endpoint
+	graphQLHandler, err := handler.NewGraphQLHandler(deps.StatsSvc, handler.WithLimits(limits.Limits{
+		MaxBodyBytes:         deps.Config.MaxBodyBytes,
+		MaxBookings:          deps.Config.MaxBookings,
+		MaxHeuristicBookings: deps.Config.MaxHeuristicBookings,
+		MaxOptimizerTime:     deps.Config.MaxOptimizerTime,
+	}), handler.WithAPIVersion(version), handler.WithTracerProvider(deps.Tracing))
+	if err != nil {
+		return nil, err
+	}
+
+	return &apiHandlers{stats: statsHandler, jobs: jobHandler, graphQL: graphQLHandler}, nil
+}
+
+// mountAPI registers the endpoints of one version of the API on router
+func mountAPI(router *mux.Router, h *apiHandlers, cfg *config.Config) {
+	// Streaming endpoints last longer than the write timeout, so it only bounds the api subrouter
+	api := router.NewRoute().Subrouter()
+	api.Use(withTimeout(cfg.WriteTimeout))
+
+	api.HandleFunc("/stats", h.stats.HandlerCalculateStats).Methods(http.MethodPost)
+	api.HandleFunc("/maximize", h.stats.HandlerMaximizeProfit).Methods(http.MethodPost)
+	api.HandleFunc("/maximize/pareto", h.stats.HandlerParetoFrontier).Methods(http.MethodPost)
+	api.HandleFunc("/maximize/sensitivity", h.stats.HandlerSensitivity).Methods(http.MethodPost)
+	api.HandleFunc("/maximize/scenarios", h.stats.HandlerCompareScenarios).Methods(http.MethodPost)
+
+	api.HandleFunc("/graphql", h.graphQL.HandlerGraphQL).Methods(http.MethodPost)
+
+	api.HandleFunc("/maximize/jobs", h.jobs.HandlerSubmitJob).Methods(http.MethodPost)
+	api.HandleFunc("/maximize/jobs/{id}", h.jobs.HandlerGetJob).Methods(http.MethodGet)
+	api.HandleFunc("/maximize/jobs/{id}", h.jobs.HandlerCancelJob).Methods(http.MethodDelete)
+	router.HandleFunc("/maximize/jobs/{id}/events", h.jobs.HandlerJobEvents).Methods(http.MethodGet)
 }
@@ -0,0 +1,276 @@
+package http_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/duksonn/stay-for-long/cmd/config"
+	"github.com/duksonn/stay-for-long/cmd/di"
+	internalhttp "github.com/duksonn/stay-for-long/internal/infra/http"
+	"github.com/duksonn/stay-for-long/internal/infra/tracing"
+)
+
+func TestRoutes_MatchOpenAPI(t *testing.T) {
+	doc, err := openapi3.NewLoader().LoadFromFile("handler/openapi.json")
+	require.NoError(t, err)
+
+	deps, err := di.Init(&config.Config{WriteTimeout: time.Second, JobWorkers: 1, JobQueueSize: 1})
+	require.NoError(t, err)
+	defer deps.JobSvc.Close()
+	router, err := internalhttp.Routes(deps)
+	require.NoError(t, err)
+
+	routes := make(map[string]bool)
+	err = router.Walk(func(route *mux.Route, _ *mux.Router, _ []*mux.Route) error {
+		path, err := route.GetPathTemplate()
+		if err != nil {
+			return nil
+		}
+		methods, err := route.GetMethods()
+		if err != nil {
+			return nil
+		}
+		for _, method := range methods {
+			routes[method+" "+path] = true
+		}
+		return nil
+	})
+	require.NoError(t, err)
+
+	documented := make(map[string]bool)
+	for path, item := range doc.Paths.Map() {
+		servers := doc.Servers
+		if len(item.Servers) > 0 {
+			servers = item.Servers
+		}
+		for _, server := range servers {
+			prefix := strings.TrimSuffix(server.URL, "/")
+			for method := range item.Operations() {
+				documented[method+" "+prefix+path] = true
+			}
+		}
+	}
+
+	for route := range routes {
+		assert.True(t, documented[route], "route %s is not documented in openapi.json", route)
+	}
+	for operation := range documented {
+		assert.True(t, routes[operation], "operation %s has no route", operation)
+	}
+	assert.NotEmpty(t, routes)
+}
+
+func TestRoutes_Versions(t *testing.T) {
+	deps, err := di.Init(&config.Config{WriteTimeout: time.Second, JobWorkers: 1, JobQueueSize: 1})
+	require.NoError(t, err)
+	defer deps.JobSvc.Close()
+	router, err := internalhttp.Routes(deps)
+	require.NoError(t, err)
+
+	bookings := `[{"request_id":"bookata_XY123","check_in":"2020-01-01","nights":5,"selling_rate":200,"margin":20}]`
+	tests := []struct {
+		name           string
+		method         string
+		path           string
+		body           string
+		expectedStatus int
+		expectedBody   string
+		deprecatedBy   string
+	}{
+		{name: "v1 stats", method: http.MethodPost, path: "/v1/stats", body: bookings, expectedStatus: http.StatusOK, expectedBody: `{"avg_night":8,"min_night":8,"max_night":8}`},
+		{name: "v2 stats", method: http.MethodPost, path: "/v2/stats", body: bookings, expectedStatus: http.StatusOK, expectedBody: `{"avg_night":8,"min_night":8,"max_night":8}`},
+		{
+			name: "unversioned stats", method: http.MethodPost, path: "/stats", body: bookings,
+			expectedStatus: http.StatusOK, expectedBody: `{"avg_night":8,"min_night":8,"max_night":8}`, deprecatedBy: "</v1/stats>",
+		},
+		{
+			name: "v1 maximize ignores the options", method: http.MethodPost, path: "/v1/maximize?objective=fun", body: bookings,
+			expectedStatus: http.StatusOK, expectedBody: `{"request_ids":["bookata_XY123"],"total_profit":40,"avg_night":8,"min_night":8,"max_night":8}`,
+		},
+		{name: "v1 error without code", method: http.MethodPost, path: "/v1/stats", body: "{", expectedStatus: http.StatusBadRequest, expectedBody: `{}`},
+		{
+			name: "v2 error with code", method: http.MethodPost, path: "/v2/stats", body: "{",
+			expectedStatus: http.StatusBadRequest, expectedBody: `{"code":"invalid_request","error":"invalid request json"}`,
+		},
+		{
+			name: "unversioned error without code", method: http.MethodPost, path: "/maximize", body: "{",
+			expectedStatus: http.StatusBadRequest, expectedBody: `{}`, deprecatedBy: "</v1/maximize>",
+		},
+		{
+			name: "v2 unknown job", method: http.MethodGet, path: "/v2/maximize/jobs/job1",
+			expectedStatus: http.StatusNotFound, expectedBody: `{"code":"job_not_found","error":"job not found"}`,
+		},
+		{
+			name: "unversioned job", method: http.MethodGet, path: "/maximize/jobs/job1",
+			expectedStatus: http.StatusNotFound, expectedBody: `{}`, deprecatedBy: "</v1/maximize/jobs/job1>",
+		},
+		{name: "unknown version", method: http.MethodPost, path: "/v3/stats", body: bookings, expectedStatus: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body)))
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.expectedBody != "" {
+				assert.JSONEq(t, tt.expectedBody, w.Body.String())
+			}
+			if tt.deprecatedBy == "" {
+				assert.Empty(t, w.Header().Get("Deprecation"))
+				assert.Empty(t, w.Header().Get("Link"))
+				return
+			}
+			assert.Equal(t, "@1792281600", w.Header().Get("Deprecation"))
+			assert.Equal(t, tt.deprecatedBy+`; rel="successor-version"`, w.Header().Get("Link"))
+		})
+	}
+}
+
+func TestRoutes_Metrics(t *testing.T) {
+	deps, err := di.Init(&config.Config{WriteTimeout: time.Second, JobWorkers: 1, JobQueueSize: 1})
+	require.NoError(t, err)
+	defer deps.JobSvc.Close()
+	router, err := internalhttp.Routes(deps)
+	require.NoError(t, err)
+
+	bookings := `[{"request_id":"bookata_XY123","check_in":"2020-01-01","nights":5,"selling_rate":200,"margin":20},
+		{"request_id":"acme_AAAAA","check_in":"2020-01-03","nights":4,"selling_rate":160,"margin":30}]`
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/v1/maximize", strings.NewReader(bookings)))
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/v2/maximize/jobs/job1", nil))
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+
+	body := w.Body.String()
+	for _, line := range []string{
+		`stayforlong_http_requests_total{method="POST",route="/v1/maximize",status="200"} 1`,
+		`stayforlong_http_requests_total{method="GET",route="/v2/maximize/jobs/{id}",status="404"} 1`,
+		fmt.Sprintf(`stayforlong_http_request_size_bytes_sum{route="/v1/maximize"} %d`, len(bookings)),
+		`stayforlong_bookings_sum{operation="maximize"} 2`,
+		`stayforlong_optimizer_search_evaluated_sum{mode="exact",outcome="completed"} 3`,
+	} {
+		assert.Contains(t, body, line)
+	}
+}
+
+func TestRoutes_AccessLog(t *testing.T) {
+	deps, err := di.Init(&config.Config{WriteTimeout: time.Second, JobWorkers: 1, JobQueueSize: 1})
+	require.NoError(t, err)
+	defer deps.JobSvc.Close()
+	var logs bytes.Buffer
+	deps.Logger = slog.New(slog.NewJSONHandler(&logs, nil))
+	router, err := internalhttp.Routes(deps)
+	require.NoError(t, err)
+
+	bookings := `[{"request_id":"bookata_XY123","check_in":"2020-01-01","nights":5,"selling_rate":200,"margin":20},
+		{"request_id":"acme_AAAAA","check_in":"2020-01-03","nights":4,"selling_rate":160,"margin":30}]`
+	tests := []struct {
+		name          string
+		method        string
+		path          string
+		body          string
+		requestID     string
+		wantRequestID string
+		wantLog       map[string]interface{}
+	}{
+		{
+			name: "request id propagated", method: http.MethodPost, path: "/v1/maximize", body: bookings,
+			requestID: "client-42", wantRequestID: "client-42",
+			wantLog: map[string]interface{}{"level": "INFO", "method": "POST", "path": "/v1/maximize", "status": 200.0, "bookings": 2.0},
+		},
+		{
+			name: "request id generated", method: http.MethodGet, path: "/v2/maximize/jobs/job1",
+			wantLog: map[string]interface{}{"level": "INFO", "method": "GET", "path": "/v2/maximize/jobs/job1", "status": 404.0},
+		},
+		{
+			name: "unusable request id replaced", method: http.MethodPost, path: "/stats", body: "{", requestID: "not an id",
+			wantLog: map[string]interface{}{"level": "INFO", "method": "POST", "path": "/stats", "status": 400.0},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logs.Reset()
+			req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+			if tt.requestID != "" {
+				req.Header.Set("X-Request-ID", tt.requestID)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			requestID := w.Header().Get("X-Request-ID")
+			if tt.wantRequestID != "" {
+				assert.Equal(t, tt.wantRequestID, requestID)
+			} else {
+				assert.Len(t, requestID, 26)
+			}
+
+			var entry map[string]interface{}
+			require.NoError(t, json.Unmarshal(logs.Bytes(), &entry), logs.String())
+			assert.Equal(t, "request", entry["msg"])
+			assert.Equal(t, requestID, entry["request_id"])
+			assert.Contains(t, entry, "duration_ms")
+			for key, value := range tt.wantLog {
+				assert.Equal(t, value, entry[key], key)
+			}
+			if _, ok := tt.wantLog["bookings"]; !ok {
+				assert.NotContains(t, entry, "bookings")
+			}
+		})
+	}
+}
+
+func TestRoutes_Tracing(t *testing.T) {
+	deps, err := di.Init(&config.Config{WriteTimeout: time.Second, JobWorkers: 1, JobQueueSize: 1})
+	require.NoError(t, err)
+	defer deps.JobSvc.Close()
+	exporter := tracetest.NewInMemoryExporter()
+	deps.Tracing = &tracing.Tracing{TracerProvider: sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))}
+	var logs bytes.Buffer
+	deps.Logger = slog.New(slog.NewJSONHandler(&logs, nil))
+	router, err := internalhttp.Routes(deps)
+	require.NoError(t, err)
+
+	bookings := `[{"request_id":"bookata_XY123","check_in":"2020-01-01","nights":5,"selling_rate":200,"margin":20}]`
+	req := httptest.NewRequest(http.MethodPost, "/v1/maximize", strings.NewReader(bookings))
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	req.Header.Set("X-Request-ID", "client-42")
+	router.ServeHTTP(httptest.NewRecorder(), req)
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 2)
+	decode, server := spans[0], spans[1]
+	assert.Equal(t, "POST /v1/maximize", server.Name)
+	assert.Equal(t, trace.SpanKindServer, server.SpanKind)
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", server.SpanContext.TraceID().String())
+	assert.Equal(t, "00f067aa0ba902b7", server.Parent.SpanID().String(), "the trace of the client is continued")
+	assert.Subset(t, server.Attributes, []attribute.KeyValue{
+		attribute.String("http.route", "/v1/maximize"),
+		attribute.String("request.id", "client-42"),
+		attribute.Int("http.response.status_code", http.StatusOK),
+	})
+	assert.Equal(t, "decode request", decode.Name)
+	assert.Equal(t, server.SpanContext.SpanID(), decode.Parent.SpanID())
+
+	var entry map[string]interface{}
+	require.NoError(t, json.Unmarshal(logs.Bytes(), &entry), logs.String())
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", entry["trace_id"])
+}
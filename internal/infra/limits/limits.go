@@ -0,0 +1,85 @@
+package limits
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/duksonn/stay-for-long/internal/domain"
+)
+
+var (
+	// ErrTooManyBookings is returned when a request holds more bookings than the configured maximum
+	ErrTooManyBookings = errors.New("too many bookings")
+	// ErrOptimizerTimeExceeded is returned when the optimizer runs longer than the configured maximum
+	ErrOptimizerTimeExceeded = errors.New("optimizer time limit exceeded")
+)
+
+// Limits caps the size and complexity of the requests accepted by the API adapters
+// A zero value disables the corresponding limit. MaxHeuristicBookings replaces MaxBookings for the
+// requests solved in heuristic mode, whose run time is bounded by their budget instead of their size
+type Limits struct {
+	MaxBodyBytes         int64
+	MaxBookings          int
+	MaxHeuristicBookings int
+	MaxOptimizerTime     time.Duration
+}
+
+// ForMode returns the limits that apply to a request solved in the given mode
+func (l Limits) ForMode(mode domain.Mode) Limits {
+	if mode == domain.ModeHeuristic {
+		l.MaxBookings = l.MaxHeuristicBookings
+	}
+
+	return l
+}
+
+// CheckBudget fails with domain.ErrInvalidBudget when a heuristic budget would outlast MaxOptimizerTime
+func (l Limits) CheckBudget(budget time.Duration) error {
+	if l.MaxOptimizerTime > 0 && budget > l.MaxOptimizerTime {
+		return fmt.Errorf("%w: got %s, limit is %s", domain.ErrInvalidBudget, budget, l.MaxOptimizerTime)
+	}
+
+	return nil
+}
+
+// CheckBookings fails with ErrTooManyBookings when count exceeds MaxBookings
+func (l Limits) CheckBookings(count int) error {
+	if l.MaxBookings > 0 && count > l.MaxBookings {
+		return fmt.Errorf("%w: got %d, limit is %d", ErrTooManyBookings, count, l.MaxBookings)
+	}
+
+	return nil
+}
+
+// CheckScenarioBookings applies MaxBookings to the base and to every scenario, counting the bookings each
+// scenario adds on top of the base
+func (l Limits) CheckScenarioBookings(base []*domain.Booking, scenarios []domain.Scenario) error {
+	if err := l.CheckBookings(len(base)); err != nil {
+		return err
+	}
+	for _, s := range scenarios {
+		count := len(base)
+		for _, patch := range s.Patches {
+			if _, ok := patch.(domain.AddBooking); ok {
+				count++
+			}
+		}
+		if err := l.CheckBookings(count); err != nil {
+			return fmt.Errorf("scenario %q: %w", s.Name, err)
+		}
+	}
+
+	return nil
+}
+
+// OptimizerContext bounds ctx to MaxOptimizerTime, using ErrOptimizerTimeExceeded as the cause so
+// the limit can be told apart from the deadlines of the server and of the client
+func (l Limits) OptimizerContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	if l.MaxOptimizerTime <= 0 {
+		return context.WithCancel(ctx)
+	}
+
+	return context.WithTimeoutCause(ctx, l.MaxOptimizerTime, ErrOptimizerTimeExceeded)
+}
@@ -0,0 +1,78 @@
+package limits_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/duksonn/stay-for-long/internal/domain"
+	"github.com/duksonn/stay-for-long/internal/infra/limits"
+)
+
+func TestLimits_CheckBookings(t *testing.T) {
+	l := limits.Limits{MaxBookings: 2, MaxHeuristicBookings: 5}
+
+	tests := []struct {
+		name    string
+		limits  limits.Limits
+		count   int
+		wantErr string
+	}{
+		{name: "within the limit", limits: l, count: 2},
+		{name: "over the limit", limits: l, count: 3, wantErr: "too many bookings: got 3, limit is 2"},
+		{name: "heuristic mode limit", limits: l.ForMode(domain.ModeHeuristic), count: 5},
+		{name: "exact mode limit", limits: l.ForMode(domain.ModeExact), count: 5, wantErr: "too many bookings: got 5, limit is 2"},
+		{name: "zero disables the limit", limits: limits.Limits{}, count: 1000},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.limits.CheckBookings(tt.count)
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.ErrorIs(t, err, limits.ErrTooManyBookings)
+			assert.EqualError(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestLimits_CheckScenarioBookings(t *testing.T) {
+	l := limits.Limits{MaxBookings: 2}
+	base := []*domain.Booking{{RequestID: "a"}, {RequestID: "b"}}
+
+	assert.NoError(t, l.CheckScenarioBookings(base, []domain.Scenario{
+		{Name: "blackout", Patches: []domain.Patch{domain.Blackout{Period: &domain.Booking{}}}},
+	}))
+
+	err := l.CheckScenarioBookings(base, []domain.Scenario{
+		{Name: "extra", Patches: []domain.Patch{domain.AddBooking{Booking: &domain.Booking{RequestID: "c"}}}},
+	})
+	assert.ErrorIs(t, err, limits.ErrTooManyBookings)
+	assert.EqualError(t, err, `scenario "extra": too many bookings: got 3, limit is 2`)
+}
+
+func TestLimits_CheckBudget(t *testing.T) {
+	l := limits.Limits{MaxOptimizerTime: time.Second}
+
+	assert.NoError(t, l.CheckBudget(time.Second))
+	assert.ErrorIs(t, l.CheckBudget(2*time.Second), domain.ErrInvalidBudget)
+	assert.NoError(t, limits.Limits{}.CheckBudget(time.Hour))
+}
+
+func TestLimits_OptimizerContext(t *testing.T) {
+	ctx, cancel := limits.Limits{MaxOptimizerTime: time.Millisecond}.OptimizerContext(context.Background())
+	defer cancel()
+	<-ctx.Done()
+	assert.ErrorIs(t, context.Cause(ctx), limits.ErrOptimizerTimeExceeded)
+
+	ctx, cancel = limits.Limits{}.OptimizerContext(context.Background())
+	_, ok := ctx.Deadline()
+	require.False(t, ok)
+	cancel()
+	assert.ErrorIs(t, context.Cause(ctx), context.Canceled)
+}
@@ -0,0 +1,74 @@
+package memory
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/duksonn/stay-for-long/internal/domain"
+	"github.com/duksonn/stay-for-long/internal/ports"
+)
+
+// Ensure JobStore implements the ports.JobStore interface
+var _ ports.JobStore = (*JobStore)(nil)
+
+// JobStore implements the ports.JobStore interface keeping the jobs in memory
+// Jobs are lost when the process stops and are not shared between instances. Finished jobs are
+// forgotten once they are older than the retention or, oldest first, once there are more than maxFinished
+type JobStore struct {
+	mu          sync.RWMutex
+	jobs        map[string]domain.Job
+	finished    []string
+	retention   time.Duration
+	maxFinished int
+}
+
+// NewJobStore creates and returns an empty JobStore
+// A zero retention keeps the finished jobs regardless of their age and a zero maxFinished regardless of their number
+func NewJobStore(retention time.Duration, maxFinished int) *JobStore {
+	return &JobStore{jobs: make(map[string]domain.Job), retention: retention, maxFinished: maxFinished}
+}
+
+// Save stores a copy of the job, replacing any previous one with the same ID
+// The finished jobs past their retention are removed at the same time
+func (s *JobStore) Save(_ context.Context, job *domain.Job) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	previous, ok := s.jobs[job.ID]
+	if job.Status.Finished() && (!ok || !previous.Status.Finished()) {
+		s.finished = append(s.finished, job.ID)
+	}
+	s.jobs[job.ID] = *job
+	s.prune(time.Now())
+
+	return nil
+}
+
+// Get returns a copy of the job with the given ID
+func (s *JobStore) Get(_ context.Context, id string) (*domain.Job, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	job, ok := s.jobs[id]
+	if !ok || s.expired(job, time.Now()) {
+		return nil, domain.ErrJobNotFound
+	}
+
+	return &job, nil
+}
+
+// prune removes the finished jobs, in the order they finished, while they are expired or too many
+func (s *JobStore) prune(now time.Time) {
+	for len(s.finished) > 0 {
+		id := s.finished[0]
+		if job, ok := s.jobs[id]; ok && !s.expired(job, now) && (s.maxFinished <= 0 || len(s.finished) <= s.maxFinished) {
+			return
+		}
+		delete(s.jobs, id)
+		s.finished = s.finished[1:]
+	}
+}
+
+// expired reports whether a finished job is past the retention at now
+func (s *JobStore) expired(job domain.Job, now time.Time) bool {
+	return s.retention > 0 && job.Status.Finished() && now.Sub(job.UpdatedAt) > s.retention
+}
@@ -0,0 +1,119 @@
+package memory_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/duksonn/stay-for-long/internal/domain"
+	"github.com/duksonn/stay-for-long/internal/infra/memory"
+)
+
+func TestJobStore(t *testing.T) {
+	store := memory.NewJobStore(0, 0)
+	ctx := context.Background()
+
+	_, err := store.Get(ctx, "job1")
+	assert.ErrorIs(t, err, domain.ErrJobNotFound)
+
+	job := &domain.Job{ID: "job1", Status: domain.JobQueued}
+	require.NoError(t, store.Save(ctx, job))
+
+	// the store keeps its own copy of the job
+	job.Status = domain.JobRunning
+	stored, err := store.Get(ctx, "job1")
+	require.NoError(t, err)
+	assert.Equal(t, domain.JobQueued, stored.Status)
+
+	stored.Progress = 0.5
+	again, err := store.Get(ctx, "job1")
+	require.NoError(t, err)
+	assert.Equal(t, float64(0), again.Progress)
+
+	require.NoError(t, store.Save(ctx, job))
+	stored, err = store.Get(ctx, "job1")
+	require.NoError(t, err)
+	assert.Equal(t, domain.JobRunning, stored.Status)
+}
+
+func TestJobStore_Retention(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+
+	tests := []struct {
+		name        string
+		retention   time.Duration
+		maxFinished int
+		jobs        []domain.Job
+		expected    []string
+		forgotten   []string
+	}{
+		{
+			name:      "finished jobs past the retention are forgotten",
+			retention: time.Hour,
+			jobs: []domain.Job{
+				{ID: "old", Status: domain.JobSucceeded, UpdatedAt: now.Add(-2 * time.Hour)},
+				{ID: "running", Status: domain.JobRunning, UpdatedAt: now.Add(-2 * time.Hour)},
+				{ID: "recent", Status: domain.JobFailed, UpdatedAt: now.Add(-time.Minute)},
+			},
+			expected:  []string{"running", "recent"},
+			forgotten: []string{"old"},
+		},
+		{
+			name:        "oldest finished jobs are forgotten over the maximum",
+			maxFinished: 2,
+			jobs: []domain.Job{
+				{ID: "first", Status: domain.JobSucceeded, UpdatedAt: now},
+				{ID: "queued", Status: domain.JobQueued, UpdatedAt: now},
+				{ID: "second", Status: domain.JobCanceled, UpdatedAt: now},
+				{ID: "third", Status: domain.JobSucceeded, UpdatedAt: now},
+			},
+			expected:  []string{"queued", "second", "third"},
+			forgotten: []string{"first"},
+		},
+		{
+			name: "zero values keep every job",
+			jobs: []domain.Job{
+				{ID: "old", Status: domain.JobSucceeded, UpdatedAt: now.Add(-24 * time.Hour)},
+				{ID: "recent", Status: domain.JobSucceeded, UpdatedAt: now},
+			},
+			expected: []string{"old", "recent"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := memory.NewJobStore(tt.retention, tt.maxFinished)
+			for _, job := range tt.jobs {
+				require.NoError(t, store.Save(ctx, &job))
+			}
+
+			for _, id := range tt.expected {
+				_, err := store.Get(ctx, id)
+				assert.NoError(t, err, id)
+			}
+			for _, id := range tt.forgotten {
+				_, err := store.Get(ctx, id)
+				assert.ErrorIs(t, err, domain.ErrJobNotFound, id)
+			}
+		})
+	}
+}
+
+func TestJobStore_RetentionCountsFinishedJobsOnce(t *testing.T) {
+	store := memory.NewJobStore(0, 1)
+	ctx := context.Background()
+
+	// saving a finished job again does not count it twice
+	job := &domain.Job{ID: "job1", Status: domain.JobRunning}
+	require.NoError(t, store.Save(ctx, job))
+	job.Status = domain.JobSucceeded
+	require.NoError(t, store.Save(ctx, job))
+	require.NoError(t, store.Save(ctx, job))
+
+	_, err := store.Get(ctx, "job1")
+	assert.NoError(t, err)
+}
@@ -0,0 +1,110 @@
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+
+	"github.com/duksonn/stay-for-long/internal/domain"
+	"github.com/duksonn/stay-for-long/internal/ports"
+)
+
+// namespace prefixes the name of every metric
+const namespace = "stayforlong"
+
+// Ensure Metrics implements the ports.OptimizerObserver interface
+var _ ports.OptimizerObserver = (*Metrics)(nil)
+
+// Metrics holds the Prometheus collectors of the service and serves them in the text exposition format
+// It implements ports.OptimizerObserver, so the application services report the size of their inputs
+// and the searches of the optimizer, while the HTTP server reports every request through ObserveRequest
+type Metrics struct {
+	registry        *prometheus.Registry
+	requests        *prometheus.CounterVec
+	requestDuration *prometheus.HistogramVec
+	requestSize     *prometheus.HistogramVec
+	bookings        *prometheus.HistogramVec
+	searchDuration  *prometheus.HistogramVec
+	searchEvaluated *prometheus.HistogramVec
+}
+
+// New creates the collectors on a registry of their own, together with the Go runtime and process ones
+func New() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "http_requests_total",
+			Help:      "HTTP requests served, by route template, method and status code.",
+		}, []string{"route", "method", "status"}),
+		requestDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "http_request_duration_seconds",
+			Help:      "Time taken to serve the HTTP requests, by route template, method and status code.",
+			Buckets:   prometheus.ExponentialBuckets(0.005, 4, 9),
+		}, []string{"route", "method", "status"}),
+		requestSize: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "http_request_size_bytes",
+			Help:      "Bytes read from the HTTP request bodies, by route template.",
+			Buckets:   prometheus.ExponentialBuckets(256, 4, 8),
+		}, []string{"route"}),
+		bookings: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "bookings",
+			Help:      "Bookings the operations are called with, by operation.",
+			Buckets:   prometheus.ExponentialBuckets(1, 2, 11),
+		}, []string{"operation"}),
+		searchDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "optimizer_search_duration_seconds",
+			Help:      "Time taken by the searches for the optimal selection, by mode and outcome.",
+			Buckets:   prometheus.ExponentialBuckets(0.001, 4, 10),
+		}, []string{"mode", "outcome"}),
+		searchEvaluated: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "optimizer_search_evaluated",
+			Help:      "Combinations checked by the exact searches and moves tried by the heuristic ones, by mode and outcome.",
+			Buckets:   prometheus.ExponentialBuckets(1, 4, 13),
+		}, []string{"mode", "outcome"}),
+	}
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.requests, m.requestDuration, m.requestSize, m.bookings, m.searchDuration, m.searchEvaluated,
+	)
+
+	return m
+}
+
+// Handler serves the metrics in the Prometheus text exposition format
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{Registry: m.registry})
+}
+
+// ObserveRequest records a served HTTP request, route being the template of the matched route
+func (m *Metrics) ObserveRequest(route, method string, status int, duration time.Duration, bodyBytes int64) {
+	code := strconv.Itoa(status)
+	m.requests.WithLabelValues(route, method, code).Inc()
+	m.requestDuration.WithLabelValues(route, method, code).Observe(duration.Seconds())
+	m.requestSize.WithLabelValues(route).Observe(float64(bodyBytes))
+}
+
+// ObserveBookings records the number of bookings an operation is called with
+func (m *Metrics) ObserveBookings(operation string, count int) {
+	m.bookings.WithLabelValues(operation).Observe(float64(count))
+}
+
+// ObserveSearch records the duration and the work of a finished search for the optimal selection
+func (m *Metrics) ObserveSearch(stats domain.SearchStats) {
+	outcome := "completed"
+	if stats.Aborted {
+		outcome = "aborted"
+	}
+	m.searchDuration.WithLabelValues(string(stats.Mode), outcome).Observe(stats.Duration.Seconds())
+	m.searchEvaluated.WithLabelValues(string(stats.Mode), outcome).Observe(float64(stats.Evaluated))
+}
@@ -0,0 +1,41 @@
+package metrics_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/duksonn/stay-for-long/internal/domain"
+	"github.com/duksonn/stay-for-long/internal/infra/metrics"
+)
+
+func TestMetrics(t *testing.T) {
+	m := metrics.New()
+	m.ObserveRequest("/v1/maximize/jobs/{id}", http.MethodGet, http.StatusNotFound, 20*time.Millisecond, 0)
+	m.ObserveRequest("/v1/maximize", http.MethodPost, http.StatusOK, 2*time.Second, 300)
+	m.ObserveBookings("maximize", 12)
+	m.ObserveSearch(domain.SearchStats{Mode: domain.ModeExact, Evaluated: 4095, Duration: 3 * time.Millisecond})
+	m.ObserveSearch(domain.SearchStats{Mode: domain.ModeHeuristic, Evaluated: 10, Duration: time.Second, Aborted: true})
+
+	w := httptest.NewRecorder()
+	m.Handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+
+	body := w.Body.String()
+	for _, line := range []string{
+		`stayforlong_http_requests_total{method="GET",route="/v1/maximize/jobs/{id}",status="404"} 1`,
+		`stayforlong_http_request_duration_seconds_bucket{method="POST",route="/v1/maximize",status="200",le="5.12"} 1`,
+		`stayforlong_http_request_size_bytes_sum{route="/v1/maximize"} 300`,
+		`stayforlong_bookings_bucket{operation="maximize",le="8"} 0`,
+		`stayforlong_bookings_bucket{operation="maximize",le="16"} 1`,
+		`stayforlong_optimizer_search_evaluated_sum{mode="exact",outcome="completed"} 4095`,
+		`stayforlong_optimizer_search_duration_seconds_count{mode="heuristic",outcome="aborted"} 1`,
+		`go_goroutines`,
+	} {
+		assert.Contains(t, body, line)
+	}
+}
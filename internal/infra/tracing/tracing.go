@@ -0,0 +1,80 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+// Exporters the spans can be sent to
+const (
+	// ExporterNone records no spans
+	ExporterNone = "none"
+	// ExporterStdout writes every span as a line of JSON to the standard error, keeping the standard output
+	// for the logs
+	ExporterStdout = "stdout"
+	// ExporterOTLP sends the spans over OTLP/gRPC, to the collector set by the OTEL_EXPORTER_OTLP_* env vars
+	ExporterOTLP = "otlp"
+)
+
+// serviceName names the service in the resource of its spans, unless OTEL_SERVICE_NAME is set
+const serviceName = "stay-for-long"
+
+// ErrUnknownExporter is returned when the traces exporter is not one of the supported ones
+var ErrUnknownExporter = errors.New("unknown traces exporter")
+
+// Tracing provides the tracers of the service and flushes the spans they record on shutdown
+type Tracing struct {
+	trace.TracerProvider
+	shutdown func(context.Context) error
+}
+
+// New creates the tracer provider of the service, batching its spans to the named exporter
+// ExporterNone, or an empty name, returns a provider recording nothing
+func New(ctx context.Context, exporter string) (*Tracing, error) {
+	var spanExporter sdktrace.SpanExporter
+	var err error
+	switch exporter {
+	case "", ExporterNone:
+		return &Tracing{TracerProvider: noop.NewTracerProvider()}, nil
+	case ExporterStdout:
+		spanExporter, err = stdouttrace.New(stdouttrace.WithWriter(os.Stderr))
+	case ExporterOTLP:
+		spanExporter, err = otlptracegrpc.New(ctx)
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnknownExporter, exporter)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := resource.New(ctx,
+		resource.WithAttributes(attribute.String("service.name", serviceName)),
+		resource.WithFromEnv(),
+		resource.WithTelemetrySDK(),
+	)
+	if err != nil {
+		return nil, err
+	}
+	provider := sdktrace.NewTracerProvider(sdktrace.WithBatcher(spanExporter), sdktrace.WithResource(res))
+
+	return &Tracing{TracerProvider: provider, shutdown: provider.Shutdown}, nil
+}
+
+// Shutdown exports the spans still buffered and stops the exporter
+func (t *Tracing) Shutdown(ctx context.Context) error {
+	if t.shutdown == nil {
+		return nil
+	}
+
+	return t.shutdown(ctx)
+}
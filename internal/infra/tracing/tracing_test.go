@@ -0,0 +1,42 @@
+package tracing_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/duksonn/stay-for-long/internal/infra/tracing"
+)
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name          string
+		exporter      string
+		wantRecording bool
+		wantErr       error
+	}{
+		{name: "default", exporter: ""},
+		{name: "none", exporter: tracing.ExporterNone},
+		{name: "stdout", exporter: tracing.ExporterStdout, wantRecording: true},
+		{name: "otlp", exporter: tracing.ExporterOTLP, wantRecording: true},
+		{name: "unknown", exporter: "zipkin", wantErr: tracing.ErrUnknownExporter},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tr, err := tracing.New(context.Background(), tt.exporter)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+
+			_, span := tr.Tracer("test").Start(context.Background(), "span")
+			assert.Equal(t, tt.wantRecording, span.IsRecording())
+			// the span is left open, so nothing is exported and no collector is needed
+			assert.NoError(t, tr.Shutdown(context.Background()))
+		})
+	}
+}
@@ -0,0 +1,71 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: internal/ports/job_store.go
+//
+// Generated by this command:
+//
+//	mockgen --source=internal/ports/job_store.go --destination=internal/mocks/mock_job_store.go --package=mocks
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+
+	domain "github.com/duksonn/stay-for-long/internal/domain"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockJobStore is a mock of JobStore interface.
+type MockJobStore struct {
+	ctrl     *gomock.Controller
+	recorder *MockJobStoreMockRecorder
+	isgomock struct{}
+}
+
+// MockJobStoreMockRecorder is the mock recorder for MockJobStore.
+type MockJobStoreMockRecorder struct {
+	mock *MockJobStore
+}
+
+// NewMockJobStore creates a new mock instance.
+func NewMockJobStore(ctrl *gomock.Controller) *MockJobStore {
+	mock := &MockJobStore{ctrl: ctrl}
+	mock.recorder = &MockJobStoreMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockJobStore) EXPECT() *MockJobStoreMockRecorder {
+	return m.recorder
+}
+
+// Get mocks base method.
+func (m *MockJobStore) Get(ctx context.Context, id string) (*domain.Job, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Get", ctx, id)
+	ret0, _ := ret[0].(*domain.Job)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Get indicates an expected call of Get.
+func (mr *MockJobStoreMockRecorder) Get(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockJobStore)(nil).Get), ctx, id)
+}
+
+// Save mocks base method.
+func (m *MockJobStore) Save(ctx context.Context, job *domain.Job) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Save", ctx, job)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Save indicates an expected call of Save.
+func (mr *MockJobStoreMockRecorder) Save(ctx, job any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Save", reflect.TypeOf((*MockJobStore)(nil).Save), ctx, job)
+}
@@ -0,0 +1,65 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: internal/ports/observer.go
+//
+// Generated by this command:
+//
+//	mockgen --source=internal/ports/observer.go --destination=internal/mocks/mock_observer.go --package=mocks
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	reflect "reflect"
+
+	domain "github.com/duksonn/stay-for-long/internal/domain"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockOptimizerObserver is a mock of OptimizerObserver interface.
+type MockOptimizerObserver struct {
+	ctrl     *gomock.Controller
+	recorder *MockOptimizerObserverMockRecorder
+	isgomock struct{}
+}
+
+// MockOptimizerObserverMockRecorder is the mock recorder for MockOptimizerObserver.
+type MockOptimizerObserverMockRecorder struct {
+	mock *MockOptimizerObserver
+}
+
+// NewMockOptimizerObserver creates a new mock instance.
+func NewMockOptimizerObserver(ctrl *gomock.Controller) *MockOptimizerObserver {
+	mock := &MockOptimizerObserver{ctrl: ctrl}
+	mock.recorder = &MockOptimizerObserverMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockOptimizerObserver) EXPECT() *MockOptimizerObserverMockRecorder {
+	return m.recorder
+}
+
+// ObserveBookings mocks base method.
+func (m *MockOptimizerObserver) ObserveBookings(operation string, count int) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "ObserveBookings", operation, count)
+}
+
+// ObserveBookings indicates an expected call of ObserveBookings.
+func (mr *MockOptimizerObserverMockRecorder) ObserveBookings(operation, count any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ObserveBookings", reflect.TypeOf((*MockOptimizerObserver)(nil).ObserveBookings), operation, count)
+}
+
+// ObserveSearch mocks base method.
+func (m *MockOptimizerObserver) ObserveSearch(stats domain.SearchStats) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "ObserveSearch", stats)
+}
+
+// ObserveSearch indicates an expected call of ObserveSearch.
+func (mr *MockOptimizerObserverMockRecorder) ObserveSearch(stats any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ObserveSearch", reflect.TypeOf((*MockOptimizerObserver)(nil).ObserveSearch), stats)
+}
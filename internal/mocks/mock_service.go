@@ -10,6 +10,7 @@
 package mocks
 
 import (
+	context "context"
 	reflect "reflect"
 
 	domain "github.com/duksonn/stay-for-long/internal/domain"
@@ -41,29 +42,160 @@ func (m *MockStatsService) EXPECT() *MockStatsServiceMockRecorder {
 }
 
 // CalculateStats mocks base method.
-func (m *MockStatsService) CalculateStats(requests domain.Bookings) *domain.StatsResult {
+func (m *MockStatsService) CalculateStats(ctx context.Context, requests domain.Bookings) (*domain.StatsResult, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "CalculateStats", requests)
+	ret := m.ctrl.Call(m, "CalculateStats", ctx, requests)
 	ret0, _ := ret[0].(*domain.StatsResult)
-	return ret0
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
 }
 
 // CalculateStats indicates an expected call of CalculateStats.
-func (mr *MockStatsServiceMockRecorder) CalculateStats(requests any) *gomock.Call {
+func (mr *MockStatsServiceMockRecorder) CalculateStats(ctx, requests any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CalculateStats", reflect.TypeOf((*MockStatsService)(nil).CalculateStats), requests)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CalculateStats", reflect.TypeOf((*MockStatsService)(nil).CalculateStats), ctx, requests)
+}
+
+// CompareScenarios mocks base method.
+func (m *MockStatsService) CompareScenarios(ctx context.Context, base domain.Bookings, scenarios []domain.Scenario, opts domain.MaximizeOptions) (*domain.ScenarioComparison, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CompareScenarios", ctx, base, scenarios, opts)
+	ret0, _ := ret[0].(*domain.ScenarioComparison)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CompareScenarios indicates an expected call of CompareScenarios.
+func (mr *MockStatsServiceMockRecorder) CompareScenarios(ctx, base, scenarios, opts any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CompareScenarios", reflect.TypeOf((*MockStatsService)(nil).CompareScenarios), ctx, base, scenarios, opts)
 }
 
 // MaximizeProfit mocks base method.
-func (m *MockStatsService) MaximizeProfit(requests domain.Bookings) *domain.MaximizeResult {
+func (m *MockStatsService) MaximizeProfit(ctx context.Context, requests domain.Bookings, opts domain.MaximizeOptions) (*domain.MaximizeResult, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "MaximizeProfit", requests)
+	ret := m.ctrl.Call(m, "MaximizeProfit", ctx, requests, opts)
 	ret0, _ := ret[0].(*domain.MaximizeResult)
-	return ret0
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
 }
 
 // MaximizeProfit indicates an expected call of MaximizeProfit.
-func (mr *MockStatsServiceMockRecorder) MaximizeProfit(requests any) *gomock.Call {
+func (mr *MockStatsServiceMockRecorder) MaximizeProfit(ctx, requests, opts any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MaximizeProfit", reflect.TypeOf((*MockStatsService)(nil).MaximizeProfit), ctx, requests, opts)
+}
+
+// ParetoFrontier mocks base method.
+func (m *MockStatsService) ParetoFrontier(ctx context.Context, requests domain.Bookings) ([]*domain.MaximizeResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ParetoFrontier", ctx, requests)
+	ret0, _ := ret[0].([]*domain.MaximizeResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ParetoFrontier indicates an expected call of ParetoFrontier.
+func (mr *MockStatsServiceMockRecorder) ParetoFrontier(ctx, requests any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ParetoFrontier", reflect.TypeOf((*MockStatsService)(nil).ParetoFrontier), ctx, requests)
+}
+
+// Sensitivity mocks base method.
+func (m *MockStatsService) Sensitivity(ctx context.Context, requests domain.Bookings, opts domain.MaximizeOptions) (*domain.SensitivityResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Sensitivity", ctx, requests, opts)
+	ret0, _ := ret[0].(*domain.SensitivityResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Sensitivity indicates an expected call of Sensitivity.
+func (mr *MockStatsServiceMockRecorder) Sensitivity(ctx, requests, opts any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Sensitivity", reflect.TypeOf((*MockStatsService)(nil).Sensitivity), ctx, requests, opts)
+}
+
+// MockJobService is a mock of JobService interface.
+type MockJobService struct {
+	ctrl     *gomock.Controller
+	recorder *MockJobServiceMockRecorder
+	isgomock struct{}
+}
+
+// MockJobServiceMockRecorder is the mock recorder for MockJobService.
+type MockJobServiceMockRecorder struct {
+	mock *MockJobService
+}
+
+// NewMockJobService creates a new mock instance.
+func NewMockJobService(ctrl *gomock.Controller) *MockJobService {
+	mock := &MockJobService{ctrl: ctrl}
+	mock.recorder = &MockJobServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockJobService) EXPECT() *MockJobServiceMockRecorder {
+	return m.recorder
+}
+
+// Cancel mocks base method.
+func (m *MockJobService) Cancel(ctx context.Context, id string) (*domain.Job, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Cancel", ctx, id)
+	ret0, _ := ret[0].(*domain.Job)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Cancel indicates an expected call of Cancel.
+func (mr *MockJobServiceMockRecorder) Cancel(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Cancel", reflect.TypeOf((*MockJobService)(nil).Cancel), ctx, id)
+}
+
+// Get mocks base method.
+func (m *MockJobService) Get(ctx context.Context, id string) (*domain.Job, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Get", ctx, id)
+	ret0, _ := ret[0].(*domain.Job)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Get indicates an expected call of Get.
+func (mr *MockJobServiceMockRecorder) Get(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockJobService)(nil).Get), ctx, id)
+}
+
+// Submit mocks base method.
+func (m *MockJobService) Submit(ctx context.Context, requests domain.Bookings, opts domain.MaximizeOptions) (*domain.Job, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Submit", ctx, requests, opts)
+	ret0, _ := ret[0].(*domain.Job)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Submit indicates an expected call of Submit.
+func (mr *MockJobServiceMockRecorder) Submit(ctx, requests, opts any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Submit", reflect.TypeOf((*MockJobService)(nil).Submit), ctx, requests, opts)
+}
+
+// Watch mocks base method.
+func (m *MockJobService) Watch(ctx context.Context, id string) (<-chan *domain.Job, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Watch", ctx, id)
+	ret0, _ := ret[0].(<-chan *domain.Job)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Watch indicates an expected call of Watch.
+func (mr *MockJobServiceMockRecorder) Watch(ctx, id any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MaximizeProfit", reflect.TypeOf((*MockStatsService)(nil).MaximizeProfit), requests)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Watch", reflect.TypeOf((*MockJobService)(nil).Watch), ctx, id)
 }
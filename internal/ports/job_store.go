@@ -0,0 +1,19 @@
+package ports
+
+import (
+	"context"
+
+	"github.com/duksonn/stay-for-long/internal/domain"
+)
+
+// JobStore defines the interface for persisting asynchronous optimization jobs
+// Implementations must be safe for concurrent use and must not share the stored jobs with the
+// callers, so a returned job can be modified without affecting the store
+type JobStore interface {
+	// Save creates or replaces a job
+	Save(ctx context.Context, job *domain.Job) error
+
+	// Get returns the job with the given ID
+	// Returns domain.ErrJobNotFound when the job does not exist
+	Get(ctx context.Context, id string) (*domain.Job, error)
+}
@@ -0,0 +1,13 @@
+package ports
+
+import "github.com/duksonn/stay-for-long/internal/domain"
+
+// OptimizerObserver defines the interface for measuring the work done by the application services
+// Implementations must be safe for concurrent use, as jobs and requests are served in parallel
+type OptimizerObserver interface {
+	// ObserveBookings receives the number of bookings an operation is called with
+	ObserveBookings(operation string, count int)
+
+	// ObserveSearch receives the stats of every finished search for the optimal selection
+	ObserveSearch(stats domain.SearchStats)
+}
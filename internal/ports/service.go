@@ -1,13 +1,52 @@
 package ports
 
-import "github.com/duksonn/stay-for-long/internal/domain"
+import (
+	"context"
+
+	"github.com/duksonn/stay-for-long/internal/domain"
+)
 
 // StatsService defines the interface for handling stats business operations
+// Every operation honours the context: long computations stop and return an error
+// wrapping the context error once it is canceled or its deadline expires
 type StatsService interface {
 	// CalculateStats computes the average, minimum, and maximum nightly rates for a set of bookings
-	CalculateStats(requests domain.Bookings) *domain.StatsResult
+	CalculateStats(ctx context.Context, requests domain.Bookings) (*domain.StatsResult, error)
 
-	// MaximizeProfit finds the optimal combination of bookings that maximizes total profit
+	// MaximizeProfit finds the optimal combination of bookings that maximizes the objective in opts
 	// while ensuring no booking periods overlap
-	MaximizeProfit(requests domain.Bookings) *domain.MaximizeResult
+	MaximizeProfit(ctx context.Context, requests domain.Bookings, opts domain.MaximizeOptions) (*domain.MaximizeResult, error)
+
+	// ParetoFrontier returns the non-overlapping selections that are not dominated
+	// when trading total profit against occupied nights
+	ParetoFrontier(ctx context.Context, requests domain.Bookings) ([]*domain.MaximizeResult, error)
+
+	// Sensitivity computes the optimal selection and reports, for each booking, how far its selling rate
+	// or margin can move before that selection changes
+	Sensitivity(ctx context.Context, requests domain.Bookings, opts domain.MaximizeOptions) (*domain.SensitivityResult, error)
+
+	// CompareScenarios evaluates the base bookings and every what-if scenario, returning the optimal
+	// selection and stats of each one together with its differences against the base
+	CompareScenarios(ctx context.Context, base domain.Bookings, scenarios []domain.Scenario, opts domain.MaximizeOptions) (*domain.ScenarioComparison, error)
+}
+
+// JobService defines the interface for running MaximizeProfit asynchronously
+// Jobs run on a bounded pool of workers and outlive the context used to submit them
+type JobService interface {
+	// Submit queues a MaximizeProfit run and returns the queued job
+	// Returns domain.ErrJobQueueFull when the queue has no room left
+	Submit(ctx context.Context, requests domain.Bookings, opts domain.MaximizeOptions) (*domain.Job, error)
+
+	// Get returns the current state of a job
+	// Returns domain.ErrJobNotFound when the job does not exist
+	Get(ctx context.Context, id string) (*domain.Job, error)
+
+	// Cancel stops a queued or running job and returns its state
+	// Returns domain.ErrJobNotFound when the job does not exist and domain.ErrJobFinished when it already ended
+	Cancel(ctx context.Context, id string) (*domain.Job, error)
+
+	// Watch streams the state of a job every time it changes, starting with its current state
+	// The channel is closed once the job finishes or ctx is done
+	// Returns domain.ErrJobNotFound when the job does not exist
+	Watch(ctx context.Context, id string) (<-chan *domain.Job, error)
 }